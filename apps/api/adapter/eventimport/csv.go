@@ -0,0 +1,138 @@
+// Package eventimport reads events from uploaded CSV and iCalendar files.
+package eventimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+// csvColumns maps accepted header names, compared case-insensitively, to
+// fields. It covers our own template, Japanese spreadsheets and Google
+// Calendar's CSV format.
+var csvColumns = map[string]string{
+	"title": "title", "subject": "title", "タイトル": "title", "件名": "title", "イベント名": "title",
+	"startat": "startAt", "start": "startAt", "開始日時": "startAt",
+	"date": "date", "start date": "date", "日付": "date", "開始日": "date",
+	"time": "time", "start time": "time", "時刻": "time", "開始時刻": "time",
+	"location": "location", "場所": "location", "会場": "location",
+	"targets": "targets", "attendees": "targets", "対象者": "targets", "参加者": "targets",
+}
+
+var (
+	dateLayouts     = []string{"2006-01-02", "2006/1/2", "1/2/2006"}
+	timeLayouts     = []string{"15:04", "3:04 PM", "3:04PM", "15:04:05"}
+	dateTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04", "2006/1/2 15:04"}
+)
+
+// ParseCSV reads events from a CSV file with a header row. Rows are numbered
+// by the file line they start on. Dates and times
+// without a zone are in domain.Location. Targets are separated by ";", "、"
+// or line breaks.
+func ParseCSV(data []byte) ([]*usecase.EventImportRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel BOM
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, domain.NewValidationError("file", "could not read CSV header: "+err.Error())
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		if field, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, domain.NewValidationError("file", "CSV header must have a title column")
+	}
+	_, hasStart := columns["startAt"]
+	if _, hasDate := columns["date"]; !hasStart && !hasDate {
+		return nil, domain.NewValidationError("file", "CSV header must have a startAt or date column")
+	}
+
+	var rows []*usecase.EventImportRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, domain.NewValidationError("file", "could not read CSV: "+err.Error())
+		}
+		line, _ := r.FieldPos(0) // rows are numbered by the line they start on
+		get := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue // blank line
+		}
+
+		row := &usecase.EventImportRow{
+			Row:      line,
+			Title:    get("title"),
+			Location: get("location"),
+			Targets:  splitTargets(get("targets")),
+		}
+		if start, err := csvStart(get("startAt"), get("date"), get("time")); err != nil {
+			row.Errors = append(row.Errors, domain.FieldError{Field: "startAt", Message: err.Error()})
+		} else {
+			row.StartAt = start
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// csvStart combines the start columns of a row.
+func csvStart(startAt, date, clock string) (time.Time, error) {
+	if startAt != "" {
+		if t, ok := parseAny(dateTimeLayouts, startAt); ok {
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("could not read %q as a date and time", startAt)
+	}
+	if date == "" {
+		return time.Time{}, nil // reported as missing
+	}
+	day, ok := parseAny(dateLayouts, date)
+	if !ok {
+		return time.Time{}, fmt.Errorf("could not read %q as a date", date)
+	}
+	if clock == "" {
+		return day, nil
+	}
+	t, ok := parseAny(timeLayouts, strings.ToUpper(clock))
+	if !ok {
+		return time.Time{}, fmt.Errorf("could not read %q as a time", clock)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, domain.Location), nil
+}
+
+func parseAny(layouts []string, s string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, domain.Location); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func splitTargets(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ';' || r == '、' || r == '\n'
+	})
+}
@@ -0,0 +1,93 @@
+package eventimport
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := "\xef\xbb\xbf" + `件名,開始日,開始時刻,場所,参加者
+"練習, 午前の部",2026/11/7,9:30 AM,"体育館 ""A""",taro@example.com;佐藤花子
+夏合宿,2026-08-01,,,"山田 太郎
+鈴木 一郎"
+
+日付不明,2026-13-01,10:00,,
+時刻不明,2026-11-08,25:00,,
+`
+	rows, err := ParseCSV([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4", len(rows))
+	}
+
+	first := rows[0]
+	if first.Row != 2 || first.Title != "練習, 午前の部" || first.Location != `体育館 "A"` {
+		t.Errorf("first row = %+v", first)
+	}
+	if want := time.Date(2026, 11, 7, 9, 30, 0, 0, domain.Location); !first.StartAt.Equal(want) {
+		t.Errorf("first start = %v, want %v", first.StartAt, want)
+	}
+	if want := []string{"taro@example.com", "佐藤花子"}; !slices.Equal(first.Targets, want) {
+		t.Errorf("first targets = %q, want %q", first.Targets, want)
+	}
+
+	camp := rows[1]
+	if want := time.Date(2026, 8, 1, 0, 0, 0, 0, domain.Location); !camp.StartAt.Equal(want) {
+		t.Errorf("all-day start = %v, want %v", camp.StartAt, want)
+	}
+	if want := []string{"山田 太郎", "鈴木 一郎"}; !slices.Equal(camp.Targets, want) {
+		t.Errorf("multi-line targets = %q, want %q", camp.Targets, want)
+	}
+
+	// The blank line is skipped but still counts towards row numbers.
+	for i, row := range rows[2:] {
+		if row.Row != 6+i {
+			t.Errorf("row %q numbered %d, want %d", row.Title, row.Row, 6+i)
+		}
+		if len(row.Errors) != 1 || row.Errors[0].Field != "startAt" || !row.StartAt.IsZero() {
+			t.Errorf("row %q: errors = %+v, start = %v", row.Title, row.Errors, row.StartAt)
+		}
+	}
+}
+
+func TestParseCSVStartAtColumn(t *testing.T) {
+	rows, err := ParseCSV([]byte("title,startAt\n練習,2026-11-07T09:30:00Z\n練習,2026-11-07 18:00\n練習,来週\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{
+		time.Date(2026, 11, 7, 9, 30, 0, 0, time.UTC),
+		time.Date(2026, 11, 7, 18, 0, 0, 0, domain.Location),
+		{},
+	}
+	for i, row := range rows {
+		if !row.StartAt.Equal(want[i]) {
+			t.Errorf("row %d start = %v, want %v", row.Row, row.StartAt, want[i])
+		}
+	}
+	if len(rows[2].Errors) != 1 {
+		t.Errorf("unreadable startAt: errors = %+v", rows[2].Errors)
+	}
+}
+
+func TestParseCSVRejectsFile(t *testing.T) {
+	tests := map[string]string{
+		"empty":         "",
+		"no title":      "date,location\n2026-11-07,体育館\n",
+		"no start":      "title,location\n練習,体育館\n",
+		"broken quotes": "title,date\n\"練習,2026-11-07\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseCSV([]byte(data)); !errors.Is(err, domain.ErrInvalidInput) {
+				t.Errorf("err = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package eventimport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+// icsProperty is one content line of an iCalendar file.
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// ParseICS reads the VEVENTs of an iCalendar (RFC 5545) file. SUMMARY,
+// DTSTART, LOCATION and ATTENDEE (email, or CN when there is none) are used.
+// Recurring events are reported as invalid rows rather than expanded, and
+// cancelled events are left out.
+func ParseICS(data []byte) ([]*usecase.EventImportRow, error) {
+	props, err := readICSProperties(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*usecase.EventImportRow
+	var row *usecase.EventImportRow
+	cancelled := false
+	n := 0
+	for _, p := range props {
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			n++
+			row = &usecase.EventImportRow{Row: n}
+			cancelled = false
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			if row != nil && !cancelled {
+				rows = append(rows, row)
+			}
+			row = nil
+		case row == nil:
+			// Calendar-level property or another component.
+		case p.name == "SUMMARY":
+			row.Title = icsUnescape(p.value)
+		case p.name == "LOCATION":
+			row.Location = icsUnescape(p.value)
+		case p.name == "DTSTART":
+			t, err := icsTime(p)
+			if err != nil {
+				row.Errors = append(row.Errors, domain.FieldError{Field: "startAt", Message: err.Error()})
+				continue
+			}
+			row.StartAt = t
+		case p.name == "ATTENDEE":
+			target := strings.TrimPrefix(strings.TrimPrefix(p.value, "mailto:"), "MAILTO:")
+			if !strings.Contains(target, "@") && p.params["CN"] != "" {
+				target = p.params["CN"]
+			}
+			row.Targets = append(row.Targets, target)
+		case p.name == "RRULE":
+			row.Errors = append(row.Errors, domain.FieldError{Field: "startAt", Message: "recurring events are not supported; create a practice series instead"})
+		case p.name == "STATUS" && strings.EqualFold(p.value, "CANCELLED"):
+			cancelled = true
+		}
+	}
+	return rows, nil
+}
+
+// readICSProperties unfolds and splits the content lines of a file.
+func readICSProperties(data []byte) ([]icsProperty, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !bytes.Contains(data[:min(len(data), 512)], []byte("BEGIN:VCALENDAR")) {
+		return nil, domain.NewValidationError("file", "not an iCalendar file")
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, domain.NewValidationError("file", "could not read iCalendar file: "+err.Error())
+	}
+
+	props := make([]icsProperty, 0, len(lines))
+	for _, line := range lines {
+		props = append(props, parseICSLine(line))
+	}
+	return props, nil
+}
+
+// parseICSLine splits "NAME;PARAM=value;PARAM=\"quoted:value\":VALUE".
+func parseICSLine(line string) icsProperty {
+	p := icsProperty{params: make(map[string]string)}
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	head := line
+	if colon >= 0 {
+		head, p.value = line[:colon], line[colon+1:]
+	}
+	parts := strings.Split(head, ";")
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p
+}
+
+// icsTime parses a DTSTART value: UTC, floating (read in domain.Location),
+// with a TZID, or an all-day DATE.
+func icsTime(p icsProperty) (time.Time, error) {
+	loc := domain.Location
+	if tzid := p.params["TZID"]; tzid != "" && tzid != "Asia/Tokyo" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q", tzid)
+		}
+		loc = l
+	}
+	v := p.value
+	switch {
+	case p.params["VALUE"] == "DATE" || len(v) == 8:
+		return parseIn("20060102", v, loc)
+	case strings.HasSuffix(v, "Z"):
+		return parseIn("20060102T150405Z", v, time.UTC)
+	default:
+		return parseIn("20060102T150405", v, loc)
+	}
+}
+
+func parseIn(layout, value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not read %q as a date and time", value)
+	}
+	return t, nil
+}
+
+var icsUnescape = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace
@@ -0,0 +1,113 @@
+package eventimport
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+func TestParseICS(t *testing.T) {
+	data := strings.ReplaceAll(`BEGIN:VCALENDAR
+VERSION:2.0
+X-WR-CALNAME:サークル
+BEGIN:VEVENT
+SUMMARY:定期練習\, 午前
+DTSTART;TZID=America/New_York:20261107T093000
+LOCATION:Gym\; Court 2
+ATTENDEE;CN=Taro:mailto:taro@example.com
+ATTENDEE;CN="佐藤 花子";RSVP=TRUE:urn:invalid
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:とても長いイベント名が
+ 折り返されて
+	続く
+DTSTART;TZID=Asia/Tokyo:20261108T180000
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:合宿
+DTSTART;VALUE=DATE:20260801
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:UTC
+DTSTART:20261109T010000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:中止
+DTSTART:20261110T100000
+STATUS:CANCELLED
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:毎週
+DTSTART:20261111T100000
+RRULE:FREQ=WEEKLY
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:不明なゾーン
+DTSTART;TZID=Mars/Olympus:20261112T100000
+END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n")
+
+	rows, err := ParseICS([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, row := range rows {
+		titles = append(titles, row.Title)
+	}
+	want := []string{"定期練習, 午前", "とても長いイベント名が折り返されて続く", "合宿", "UTC", "毎週", "不明なゾーン"}
+	if !slices.Equal(titles, want) {
+		t.Fatalf("titles = %q, want %q", titles, want)
+	}
+	if rows[4].Row != 6 {
+		t.Errorf("row after a cancelled event numbered %d, want 6", rows[4].Row)
+	}
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	starts := []time.Time{
+		time.Date(2026, 11, 7, 9, 30, 0, 0, ny),
+		time.Date(2026, 11, 8, 18, 0, 0, 0, domain.Location),
+		time.Date(2026, 8, 1, 0, 0, 0, 0, domain.Location),
+		time.Date(2026, 11, 9, 1, 0, 0, 0, time.UTC),
+		time.Date(2026, 11, 11, 10, 0, 0, 0, domain.Location),
+	}
+	for i, want := range starts {
+		if !rows[i].StartAt.Equal(want) {
+			t.Errorf("%s start = %v, want %v", rows[i].Title, rows[i].StartAt, want)
+		}
+	}
+
+	first := rows[0]
+	if first.Location != "Gym; Court 2" {
+		t.Errorf("location = %q", first.Location)
+	}
+	if want := []string{"taro@example.com", "佐藤 花子"}; !slices.Equal(first.Targets, want) {
+		t.Errorf("targets = %q, want %q", first.Targets, want)
+	}
+	for _, row := range rows[:4] {
+		if len(row.Errors) != 0 {
+			t.Errorf("%s: unexpected errors %+v", row.Title, row.Errors)
+		}
+	}
+	for _, row := range rows[4:] {
+		if len(row.Errors) != 1 || row.Errors[0].Field != "startAt" {
+			t.Errorf("%s: errors = %+v, want one on startAt", row.Title, row.Errors)
+		}
+	}
+}
+
+func TestParseICSRejectsOtherFiles(t *testing.T) {
+	for _, data := range []string{"", "title,date\n練習,2026-11-07\n", "BEGIN:VCARD\r\nEND:VCARD\r\n"} {
+		if _, err := ParseICS([]byte(data)); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("ParseICS(%q) err = %v, want ErrInvalidInput", data, err)
+		}
+	}
+}
@@ -5,127 +5,314 @@ import "time"
 
 // CreateCircleRequest represents request to create a circle.
 type CreateCircleRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	LogoURL     string `json:"logoUrl"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=1000"`
+	LogoURL     string `json:"logoUrl" validate:"url"`
+}
+
+// UpdateCirclePaymentSettingsRequest represents request to set a circle's payment instructions.
+type UpdateCirclePaymentSettingsRequest struct {
+	BankInfo   string `json:"bankInfo" validate:"max=500"`
+	PayPayInfo string `json:"paypayInfo" validate:"max=500"`
 }
 
 // AddMemberRequest represents request to add a member.
 type AddMemberRequest struct {
-	UserID string `json:"userId"`
-	Role   string `json:"role"`
+	UserID string   `json:"userId" validate:"required"`
+	Role   string   `json:"role" validate:"oneof=ADMIN MEMBER"`
+	Tags   []string `json:"tags" validate:"max=10"`
+}
+
+// SetMemberTagsRequest represents request to replace the tags of a member.
+type SetMemberTagsRequest struct {
+	Tags []string `json:"tags" validate:"max=10"`
 }
 
 // CreateEventRequest represents request to create an event.
 type CreateEventRequest struct {
-	CircleID          string    `json:"circleId"`
-	Title             string    `json:"title"`
-	StartAt           time.Time `json:"startAt"`
-	Location          string    `json:"location"`
-	CoverImageURL     string    `json:"coverImageUrl"`
+	CircleID          string    `json:"circleId" validate:"required"`
+	Title             string    `json:"title" validate:"required,max=100"`
+	StartAt           time.Time `json:"startAt" validate:"required"`
+	Location          string    `json:"location" validate:"max=200"`
+	CoverImageURL     string    `json:"coverImageUrl" validate:"url"`
 	RSVPTargetUserIDs []string  `json:"rsvpTargetUserIds"`
-	CreatedBy         string    `json:"createdBy"`
+	Capacity          int       `json:"capacity" validate:"min=0,max=10000"` // 0 means unlimited
+	RSVPDeadline      time.Time `json:"rsvpDeadline"`                        // zero means until startAt
 }
 
 // CreateAnnouncementRequest represents request to create an announcement.
 type CreateAnnouncementRequest struct {
-	CircleID  string `json:"circleId"`
-	EventID   string `json:"eventId"`
-	Title     string `json:"title"`
-	Body      string `json:"body"`
-	CreatedBy string `json:"createdBy"`
+	CircleID string `json:"circleId" validate:"required"`
+	EventID  string `json:"eventId"`
+	Title    string `json:"title" validate:"required,max=100"`
+	Body     string `json:"body" validate:"required,max=10000"`
 }
 
 // RSVPRequest represents request to submit RSVP.
 type RSVPRequest struct {
-	Status string `json:"status"` // GO, NO, LATE, EARLY
-	Note   string `json:"note"`
+	Status string `json:"status" validate:"required,oneof=GO NO LATE EARLY"` // GO, NO, LATE, EARLY
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// OverrideRSVPRequest represents request by an admin to set a member's RSVP.
+type OverrideRSVPRequest struct {
+	RSVPRequest
+	Reason string `json:"reason" validate:"max=500"` // required after the deadline and recorded in the audit log
 }
 
 // CreateSettlementRequest represents request to create a settlement.
 type CreateSettlementRequest struct {
-	CircleID      string    `json:"circleId"`
-	EventID       string    `json:"eventId"`
-	Title         string    `json:"title"`
-	Amount        int       `json:"amount"`
-	DueAt         time.Time `json:"dueAt"`
-	TargetUserIDs []string  `json:"targetUserIds"`
-	BankInfo      string    `json:"bankInfo"`
-	PayPayInfo    string    `json:"paypayInfo"`
+	CircleID      string               `json:"circleId" validate:"required"`
+	EventID       string               `json:"eventId"`
+	Title         string               `json:"title" validate:"required,max=100"`
+	Amount        int                  `json:"amount" validate:"min=0"` // computed when lineItems are given
+	DueAt         time.Time            `json:"dueAt" validate:"required,future"`
+	TargetUserIDs []string             `json:"targetUserIds"`
+	BankInfo      string               `json:"bankInfo" validate:"max=500"`
+	PayPayInfo    string               `json:"paypayInfo" validate:"max=500"`
+	LineItems     []SettlementLineItem `json:"lineItems" validate:"max=50"`
+	PricingRules  []PricingRule        `json:"pricingRules" validate:"max=20"`
+}
+
+// CreateSplitSettlementRequest represents request to split a total expense
+// of an event among its attendees.
+type CreateSplitSettlementRequest struct {
+	Title       string    `json:"title" validate:"required,max=100"`
+	TotalAmount int       `json:"totalAmount" validate:"min=1"`
+	Rounding    string    `json:"rounding" validate:"oneof=ORGANIZER_ABSORBS ROUND_UP SPREAD"` // defaults to ORGANIZER_ABSORBS
+	DueAt       time.Time `json:"dueAt" validate:"required,future"`
+	BankInfo    string    `json:"bankInfo" validate:"max=500"`
+	PayPayInfo  string    `json:"paypayInfo" validate:"max=500"`
+}
+
+// PricingRule represents a rule changing what matching targets of a settlement owe.
+type PricingRule struct {
+	Match   string `json:"match" validate:"required,oneof=ROLE TAG RSVP USER"`
+	Value   string `json:"value" validate:"required,max=100"`
+	Percent *int   `json:"percent"` // exactly one of percent and amount
+	Amount  *int   `json:"amount"`
+}
+
+// SetPricingRulesRequest represents request to replace the pricing rules of a settlement.
+type SetPricingRulesRequest struct {
+	PricingRules []PricingRule `json:"pricingRules" validate:"max=20"`
+}
+
+// SettlementLineItem represents one part of what each target of a settlement owes.
+type SettlementLineItem struct {
+	Label     string         `json:"label" validate:"required,max=100"`
+	Amount    int            `json:"amount" validate:"min=0"`
+	Overrides map[string]int `json:"overrides"` // amount by target user ID
 }
 
 // ReportPaymentRequest represents request to report a payment.
 type ReportPaymentRequest struct {
-	Method string `json:"method"` // BANK, PAYPAY
-	Note   string `json:"note"`
+	Method string `json:"method" validate:"required,oneof=BANK PAYPAY"` // BANK, PAYPAY
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// RejectPaymentRequest represents request to reject a reported payment.
+type RejectPaymentRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// BulkConfirmPaymentsRequest represents request to confirm several payments at once.
+// An empty UserIDs confirms every reported payment of the settlement.
+type BulkConfirmPaymentsRequest struct {
+	UserIDs []string `json:"userIds"`
 }
 
 // ChatRequest represents request for AI chat.
 type ChatRequest struct {
-	CircleID string `json:"circleId"`
-	Message  string `json:"message"`
+	CircleID string `json:"circleId" validate:"required"`
+	Message  string `json:"message" validate:"required,max=2000"`
 }
 
-// UpdateUserRequest represents request to update user profile.
+// UpdateUserRequest represents request to update the caller's profile.
 type UpdateUserRequest struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	AvatarURL string `json:"avatarUrl"`
+	Name      string `json:"name" validate:"max=50"`
+	Email     string `json:"email" validate:"max=254,email"`
+	AvatarURL string `json:"avatarUrl" validate:"url"`
+}
+
+// UpdateNotificationPreferencesRequest represents request to set the caller's notification channels.
+type UpdateNotificationPreferencesRequest struct {
+	Channels   []string `json:"channels" validate:"max=4"` // EMAIL, LINE, WEBHOOK, WEB_PUSH
+	LINEUserID string   `json:"lineUserId" validate:"max=64"`
+	WebhookURL string   `json:"webhookUrl" validate:"max=2000,url"`
+}
+
+// PushSubscriptionKeys are the keys of a browser's Web Push subscription.
+type PushSubscriptionKeys struct {
+	P256dh string `json:"p256dh" validate:"required,max=200"`
+	Auth   string `json:"auth" validate:"required,max=100"`
+}
+
+// PushSubscriptionRequest represents a browser's Web Push subscription,
+// as returned by PushSubscription.toJSON().
+type PushSubscriptionRequest struct {
+	Endpoint string               `json:"endpoint" validate:"required,max=2000,url"`
+	Keys     PushSubscriptionKeys `json:"keys"`
+}
+
+// RemovePushSubscriptionRequest represents request to unsubscribe a browser from Web Push.
+type RemovePushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" validate:"required"`
 }
 
 // UpdateEventRequest represents request to update an event.
 type UpdateEventRequest struct {
-	Title             string    `json:"title"`
-	StartAt           time.Time `json:"startAt"`
-	Location          string    `json:"location"`
-	CoverImageURL     string    `json:"coverImageUrl"`
+	Title             string    `json:"title" validate:"required,max=100"`
+	StartAt           time.Time `json:"startAt" validate:"required"`
+	Location          string    `json:"location" validate:"max=200"`
+	CoverImageURL     string    `json:"coverImageUrl" validate:"url"`
 	RSVPTargetUserIDs []string  `json:"rsvpTargetUserIds"`
+	Capacity          int       `json:"capacity" validate:"min=0,max=10000"` // 0 means unlimited
+	RSVPDeadline      time.Time `json:"rsvpDeadline"`                        // zero means until startAt
+}
+
+// UpdateAnnouncementRequest represents request to update an announcement.
+type UpdateAnnouncementRequest struct {
+	Title string `json:"title" validate:"required,max=100"`
+	Body  string `json:"body" validate:"required,max=10000"`
 }
 
 // UpdateSettlementRequest represents request to update a settlement.
 type UpdateSettlementRequest struct {
-	Title  string    `json:"title"`
-	Amount int       `json:"amount"`
-	DueAt  time.Time `json:"dueAt"`
+	Title     string               `json:"title" validate:"required,max=100"`
+	Amount    int                  `json:"amount" validate:"min=0"` // computed when lineItems are given
+	DueAt     time.Time            `json:"dueAt" validate:"required"`
+	LineItems []SettlementLineItem `json:"lineItems" validate:"max=50"`
 }
 
 // CreatePracticeCategoryRequest represents request to create a practice category.
 type CreatePracticeCategoryRequest struct {
-	CircleID string `json:"circleId"`
-	Name     string `json:"name"`
+	CircleID string `json:"circleId" validate:"required"`
+	Name     string `json:"name" validate:"required,max=50"`
 	ParentID string `json:"parentId"` // optional
-	Order    int    `json:"order"`
+	Order    int    `json:"order" validate:"min=0"`
 }
 
 // CreatePracticeSeriesRequest represents request to create a practice series.
 type CreatePracticeSeriesRequest struct {
-	CircleID   string `json:"circleId"`
-	CategoryID string `json:"categoryId"`
-	Name       string `json:"name"`
-	DayOfWeek  int    `json:"dayOfWeek"`
-	StartTime  string `json:"startTime"`
-	Location   string `json:"location"`
-	Fee        int    `json:"fee"`
+	CircleID   string `json:"circleId" validate:"required"`
+	CategoryID string `json:"categoryId" validate:"required"`
+	Name       string `json:"name" validate:"required,max=100"`
+	DayOfWeek  int    `json:"dayOfWeek" validate:"min=0,max=6"`
+	StartTime  string `json:"startTime" validate:"required,hhmm"`
+	PracticeScheduleFields
+	Location string `json:"location" validate:"max=200"`
+	Fee      int    `json:"fee" validate:"min=0"`
+}
+
+// PracticeScheduleFields are the optional recurrence fields of a practice
+// series. Without a recurrence the series repeats weekly on dayOfWeek.
+type PracticeScheduleFields struct {
+	Recurrence      string   `json:"recurrence" validate:"max=200"` // RRULE, e.g. "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA"
+	StartDate       string   `json:"startDate" validate:"date"`
+	ExDates         []string `json:"exDates" validate:"max=366"`
+	EndTime         string   `json:"endTime" validate:"hhmm"` // takes precedence over durationMinutes
+	DurationMinutes int      `json:"durationMinutes" validate:"min=0,max=1440"`
+}
+
+// UpdatePracticeSeriesRequest represents request to update a practice series.
+type UpdatePracticeSeriesRequest struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	DayOfWeek int    `json:"dayOfWeek" validate:"min=0,max=6"`
+	StartTime string `json:"startTime" validate:"required,hhmm"`
+	PracticeScheduleFields
+	Location string `json:"location" validate:"max=200"`
+	Fee      int    `json:"fee" validate:"min=0"`
 }
 
 // CreatePracticeSessionRequest represents request to create a practice session.
 type CreatePracticeSessionRequest struct {
-	Date time.Time `json:"date"`
-	Note string    `json:"note"`
+	Date time.Time `json:"date" validate:"required"`
+	Note string    `json:"note" validate:"max=500"`
+}
+
+// UpdatePracticeSessionRequest represents request to update a practice session.
+type UpdatePracticeSessionRequest struct {
+	Date         time.Time `json:"date" validate:"required"`
+	EndAt        time.Time `json:"endAt"`
+	Note         string    `json:"note" validate:"max=500"`
+	Cancelled    bool      `json:"cancelled"`
+	RSVPDeadline time.Time `json:"rsvpDeadline"` // zero means until the session starts
+}
+
+// CancelPracticeSessionRequest represents request to cancel a practice session.
+type CancelPracticeSessionRequest struct {
+	Reason string `json:"reason" validate:"required,max=200"`
+}
+
+// ReschedulePracticeSessionRequest represents request to move a practice session.
+type ReschedulePracticeSessionRequest struct {
+	Date   time.Time `json:"date" validate:"required"`
+	EndAt  time.Time `json:"endAt"`
+	Reason string    `json:"reason" validate:"required,max=200"`
+}
+
+// CreateCalendarExceptionRequest represents request to add a circle calendar exception.
+type CreateCalendarExceptionRequest struct {
+	Kind      string `json:"kind" validate:"required,oneof=CLOSURE EXAM HOLIDAY OTHER"`
+	Title     string `json:"title" validate:"required,max=100"`
+	StartDate string `json:"startDate" validate:"required,date"`
+	EndDate   string `json:"endDate" validate:"date"` // defaults to startDate
+	Action    string `json:"action" validate:"required,oneof=CANCEL SKIP"`
+}
+
+// ImportHolidaysRequest represents request to import national holidays as calendar exceptions.
+type ImportHolidaysRequest struct {
+	Year   int    `json:"year" validate:"min=2000,max=2100"`
+	Action string `json:"action" validate:"required,oneof=CANCEL SKIP"`
+}
+
+// GeneratePracticeSessionsRequest represents request to generate the sessions of a series.
+// Sessions are generated from From (default today) through To inclusive,
+// or for Weeks weeks (default 8) when To is omitted.
+type GeneratePracticeSessionsRequest struct {
+	From  string `json:"from" validate:"date"` // "2024-04-01"
+	To    string `json:"to" validate:"date"`
+	Weeks int    `json:"weeks" validate:"min=0,max=52"`
 }
 
 // PracticeRSVPRequest represents request to submit practice RSVP.
 type PracticeRSVPRequest struct {
-	Status string `json:"status"` // GO, NO
+	Status string `json:"status" validate:"required,oneof=GO NO"` // GO, NO
+}
+
+// OverridePracticeRSVPRequest represents request by an admin to set a member's practice RSVP.
+type OverridePracticeRSVPRequest struct {
+	PracticeRSVPRequest
+	Reason string `json:"reason" validate:"max=500"` // required after the deadline and recorded in the audit log
 }
 
 // BulkPracticeRSVPItem represents a single item in bulk RSVP.
 type BulkPracticeRSVPItem struct {
-	SessionID string `json:"sessionId"`
-	Status    string `json:"status"` // GO, NO
+	SessionID string `json:"sessionId" validate:"required"`
+	Status    string `json:"status" validate:"required,oneof=GO NO"` // GO, NO
 }
 
 // BulkPracticeRSVPRequest represents request to bulk submit practice RSVPs.
 type BulkPracticeRSVPRequest struct {
-	RSVPs []BulkPracticeRSVPItem `json:"rsvps"`
+	RSVPs []BulkPracticeRSVPItem `json:"rsvps" validate:"required"`
+}
+
+// CreateMonthlyInvoicesRequest represents request to bill a circle's practice fees for a month.
+type CreateMonthlyInvoicesRequest struct {
+	Month string `json:"month" validate:"required,yyyymm"`
+}
+
+// CreatePracticeSettlementsRequest represents request to create monthly practice settlements.
+type CreatePracticeSettlementsRequest struct {
+	Month string `json:"month" validate:"required,yyyymm"` // e.g. "2024-04"
+}
+
+// UpdateReminderPolicyRequest represents request to set a circle's reminder policy.
+// Offsets are days before the event or due date, or after it for overdue payments.
+type UpdateReminderPolicyRequest struct {
+	Enabled           bool  `json:"enabled"`
+	RSVPDaysBefore    []int `json:"rsvpDaysBefore" validate:"max=5"`
+	PaymentDaysBefore []int `json:"paymentDaysBefore" validate:"max=5"`
+	PaymentDaysAfter  []int `json:"paymentDaysAfter" validate:"max=5"`
 }
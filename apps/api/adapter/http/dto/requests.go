@@ -0,0 +1,56 @@
+package dto
+
+import "errors"
+
+// requests lists every request body the handlers decode, so that their
+// `validate` tags can be checked when the server starts.
+var requests = []interface{}{
+	CreateCircleRequest{},
+	UpdateCirclePaymentSettingsRequest{},
+	AddMemberRequest{},
+	SetMemberTagsRequest{},
+	CreateEventRequest{},
+	CreateAnnouncementRequest{},
+	RSVPRequest{},
+	OverrideRSVPRequest{},
+	CreateSettlementRequest{},
+	CreateSplitSettlementRequest{},
+	SetPricingRulesRequest{},
+	ReportPaymentRequest{},
+	RejectPaymentRequest{},
+	BulkConfirmPaymentsRequest{},
+	ChatRequest{},
+	UpdateUserRequest{},
+	UpdateNotificationPreferencesRequest{},
+	PushSubscriptionRequest{},
+	RemovePushSubscriptionRequest{},
+	UpdateEventRequest{},
+	UpdateAnnouncementRequest{},
+	UpdateSettlementRequest{},
+	CreatePracticeCategoryRequest{},
+	CreatePracticeSeriesRequest{},
+	UpdatePracticeSeriesRequest{},
+	CreatePracticeSessionRequest{},
+	UpdatePracticeSessionRequest{},
+	CancelPracticeSessionRequest{},
+	ReschedulePracticeSessionRequest{},
+	CreateCalendarExceptionRequest{},
+	ImportHolidaysRequest{},
+	GeneratePracticeSessionsRequest{},
+	PracticeRSVPRequest{},
+	OverridePracticeRSVPRequest{},
+	BulkPracticeRSVPRequest{},
+	CreateMonthlyInvoicesRequest{},
+	CreatePracticeSettlementsRequest{},
+	UpdateReminderPolicyRequest{},
+}
+
+// CheckRequests checks the `validate` tags of every request DTO with
+// CheckTags.
+func CheckRequests() error {
+	var errs []error
+	for _, r := range requests {
+		errs = append(errs, CheckTags(r))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,291 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// Validate checks v against the `validate` struct tags of its fields and
+// returns a *domain.ValidationError listing every invalid field, or nil.
+//
+// Supported rules (comma separated):
+//
+//	required   value must not be empty (blank strings count as empty)
+//	min=N      numbers must be >= N, strings/slices must have length >= N
+//	max=N      numbers must be <= N, strings/slices must have length <= N
+//	oneof=A B  value must be one of the space separated options
+//	hhmm       string must be a 24-hour "HH:MM" time
+//	yyyymm     string must be a "YYYY-MM" month
+//	date       string must be a "YYYY-MM-DD" date
+//	url        string must be an absolute http(s) URL
+//	email      string must be a plain email address
+//	future     time must be after now
+//
+// Rules other than required are skipped for empty strings, slices and times,
+// so optional fields only need to be valid when present. Numbers are always
+// checked. Nested structs and slices of structs are validated recursively;
+// fields of embedded structs are reported without a prefix.
+//
+// A tag that is invalid for its field is a programming error, reported as a
+// plain error rather than invalid input; CheckTags finds such tags up front.
+func Validate(v interface{}) error {
+	verr := &domain.ValidationError{}
+	if err := validateStruct(reflect.Indirect(reflect.ValueOf(v)), "", verr); err != nil {
+		return err
+	}
+	return verr.ErrOrNil()
+}
+
+func validateStruct(rv reflect.Value, prefix string, verr *domain.ValidationError) error {
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := prefix + fieldName(sf)
+		fv := rv.Field(i)
+
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			msg, err := checkRules(fv, tag)
+			if err != nil {
+				return fmt.Errorf("dto: %s.%s: %w", rt.Name(), sf.Name, err)
+			}
+			if msg != "" {
+				verr.Add(name, msg)
+				continue
+			}
+		}
+
+		var err error
+		switch {
+		case sf.Anonymous && fv.Kind() == reflect.Struct:
+			err = validateStruct(fv, prefix, verr) // embedded fields are flattened in JSON
+		case fv.Kind() == reflect.Struct && fv.Type() != timeType:
+			err = validateStruct(fv, name+".", verr)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < fv.Len() && err == nil; j++ {
+				err = validateStruct(fv.Index(j), fmt.Sprintf("%s[%d].", name, j), verr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// CheckTags checks the `validate` tags of the struct type of v, and of the
+// structs it nests, without validating a value. It reports every rule that
+// is unknown, malformed or not supported for its field's type.
+func CheckTags(v interface{}) error {
+	rt := reflect.TypeOf(v)
+	for rt != nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return fmt.Errorf("dto: %T is not a struct", v)
+	}
+	var errs []error
+	checkStructTags(rt, &errs)
+	return errors.Join(errs...)
+}
+
+func checkStructTags(rt reflect.Type, errs *[]error) {
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				name, arg, _ := strings.Cut(rule, "=")
+				if err := checkRuleTag(sf.Type, name, arg); err != nil {
+					*errs = append(*errs, fmt.Errorf("dto: %s.%s: %w", rt.Name(), sf.Name, err))
+				}
+			}
+		}
+
+		switch ft := sf.Type; {
+		case ft.Kind() == reflect.Struct && ft != timeType:
+			checkStructTags(ft, errs)
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+			checkStructTags(ft.Elem(), errs)
+		}
+	}
+}
+
+// checkRuleTag checks that a rule is known, has a valid argument and
+// supports fields of type t.
+func checkRuleTag(t reflect.Type, name, arg string) error {
+	switch name {
+	case "required":
+		return nil
+	case "min", "max":
+		if _, err := strconv.Atoi(arg); err != nil {
+			return fmt.Errorf("bad %s rule %q", name, arg)
+		}
+		switch t.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map:
+			return nil
+		}
+		if isNumberKind(t.Kind()) {
+			return nil
+		}
+	case "oneof":
+		if len(strings.Fields(arg)) == 0 {
+			return errors.New("oneof rule without options")
+		}
+		if t.Kind() == reflect.String {
+			return nil
+		}
+	case "hhmm", "yyyymm", "date", "url", "email":
+		if t.Kind() == reflect.String {
+			return nil
+		}
+	case "future":
+		if t == timeType {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown validation rule %q", name)
+	}
+	return fmt.Errorf("%s rule not supported for %s", name, t)
+}
+
+// fieldName returns the JSON name of a field so errors match the request body.
+func fieldName(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
+
+// checkRules returns the message of the first failing rule, or "". It
+// fails when a rule is invalid for the field.
+func checkRules(fv reflect.Value, tag string) (string, error) {
+	empty := isEmpty(fv)
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if err := checkRuleTag(fv.Type(), name, arg); err != nil {
+			return "", err
+		}
+		if name == "required" {
+			if empty {
+				return "is required", nil
+			}
+			continue
+		}
+		if empty && !isNumberKind(fv.Kind()) {
+			continue
+		}
+		if msg := checkRule(fv, name, arg); msg != "" {
+			return msg, nil
+		}
+	}
+	return "", nil
+}
+
+// checkRule returns the message of a failing rule, or "". The rule must
+// have passed checkRuleTag for the field.
+func checkRule(fv reflect.Value, name, arg string) string {
+	switch name {
+	case "min", "max":
+		limit, _ := strconv.Atoi(arg)
+		n, isLen := measure(fv)
+		if (name == "min" && n >= limit) || (name == "max" && n <= limit) {
+			return ""
+		}
+		bound := "at least"
+		if name == "max" {
+			bound = "at most"
+		}
+		if isLen {
+			return fmt.Sprintf("length must be %s %d", bound, limit)
+		}
+		return fmt.Sprintf("must be %s %d", bound, limit)
+	case "oneof":
+		options := strings.Fields(arg)
+		for _, o := range options {
+			if fv.String() == o {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(options, ", ")
+	case "hhmm":
+		if _, err := time.Parse("15:04", fv.String()); err != nil || len(fv.String()) != 5 {
+			return "must be a time in HH:MM format"
+		}
+	case "yyyymm":
+		if _, err := time.Parse("2006-01", fv.String()); err != nil {
+			return "must be a month in YYYY-MM format"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", fv.String()); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	case "url":
+		u, err := url.Parse(fv.String())
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an http(s) URL"
+		}
+	case "email":
+		addr, err := mail.ParseAddress(fv.String())
+		if err != nil || addr.Address != fv.String() {
+			return "must be an email address"
+		}
+	case "future":
+		if t, ok := fv.Interface().(time.Time); ok && !t.After(time.Now()) {
+			return "must be in the future"
+		}
+	}
+	return ""
+}
+
+// measure returns the numeric value of fv, or its length for strings, slices
+// and maps (in which case isLen is true). checkRuleTag rules out other kinds.
+func measure(fv reflect.Value) (n int, isLen bool) {
+	switch fv.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(fv.String()), true
+	case reflect.Slice, reflect.Map:
+		return fv.Len(), true
+	}
+	return int(fv.Int()), false
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(fv.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	case reflect.Struct:
+		if t, ok := fv.Interface().(time.Time); ok {
+			return t.IsZero()
+		}
+	}
+	return fv.IsZero()
+}
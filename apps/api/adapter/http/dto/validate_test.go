@@ -0,0 +1,131 @@
+package dto
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+func TestRequestTagsAreValid(t *testing.T) {
+	if err := CheckRequests(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestEveryRequestIsChecked makes sure new request DTOs are added to
+// requests, so their tags are checked at startup.
+func TestEveryRequestIsChecked(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "request.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := make(map[string]bool)
+	for _, r := range requests {
+		checked[reflect.TypeOf(r).Name()] = true
+	}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			name := spec.(*ast.TypeSpec).Name.Name
+			if strings.HasSuffix(name, "Request") && !checked[name] {
+				t.Errorf("%s is missing from requests", name)
+			}
+		}
+	}
+}
+
+func TestCheckTagsReportsBadTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"unknown rule", struct {
+			A string `validate:"requird"`
+		}{}, `unknown validation rule "requird"`},
+		{"bad limit", struct {
+			A string `validate:"max=ten"`
+		}{}, `bad max rule "ten"`},
+		{"min on bool", struct {
+			A bool `validate:"min=1"`
+		}{}, "min rule not supported for bool"},
+		{"oneof on int", struct {
+			A int `validate:"oneof=1 2"`
+		}{}, "oneof rule not supported for int"},
+		{"oneof without options", struct {
+			A string `validate:"oneof="`
+		}{}, "oneof rule without options"},
+		{"future on string", struct {
+			A string `validate:"future"`
+		}{}, "future rule not supported for string"},
+		{"nested slice", struct {
+			Items []struct {
+				A int `validate:"url"`
+			}
+		}{}, "url rule not supported for int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTags(tt.v)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReturnsBadTagsAsErrors(t *testing.T) {
+	req := struct {
+		A bool `json:"a" validate:"max=1"`
+	}{}
+	err := Validate(&req)
+	if err == nil {
+		t.Fatal("got nil, want an error")
+	}
+	if errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("bad tag reported as invalid input: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	req := CreateSettlementRequest{
+		Title:     " ",
+		Amount:    -1,
+		DueAt:     time.Now().Add(-time.Hour),
+		LineItems: []SettlementLineItem{{Label: "交通費"}, {Amount: 1}},
+	}
+	err := Validate(&req)
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a ValidationError", err)
+	}
+	got := make(map[string]string)
+	for _, f := range verr.Fields {
+		got[f.Field] = f.Message
+	}
+	want := map[string]string{
+		"circleId":           "is required",
+		"title":              "is required",
+		"amount":             "must be at least 0",
+		"dueAt":              "must be in the future",
+		"lineItems[1].label": "is required",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Rules other than required skip empty optional fields.
+	if err := Validate(&CreateSplitSettlementRequest{Title: "飲み会", TotalAmount: 1, DueAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Errorf("empty rounding rejected: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/response"
 	"github.com/noa/circle-app/api/usecase"
 )
 
@@ -22,8 +23,8 @@ func NewAnnouncementHandler(i *usecase.AnnouncementInteractor) *AnnouncementHand
 // Create handles POST /announcements.
 func (h *AnnouncementHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateAnnouncementRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -33,10 +34,10 @@ func (h *AnnouncementHandler) Create(w http.ResponseWriter, r *http.Request) {
 		req.EventID,
 		req.Title,
 		req.Body,
-		req.CreatedBy,
+		getUserID(r),
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -48,9 +49,9 @@ func (h *AnnouncementHandler) Create(w http.ResponseWriter, r *http.Request) {
 // Get handles GET /announcements/{id}.
 func (h *AnnouncementHandler) Get(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	announcement, err := h.interactor.GetAnnouncement(r.Context(), id)
+	announcement, err := h.interactor.GetAnnouncement(r.Context(), id, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		response.Error(w, err)
 		return
 	}
 
@@ -67,9 +68,9 @@ func (h *AnnouncementHandler) Get(w http.ResponseWriter, r *http.Request) {
 // GetByEvent handles GET /events/{eventId}/announcements.
 func (h *AnnouncementHandler) GetByEvent(w http.ResponseWriter, r *http.Request) {
 	eventID := r.PathValue("eventId")
-	announcements, err := h.interactor.GetByEvent(r.Context(), eventID)
+	announcements, err := h.interactor.GetByEvent(r.Context(), eventID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -88,9 +89,9 @@ func (h *AnnouncementHandler) GetByCircle(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	announcements, err := h.interactor.GetByCircle(r.Context(), circleID, limit)
+	announcements, err := h.interactor.GetByCircle(r.Context(), circleID, limit, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -101,18 +102,15 @@ func (h *AnnouncementHandler) GetByCircle(w http.ResponseWriter, r *http.Request
 // Update handles PUT /announcements/{id}.
 func (h *AnnouncementHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	var req struct {
-		Title string `json:"title"`
-		Body  string `json:"body"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var req dto.UpdateAnnouncementRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
-	announcement, err := h.interactor.UpdateAnnouncement(r.Context(), id, req.Title, req.Body)
+	announcement, err := h.interactor.UpdateAnnouncement(r.Context(), id, req.Title, req.Body, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -123,8 +121,8 @@ func (h *AnnouncementHandler) Update(w http.ResponseWriter, r *http.Request) {
 // Delete handles DELETE /announcements/{id}.
 func (h *AnnouncementHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	if err := h.interactor.DeleteAnnouncement(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.interactor.DeleteAnnouncement(r.Context(), id, getUserID(r)); err != nil {
+		response.Error(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
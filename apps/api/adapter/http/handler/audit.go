@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/noa/circle-app/api/adapter/http/response"
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+// AuditHandler handles audit log HTTP requests.
+type AuditHandler struct {
+	interactor *usecase.AuditInteractor
+}
+
+// NewAuditHandler creates a new AuditHandler.
+func NewAuditHandler(i *usecase.AuditInteractor) *AuditHandler {
+	return &AuditHandler{interactor: i}
+}
+
+// GetByCircle handles GET /circles/{circleId}/audit-log.
+func (h *AuditHandler) GetByCircle(w http.ResponseWriter, r *http.Request) {
+	circleID := r.PathValue("circleId")
+
+	entries, err := h.interactor.GetByCircle(r.Context(), circleID, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	if entries == nil {
+		entries = []*domain.AuditEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
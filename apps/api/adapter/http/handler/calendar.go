@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/noa/circle-app/api/adapter/http/response"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+// CalendarHandler handles calendar feed HTTP requests.
+type CalendarHandler struct {
+	interactor *usecase.CalendarInteractor
+}
+
+// NewCalendarHandler creates a new CalendarHandler.
+func NewCalendarHandler(i *usecase.CalendarInteractor) *CalendarHandler {
+	return &CalendarHandler{interactor: i}
+}
+
+// calendarFeedLinkResponse holds the subscription URLs of a user's feed.
+type calendarFeedLinkResponse struct {
+	URL       string `json:"url"`
+	GoOnlyURL string `json:"goOnlyUrl"`
+}
+
+// GetFeedLink handles GET /circles/{circleId}/calendar-feed.
+func (h *CalendarHandler) GetFeedLink(w http.ResponseWriter, r *http.Request) {
+	circleID := r.PathValue("circleId")
+	link, err := h.interactor.GetFeedLink(r.Context(), circleID, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	writeFeedLink(w, r, circleID, link)
+}
+
+// RotateFeedLink handles POST /circles/{circleId}/calendar-feed/rotate.
+func (h *CalendarHandler) RotateFeedLink(w http.ResponseWriter, r *http.Request) {
+	circleID := r.PathValue("circleId")
+	link, err := h.interactor.RotateFeedLink(r.Context(), circleID, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	writeFeedLink(w, r, circleID, link)
+}
+
+// writeFeedLink responds with the subscription URLs of a feed token.
+func writeFeedLink(w http.ResponseWriter, r *http.Request, circleID string, link *usecase.CalendarFeedLink) {
+	scheme := "https"
+	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+	feedURL := url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     "/circles/" + circleID + "/calendar.ics",
+		RawQuery: url.Values{"token": {link.Token}}.Encode(),
+	}
+	goOnly := feedURL
+	goOnly.RawQuery = url.Values{"token": {link.Token}, "rsvp": {"go"}}.Encode()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calendarFeedLinkResponse{URL: feedURL.String(), GoOnlyURL: goOnly.String()})
+}
+
+// GetFeed handles GET /circles/{circleId}/calendar.ics?token=...[&rsvp=go].
+// It is public: the token in the query identifies the user.
+func (h *CalendarHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	feed, err := h.interactor.GetFeed(r.Context(), r.PathValue("circleId"), q.Get("token"), q.Get("rsvp") == "go")
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	// The status line is already sent, so a failed write can only be logged.
+	if err := writeICalendar(w, feed); err != nil {
+		log.Printf("Warning: could not write calendar feed: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/response"
 	"github.com/noa/circle-app/api/usecase"
 )
 
@@ -21,26 +22,17 @@ func NewChatHandler(i *usecase.ChatInteractor) *ChatHandler {
 // Ask handles POST /ai/chat.
 func (h *ChatHandler) Ask(w http.ResponseWriter, r *http.Request) {
 	var req dto.ChatRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
-	if req.CircleID == "" {
-		http.Error(w, "circleId is required", http.StatusBadRequest)
-		return
-	}
-	if req.Message == "" {
-		http.Error(w, "message is required", http.StatusBadRequest)
-		return
-	}
-
-	response, err := h.interactor.Ask(r.Context(), req.CircleID, req.Message)
+	resp, err := h.interactor.Ask(r.Context(), req.CircleID, req.Message, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(resp)
 }
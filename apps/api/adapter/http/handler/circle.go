@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/response"
 	"github.com/noa/circle-app/api/domain"
 	"github.com/noa/circle-app/api/usecase"
 )
@@ -23,14 +24,14 @@ func NewCircleHandler(i *usecase.CircleInteractor) *CircleHandler {
 // Create handles POST /circles.
 func (h *CircleHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateCircleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
-	circle, err := h.interactor.CreateCircle(r.Context(), req.Name, req.Description, req.LogoURL)
+	circle, err := h.interactor.CreateCircle(r.Context(), req.Name, req.Description, req.LogoURL, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -42,9 +43,27 @@ func (h *CircleHandler) Create(w http.ResponseWriter, r *http.Request) {
 // Get handles GET /circles/{circleId}.
 func (h *CircleHandler) Get(w http.ResponseWriter, r *http.Request) {
 	circleID := r.PathValue("circleId")
-	circle, err := h.interactor.GetCircle(r.Context(), circleID)
+	circle, err := h.interactor.GetCircle(r.Context(), circleID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(circle)
+}
+
+// UpdatePaymentSettings handles PUT /circles/{circleId}/payment-settings.
+func (h *CircleHandler) UpdatePaymentSettings(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateCirclePaymentSettingsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	circle, err := h.interactor.UpdatePaymentSettings(r.Context(), r.PathValue("circleId"), req.BankInfo, req.PayPayInfo, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -57,19 +76,19 @@ func (h *CircleHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 	circleID := r.PathValue("circleId")
 
 	var req dto.AddMemberRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
 	role := domain.MemberRole(req.Role)
-	if role != domain.RoleAdmin && role != domain.RoleMember {
+	if role == "" {
 		role = domain.RoleMember
 	}
 
-	membership, err := h.interactor.AddMember(r.Context(), circleID, req.UserID, role)
+	membership, err := h.interactor.AddMember(r.Context(), circleID, req.UserID, role, req.Tags, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -78,13 +97,31 @@ func (h *CircleHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(membership)
 }
 
+// SetMemberTags handles PUT /circles/{circleId}/members/{userId}/tags.
+func (h *CircleHandler) SetMemberTags(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetMemberTagsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	membership, err := h.interactor.SetMemberTags(r.Context(), r.PathValue("circleId"), r.PathValue("userId"), req.Tags, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(membership)
+}
+
 // GetMembers handles GET /circles/{circleId}/members.
 func (h *CircleHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
 	circleID := r.PathValue("circleId")
 
-	members, err := h.interactor.GetMembers(r.Context(), circleID)
+	members, err := h.interactor.GetMembers(r.Context(), circleID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
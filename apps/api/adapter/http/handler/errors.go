@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/domain"
+)
+
+// decodeJSON decodes the request body into v and validates it against the
+// DTO's `validate` tags, reporting malformed bodies and invalid fields as
+// invalid input.
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return domain.NewValidationError("body", "malformed JSON: "+err.Error())
+	}
+	return dto.Validate(v)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/response"
 	"github.com/noa/circle-app/api/usecase"
 )
 
@@ -21,8 +22,8 @@ func NewEventHandler(i *usecase.EventInteractor) *EventHandler {
 // Create handles POST /events.
 func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateEventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -34,10 +35,12 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 		req.Location,
 		req.CoverImageURL,
 		req.RSVPTargetUserIDs,
-		req.CreatedBy,
+		req.Capacity,
+		req.RSVPDeadline,
+		getUserID(r),
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -49,9 +52,9 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 // Get handles GET /events/{eventId}.
 func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
 	eventID := r.PathValue("eventId")
-	event, err := h.interactor.GetEvent(r.Context(), eventID)
+	event, err := h.interactor.GetEvent(r.Context(), eventID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		response.Error(w, err)
 		return
 	}
 
@@ -62,9 +65,9 @@ func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
 // GetByCircle handles GET /circles/{circleId}/events.
 func (h *EventHandler) GetByCircle(w http.ResponseWriter, r *http.Request) {
 	circleID := r.PathValue("circleId")
-	events, err := h.interactor.GetEventsByCircle(r.Context(), circleID)
+	events, err := h.interactor.GetEventsByCircle(r.Context(), circleID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -76,8 +79,8 @@ func (h *EventHandler) GetByCircle(w http.ResponseWriter, r *http.Request) {
 func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 	eventID := r.PathValue("eventId")
 	var req dto.UpdateEventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -89,9 +92,12 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 		req.Location,
 		req.CoverImageURL,
 		req.RSVPTargetUserIDs,
+		req.Capacity,
+		req.RSVPDeadline,
+		getUserID(r),
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -102,8 +108,8 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 // Delete handles DELETE /events/{eventId}.
 func (h *EventHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	eventID := r.PathValue("eventId")
-	if err := h.interactor.DeleteEvent(r.Context(), eventID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.interactor.DeleteEvent(r.Context(), eventID, getUserID(r)); err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/noa/circle-app/api/adapter/eventimport"
+	"github.com/noa/circle-app/api/adapter/http/response"
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+// maxImportSize bounds uploaded import files.
+const maxImportSize = 1 << 20
+
+// errImportTooLarge is returned when an import exceeds maxImportSize.
+var errImportTooLarge = fmt.Errorf("%w: import file must be at most 1 MiB", domain.ErrTooLarge)
+
+// isTooLarge reports whether err came from hitting the request body limit.
+func isTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+// readImportRows reads an uploaded .ics or CSV file, sent either as the
+// "file" field of a multipart form or as the raw request body. The format is
+// taken from the "format" query parameter, the file name or the content.
+func readImportRows(w http.ResponseWriter, r *http.Request) ([]*usecase.EventImportRow, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var data []byte
+	var filename string
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, ferr := r.FormFile("file")
+		if ferr != nil {
+			if isTooLarge(ferr) {
+				return nil, errImportTooLarge
+			}
+			return nil, domain.NewValidationError("file", "is required")
+		}
+		defer file.Close()
+		filename = header.Filename
+		data, err = io.ReadAll(file)
+	} else {
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		if isTooLarge(err) {
+			return nil, errImportTooLarge
+		}
+		return nil, err
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(path.Ext(filename)), ".")
+	}
+	if format == "" {
+		format = "csv"
+		if bytes.Contains(data[:min(len(data), 512)], []byte("BEGIN:VCALENDAR")) {
+			format = "ics"
+		}
+	}
+	switch format {
+	case "ics", "ical":
+		return eventimport.ParseICS(data)
+	case "csv":
+		return eventimport.ParseCSV(data)
+	default:
+		return nil, domain.NewValidationError("format", "must be one of ics, csv")
+	}
+}
+
+// PreviewImport handles POST /circles/{circleId}/events/import/preview.
+func (h *EventHandler) PreviewImport(w http.ResponseWriter, r *http.Request) {
+	rows, err := readImportRows(w, r)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	preview, err := h.interactor.PreviewImport(r.Context(), r.PathValue("circleId"), rows, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// Import handles POST /circles/{circleId}/events/import[?skipInvalid=true].
+func (h *EventHandler) Import(w http.ResponseWriter, r *http.Request) {
+	rows, err := readImportRows(w, r)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	skipInvalid := r.URL.Query().Get("skipInvalid") == "true"
+	result, err := h.interactor.ImportEvents(r.Context(), r.PathValue("circleId"), rows, skipInvalid, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
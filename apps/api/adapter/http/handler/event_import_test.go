@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/noa/circle-app/api/adapter/http/response"
+	"github.com/noa/circle-app/api/domain"
+)
+
+func TestReadImportRowsRejectsLargeFiles(t *testing.T) {
+	big := "title,date\n" + strings.Repeat("練習,2026-11-07\n", maxImportSize/10)
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, _ := mw.CreateFormFile("file", "events.csv")
+	fw.Write([]byte(big))
+	mw.Close()
+
+	tests := map[string]struct {
+		body        string
+		contentType string
+	}{
+		"multipart": {form.String(), mw.FormDataContentType()},
+		"raw body":  {big, "text/csv"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/circles/c1/events/import", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			_, err := readImportRows(w, r)
+			if !errors.Is(err, domain.ErrTooLarge) {
+				t.Fatalf("err = %v, want ErrTooLarge", err)
+			}
+			response.Error(w, err)
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want 413", w.Code)
+			}
+		})
+	}
+}
+
+func TestReadImportRowsRequiresFile(t *testing.T) {
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	mw.WriteField("format", "csv")
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/circles/c1/events/import", &form)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	if _, err := readImportRows(httptest.NewRecorder(), r); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("err = %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/noa/circle-app/api/usecase"
+)
+
+// uidDomain makes calendar entry UIDs globally unique (RFC 5545 3.8.4.7).
+const uidDomain = "@circle-app"
+
+// writeICalendar renders a feed as an RFC 5545 VCALENDAR.
+func writeICalendar(w io.Writer, feed *usecase.CalendarFeed) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	now := icalTime(time.Now())
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//circle-app//calendar feed//JA")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", icalText(feed.Name))
+	line("X-WR-TIMEZONE", "Asia/Tokyo")
+	for _, e := range feed.Entries {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID+uidDomain)
+		line("DTSTAMP", now)
+		line("DTSTART", icalTime(e.Start))
+		line("DTEND", icalTime(e.End))
+		if !e.Updated.IsZero() {
+			line("LAST-MODIFIED", icalTime(e.Updated))
+		}
+		line("SEQUENCE", strconv.Itoa(e.Sequence))
+		line("SUMMARY", icalText(e.Summary))
+		if e.Location != "" {
+			line("LOCATION", icalText(e.Location))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION", icalText(e.Description))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// icalTime formats t as a UTC DATE-TIME.
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icalText escapes a TEXT value.
+var icalText = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace
+
+// writeFolded writes a content line, folding it into lines of at most 75
+// octets without splitting UTF-8 characters, terminated by CRLF.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/noa/circle-app/api/usecase"
+)
+
+func TestICalText(t *testing.T) {
+	got := icalText("練習; 持ち物, ラケット\\シューズ\r\n雨天中止\n")
+	want := `練習\; 持ち物\, ラケット\\シューズ\n雨天中止\n`
+	if got != want {
+		t.Errorf("icalText = %q, want %q", got, want)
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	long := "DESCRIPTION:" + strings.Repeat("あいうえお", 20)
+	writeFolded(w, long)
+	writeFolded(w, "SUMMARY:short")
+	w.Flush()
+
+	out := buf.String()
+	if !strings.HasSuffix(out, "\r\nSUMMARY:short\r\n") {
+		t.Fatalf("lines are not CRLF terminated: %q", out)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+	var unfolded strings.Builder
+	for i, line := range lines[:len(lines)-1] {
+		if len(line) > 75 {
+			t.Errorf("line %d has %d octets", i, len(line))
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a character: %q", i, line)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(line, " ") {
+				t.Fatalf("continuation line %d does not start with a space", i)
+			}
+			line = line[1:]
+		}
+		unfolded.WriteString(line)
+	}
+	if len(lines) < 3 || unfolded.String() != long {
+		t.Errorf("unfolded %q, want %q", unfolded.String(), long)
+	}
+}
+
+func TestWriteICalendar(t *testing.T) {
+	start := time.Date(2025, 7, 5, 14, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	feed := &usecase.CalendarFeed{
+		Name: "テニス部",
+		Entries: []usecase.CalendarEntry{{
+			UID:      "practice-s1",
+			Summary:  "土曜練習",
+			Location: "市民体育館, 第2コート",
+			Start:    start,
+			End:      start.Add(2 * time.Hour),
+			Updated:  start.Add(-time.Hour),
+			Sequence: 3,
+		}},
+	}
+	var buf bytes.Buffer
+	if err := writeICalendar(&buf, feed); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, line := range []string{
+		"BEGIN:VCALENDAR",
+		"X-WR-CALNAME:テニス部",
+		"BEGIN:VEVENT",
+		"UID:practice-s1@circle-app",
+		"DTSTART:20250705T050000Z",
+		"DTEND:20250705T070000Z",
+		"LAST-MODIFIED:20250705T040000Z",
+		"SEQUENCE:3",
+		"SUMMARY:土曜練習",
+		`LOCATION:市民体育館\, 第2コート`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+	} {
+		if !strings.Contains(out, "\r\n"+line+"\r\n") && !strings.HasPrefix(out, line+"\r\n") {
+			t.Errorf("missing line %q in\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "DESCRIPTION") {
+		t.Error("empty description written")
+	}
+}
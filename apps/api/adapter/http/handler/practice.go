@@ -3,8 +3,10 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/response"
 	"github.com/noa/circle-app/api/domain"
 	"github.com/noa/circle-app/api/usecase"
 )
@@ -24,11 +26,11 @@ func NewPracticeHandler(uc *usecase.PracticeUseCase) *PracticeHandler {
 // CreateCategory handles POST /practice-categories.
 func (h *PracticeHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreatePracticeCategoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
-	userID := r.Header.Get("X-User-Id")
+	userID := getUserID(r)
 	cat := &domain.PracticeCategory{
 		CircleID:  req.CircleID,
 		Name:      req.Name,
@@ -37,7 +39,7 @@ func (h *PracticeHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		CreatedBy: userID,
 	}
 	if err := h.uc.CreateCategory(r.Context(), cat); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -48,9 +50,9 @@ func (h *PracticeHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 // GetCategories handles GET /circles/{circleId}/practice-categories.
 func (h *PracticeHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
 	circleID := r.PathValue("circleId")
-	cats, err := h.uc.GetCategories(r.Context(), circleID)
+	cats, err := h.uc.GetCategories(r.Context(), circleID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -60,8 +62,8 @@ func (h *PracticeHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 // DeleteCategory handles DELETE /practice-categories/{id}.
 func (h *PracticeHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	if err := h.uc.DeleteCategory(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.uc.DeleteCategory(r.Context(), id, getUserID(r)); err != nil {
+		response.Error(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -69,14 +71,26 @@ func (h *PracticeHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 
 // --- Series ---
 
+// applySchedule copies the recurrence fields of a request onto a series.
+func applySchedule(series *domain.PracticeSeries, req dto.PracticeScheduleFields) error {
+	series.Recurrence = req.Recurrence
+	series.StartDate = req.StartDate
+	series.ExDates = req.ExDates
+	series.DurationMinutes = req.DurationMinutes
+	if req.EndTime != "" {
+		return series.SetEndTime(req.EndTime)
+	}
+	return nil
+}
+
 // CreateSeries handles POST /practice-series.
 func (h *PracticeHandler) CreateSeries(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreatePracticeSeriesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
-	userID := r.Header.Get("X-User-Id")
+	userID := getUserID(r)
 	series := &domain.PracticeSeries{
 		CircleID:   req.CircleID,
 		CategoryID: req.CategoryID,
@@ -87,8 +101,12 @@ func (h *PracticeHandler) CreateSeries(w http.ResponseWriter, r *http.Request) {
 		Fee:        req.Fee,
 		CreatedBy:  userID,
 	}
+	if err := applySchedule(series, req.PracticeScheduleFields); err != nil {
+		response.Error(w, err)
+		return
+	}
 	if err := h.uc.CreateSeries(r.Context(), series); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -99,9 +117,9 @@ func (h *PracticeHandler) CreateSeries(w http.ResponseWriter, r *http.Request) {
 // GetSeriesByCircle handles GET /circles/{circleId}/practice-series.
 func (h *PracticeHandler) GetSeriesByCircle(w http.ResponseWriter, r *http.Request) {
 	circleID := r.PathValue("circleId")
-	series, err := h.uc.GetSeriesByCircle(r.Context(), circleID)
+	series, err := h.uc.GetSeriesByCircle(r.Context(), circleID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -111,10 +129,10 @@ func (h *PracticeHandler) GetSeriesByCircle(w http.ResponseWriter, r *http.Reque
 // GetSeriesDetail handles GET /practice-series/{id}.
 func (h *PracticeHandler) GetSeriesDetail(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	userID := r.Header.Get("X-User-Id")
+	userID := getUserID(r)
 	detail, err := h.uc.GetSeriesDetail(r.Context(), id, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -127,8 +145,8 @@ func (h *PracticeHandler) GetSeriesDetail(w http.ResponseWriter, r *http.Request
 func (h *PracticeHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
 	seriesID := r.PathValue("id")
 	var req dto.CreatePracticeSessionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 	session := &domain.PracticeSession{
@@ -136,24 +154,187 @@ func (h *PracticeHandler) CreateSession(w http.ResponseWriter, r *http.Request)
 		Date:     req.Date,
 		Note:     req.Note,
 	}
-	if err := h.uc.CreateSession(r.Context(), session); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.uc.CreateSession(r.Context(), session, getUserID(r)); err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+// GenerateSessions handles POST /practice-series/{id}/sessions/generate.
+func (h *PracticeHandler) GenerateSessions(w http.ResponseWriter, r *http.Request) {
+	seriesID := r.PathValue("id")
+	var req dto.GeneratePracticeSessionsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	from := domain.StartOfDay(time.Now())
+	if req.From != "" {
+		var err error
+		if from, err = time.ParseInLocation("2006-01-02", req.From, domain.Location); err != nil {
+			response.Error(w, domain.NewValidationError("from", "must be a date in YYYY-MM-DD format"))
+			return
+		}
+	}
+	var to time.Time
+	switch {
+	case req.To != "":
+		var err error
+		if to, err = time.ParseInLocation("2006-01-02", req.To, domain.Location); err != nil {
+			response.Error(w, domain.NewValidationError("to", "must be a date in YYYY-MM-DD format"))
+			return
+		}
+		to = to.AddDate(0, 0, 1) // inclusive
+	case req.Weeks > 0:
+		to = from.AddDate(0, 0, 7*req.Weeks)
+	default:
+		to = from.AddDate(0, 0, 7*usecase.DefaultGenerationWeeks)
+	}
+
+	sessions, err := h.uc.GenerateSessions(r.Context(), seriesID, from, to, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// UpdateSession handles PUT /practice-sessions/{id}.
+func (h *PracticeHandler) UpdateSession(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdatePracticeSessionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+	session := &domain.PracticeSession{
+		ID:           r.PathValue("id"),
+		Date:         req.Date,
+		EndAt:        req.EndAt,
+		Note:         req.Note,
+		Cancelled:    req.Cancelled,
+		RSVPDeadline: req.RSVPDeadline,
+	}
+	updated, err := h.uc.UpdateSession(r.Context(), session, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// CancelSession handles POST /practice-sessions/{id}/cancel.
+func (h *PracticeHandler) CancelSession(w http.ResponseWriter, r *http.Request) {
+	var req dto.CancelPracticeSessionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+	session, err := h.uc.CancelSession(r.Context(), r.PathValue("id"), req.Reason, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// RescheduleSession handles POST /practice-sessions/{id}/reschedule.
+func (h *PracticeHandler) RescheduleSession(w http.ResponseWriter, r *http.Request) {
+	var req dto.ReschedulePracticeSessionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+	session, err := h.uc.RescheduleSession(r.Context(), r.PathValue("id"), req.Date, req.EndAt, req.Reason, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(session)
 }
 
+// --- Calendar exceptions ---
+
+// GetExceptions handles GET /circles/{circleId}/calendar-exceptions.
+func (h *PracticeHandler) GetExceptions(w http.ResponseWriter, r *http.Request) {
+	exceptions, err := h.uc.GetExceptions(r.Context(), r.PathValue("circleId"), getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exceptions)
+}
+
+// CreateException handles POST /circles/{circleId}/calendar-exceptions.
+func (h *PracticeHandler) CreateException(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateCalendarExceptionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+	exception := &domain.CalendarException{
+		CircleID:  r.PathValue("circleId"),
+		Kind:      domain.ExceptionKind(req.Kind),
+		Title:     req.Title,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Action:    domain.ExceptionAction(req.Action),
+		CreatedBy: getUserID(r),
+	}
+	result, err := h.uc.CreateException(r.Context(), exception)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// ImportHolidays handles POST /circles/{circleId}/calendar-exceptions/holidays.
+func (h *PracticeHandler) ImportHolidays(w http.ResponseWriter, r *http.Request) {
+	var req dto.ImportHolidaysRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+	results, err := h.uc.ImportHolidays(r.Context(), r.PathValue("circleId"), req.Year, domain.ExceptionAction(req.Action), getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(results)
+}
+
+// DeleteException handles DELETE /calendar-exceptions/{id}.
+func (h *PracticeHandler) DeleteException(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.DeleteException(r.Context(), r.PathValue("id"), getUserID(r)); err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // --- RSVP ---
 
 // SubmitRSVP handles POST /practice-sessions/{id}/rsvp.
 func (h *PracticeHandler) SubmitRSVP(w http.ResponseWriter, r *http.Request) {
 	sessionID := r.PathValue("id")
-	userID := r.Header.Get("X-User-Id")
+	userID := getUserID(r)
 	var req dto.PracticeRSVPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 	rsvp := &domain.PracticeRSVP{
@@ -162,7 +343,27 @@ func (h *PracticeHandler) SubmitRSVP(w http.ResponseWriter, r *http.Request) {
 		Status:    domain.PracticeRSVPStatus(req.Status),
 	}
 	if err := h.uc.SubmitRSVP(r.Context(), rsvp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rsvp)
+}
+
+// OverrideRSVP handles PUT /practice-sessions/{id}/rsvps/{userId}.
+func (h *PracticeHandler) OverrideRSVP(w http.ResponseWriter, r *http.Request) {
+	var req dto.OverridePracticeRSVPRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+	rsvp := &domain.PracticeRSVP{
+		SessionID: r.PathValue("id"),
+		UserID:    r.PathValue("userId"),
+		Status:    domain.PracticeRSVPStatus(req.Status),
+	}
+	if err := h.uc.OverrideRSVP(r.Context(), rsvp, req.Reason, getUserID(r)); err != nil {
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -171,10 +372,11 @@ func (h *PracticeHandler) SubmitRSVP(w http.ResponseWriter, r *http.Request) {
 
 // BulkRSVP handles POST /practice-series/{id}/bulk-rsvp.
 func (h *PracticeHandler) BulkRSVP(w http.ResponseWriter, r *http.Request) {
-	userID := r.Header.Get("X-User-Id")
+	seriesID := r.PathValue("id")
+	userID := getUserID(r)
 	var req dto.BulkPracticeRSVPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -187,8 +389,8 @@ func (h *PracticeHandler) BulkRSVP(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	if err := h.uc.BulkRSVP(r.Context(), rsvps); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.uc.BulkRSVP(r.Context(), seriesID, rsvps); err != nil {
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -198,9 +400,9 @@ func (h *PracticeHandler) BulkRSVP(w http.ResponseWriter, r *http.Request) {
 // GetSessionRSVPs handles GET /practice-sessions/{id}/rsvps.
 func (h *PracticeHandler) GetSessionRSVPs(w http.ResponseWriter, r *http.Request) {
 	sessionID := r.PathValue("id")
-	rsvps, err := h.uc.GetSessionRSVPs(r.Context(), sessionID)
+	rsvps, err := h.uc.GetSessionRSVPs(r.Context(), sessionID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -213,33 +415,61 @@ func (h *PracticeHandler) GetSessionRSVPs(w http.ResponseWriter, r *http.Request
 func (h *PracticeHandler) CreateSettlements(w http.ResponseWriter, r *http.Request) {
 	seriesID := r.PathValue("id")
 
-	// Parse optional month from query or body?
-	// For simplicity, let's say body: { "month": "2024-04" }
-	var req struct {
-		Month string `json:"month"`
+	var req dto.CreatePracticeSettlementsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		// If no body or invalid, maybe default to current month?
-		// Or return error. Let's return error for now to be safe.
-		http.Error(w, err.Error(), http.StatusBadRequest)
+
+	billing, err := h.uc.CreateSettlements(r.Context(), seriesID, req.Month, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
 		return
 	}
 
-	if err := h.uc.CreateSettlements(r.Context(), seriesID, req.Month); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(billing)
+}
+
+// PreviewMonthlyInvoices handles POST /circles/{circleId}/practice-invoices/preview.
+func (h *PracticeHandler) PreviewMonthlyInvoices(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateMonthlyInvoicesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+	run, err := h.uc.PreviewMonthlyInvoices(r.Context(), r.PathValue("circleId"), req.Month, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
 
-	w.WriteHeader(http.StatusCreated) // 201 Created
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+// CreateMonthlyInvoices handles POST /circles/{circleId}/practice-invoices.
+func (h *PracticeHandler) CreateMonthlyInvoices(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateMonthlyInvoicesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+	run, err := h.uc.CreateMonthlyInvoices(r.Context(), r.PathValue("circleId"), req.Month, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(run)
 }
 
 // UpdateSeries handles PUT /practice-series/{id}.
 func (h *PracticeHandler) UpdateSeries(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	var req dto.CreatePracticeSeriesRequest // Reusing Create request DTO for simplicity
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var req dto.UpdatePracticeSeriesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 	// Create domain object from req
@@ -250,9 +480,13 @@ func (h *PracticeHandler) UpdateSeries(w http.ResponseWriter, r *http.Request) {
 		Location:  req.Location,
 		Fee:       req.Fee,
 	}
-	updated, err := h.uc.UpdateSeries(r.Context(), id, updateData)
+	if err := applySchedule(updateData, req.PracticeScheduleFields); err != nil {
+		response.Error(w, err)
+		return
+	}
+	updated, err := h.uc.UpdateSeries(r.Context(), id, updateData, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -262,8 +496,8 @@ func (h *PracticeHandler) UpdateSeries(w http.ResponseWriter, r *http.Request) {
 // DeleteSeries handles DELETE /practice-series/{id}.
 func (h *PracticeHandler) DeleteSeries(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	if err := h.uc.DeleteSeries(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.uc.DeleteSeries(r.Context(), id, getUserID(r)); err != nil {
+		response.Error(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
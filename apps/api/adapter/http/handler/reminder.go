@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/response"
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+// ReminderHandler handles reminder HTTP requests.
+type ReminderHandler struct {
+	interactor *usecase.ReminderInteractor
+}
+
+// NewReminderHandler creates a new ReminderHandler.
+func NewReminderHandler(i *usecase.ReminderInteractor) *ReminderHandler {
+	return &ReminderHandler{interactor: i}
+}
+
+// GetPolicy handles GET /circles/{circleId}/reminder-policy.
+func (h *ReminderHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.interactor.GetPolicy(r.Context(), r.PathValue("circleId"), getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// UpdatePolicy handles PUT /circles/{circleId}/reminder-policy.
+func (h *ReminderHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateReminderPolicyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	policy := &domain.ReminderPolicy{
+		CircleID:          r.PathValue("circleId"),
+		Enabled:           req.Enabled,
+		RSVPDaysBefore:    req.RSVPDaysBefore,
+		PaymentDaysBefore: req.PaymentDaysBefore,
+		PaymentDaysAfter:  req.PaymentDaysAfter,
+	}
+	updated, err := h.interactor.UpdatePolicy(r.Context(), policy, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// GetJobs handles GET /circles/{circleId}/reminders.
+func (h *ReminderHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.interactor.GetJobs(r.Context(), r.PathValue("circleId"), getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	if jobs == nil {
+		jobs = []*domain.ReminderJob{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/middleware"
+	"github.com/noa/circle-app/api/adapter/http/response"
 	"github.com/noa/circle-app/api/domain"
 	"github.com/noa/circle-app/api/usecase"
 )
@@ -19,40 +21,49 @@ func NewRSVPHandler(i *usecase.RSVPInteractor) *RSVPHandler {
 	return &RSVPHandler{interactor: i}
 }
 
-// getUserID extracts user ID from request header.
+// getUserID returns the authenticated user ID set by the auth middleware.
 func getUserID(r *http.Request) string {
-	return r.Header.Get("X-User-Id")
+	return middleware.UserIDFromContext(r.Context())
 }
 
 // Submit handles POST /events/{eventId}/rsvp.
 func (h *RSVPHandler) Submit(w http.ResponseWriter, r *http.Request) {
 	userID := getUserID(r)
 	if userID == "" {
-		http.Error(w, "X-User-Id header required", http.StatusUnauthorized)
+		response.Error(w, domain.ErrUnauthenticated)
 		return
 	}
 
 	eventID := r.PathValue("eventId")
 
 	var req dto.RSVPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
-	status := domain.RSVPStatus(req.Status)
-	if status != domain.RSVPGo && status != domain.RSVPNo && status != domain.RSVPLate && status != domain.RSVPEarly {
-		http.Error(w, "invalid status: must be GO, NO, LATE, or EARLY", http.StatusBadRequest)
+	rsvp, err := h.interactor.SubmitRSVP(r.Context(), eventID, userID, domain.RSVPStatus(req.Status), req.Note)
+	if err != nil {
+		response.Error(w, err)
 		return
 	}
 
-	rsvp, err := h.interactor.SubmitRSVP(r.Context(), eventID, userID, status, req.Note)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rsvp)
+}
+
+// Override handles PUT /events/{eventId}/rsvps/{userId}.
+func (h *RSVPHandler) Override(w http.ResponseWriter, r *http.Request) {
+	var req dto.OverrideRSVPRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	rsvp, err := h.interactor.OverrideRSVP(r.Context(), r.PathValue("eventId"), r.PathValue("userId"),
+		domain.RSVPStatus(req.Status), req.Note, req.Reason, getUserID(r))
 	if err != nil {
-		if err == domain.ErrForbidden {
-			http.Error(w, err.Error(), http.StatusForbidden)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -60,11 +71,25 @@ func (h *RSVPHandler) Submit(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(rsvp)
 }
 
+// GetSummary handles GET /events/{eventId}/rsvps/summary.
+func (h *RSVPHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	eventID := r.PathValue("eventId")
+
+	summary, err := h.interactor.GetRSVPSummary(r.Context(), eventID, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
 // GetMy handles GET /events/{eventId}/rsvp/me.
 func (h *RSVPHandler) GetMy(w http.ResponseWriter, r *http.Request) {
 	userID := getUserID(r)
 	if userID == "" {
-		http.Error(w, "X-User-Id header required", http.StatusUnauthorized)
+		response.Error(w, domain.ErrUnauthenticated)
 		return
 	}
 
@@ -72,11 +97,7 @@ func (h *RSVPHandler) GetMy(w http.ResponseWriter, r *http.Request) {
 
 	rsvp, err := h.interactor.GetMyRSVP(r.Context(), eventID, userID)
 	if err != nil {
-		if err == domain.ErrForbidden {
-			http.Error(w, err.Error(), http.StatusForbidden)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -94,9 +115,9 @@ func (h *RSVPHandler) GetMy(w http.ResponseWriter, r *http.Request) {
 func (h *RSVPHandler) GetByEvent(w http.ResponseWriter, r *http.Request) {
 	eventID := r.PathValue("eventId")
 
-	rsvps, err := h.interactor.GetEventRSVPs(r.Context(), eventID)
+	rsvps, err := h.interactor.GetEventRSVPs(r.Context(), eventID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
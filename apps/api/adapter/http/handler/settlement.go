@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/response"
 	"github.com/noa/circle-app/api/domain"
 	"github.com/noa/circle-app/api/usecase"
 )
@@ -22,8 +23,8 @@ func NewSettlementHandler(i *usecase.SettlementInteractor) *SettlementHandler {
 // Create handles POST /settlements.
 func (h *SettlementHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateSettlementRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -37,9 +38,41 @@ func (h *SettlementHandler) Create(w http.ResponseWriter, r *http.Request) {
 		req.TargetUserIDs,
 		req.BankInfo,
 		req.PayPayInfo,
+		toLineItems(req.LineItems),
+		toPricingRules(req.PricingRules),
+		getUserID(r),
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(settlement)
+}
+
+// CreateSplit handles POST /events/{eventId}/settlements/split.
+func (h *SettlementHandler) CreateSplit(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateSplitSettlementRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	settlement, err := h.interactor.CreateSplitSettlement(
+		r.Context(),
+		r.PathValue("eventId"),
+		req.Title,
+		req.TotalAmount,
+		domain.SplitRounding(req.Rounding),
+		req.DueAt,
+		req.BankInfo,
+		req.PayPayInfo,
+		getUserID(r),
+	)
+	if err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -51,9 +84,9 @@ func (h *SettlementHandler) Create(w http.ResponseWriter, r *http.Request) {
 // GetByEvent handles GET /events/{eventId}/settlements.
 func (h *SettlementHandler) GetByEvent(w http.ResponseWriter, r *http.Request) {
 	eventID := r.PathValue("eventId")
-	settlements, err := h.interactor.GetByEvent(r.Context(), eventID)
+	settlements, err := h.interactor.GetByEvent(r.Context(), eventID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -61,17 +94,43 @@ func (h *SettlementHandler) GetByEvent(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(settlements)
 }
 
+// GetByCircle handles GET /circles/{circleId}/settlements.
+func (h *SettlementHandler) GetByCircle(w http.ResponseWriter, r *http.Request) {
+	circleID := r.PathValue("circleId")
+	summary, err := h.interactor.GetCircleSummary(r.Context(), circleID, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// GetSummary handles GET /settlements/{id}/summary.
+func (h *SettlementHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	settlementID := r.PathValue("id")
+	summary, err := h.interactor.GetSummary(r.Context(), settlementID, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
 // GetMy handles GET /settlements/me.
 func (h *SettlementHandler) GetMy(w http.ResponseWriter, r *http.Request) {
 	userID := getUserID(r)
 	if userID == "" {
-		http.Error(w, "X-User-Id header required", http.StatusUnauthorized)
+		response.Error(w, domain.ErrUnauthenticated)
 		return
 	}
 
 	settlements, err := h.interactor.GetMySettlements(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -102,35 +161,21 @@ func (h *SettlementHandler) GetMy(w http.ResponseWriter, r *http.Request) {
 func (h *SettlementHandler) ReportPayment(w http.ResponseWriter, r *http.Request) {
 	userID := getUserID(r)
 	if userID == "" {
-		http.Error(w, "X-User-Id header required", http.StatusUnauthorized)
+		response.Error(w, domain.ErrUnauthenticated)
 		return
 	}
 
 	settlementID := r.PathValue("id")
 
 	var req dto.ReportPaymentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	method := domain.PaymentMethod(req.Method)
-	if method != domain.PaymentMethodBank && method != domain.PaymentMethodPayPay {
-		http.Error(w, "invalid method: must be BANK or PAYPAY", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
-	payment, err := h.interactor.ReportPayment(r.Context(), settlementID, userID, method, req.Note)
+	payment, err := h.interactor.ReportPayment(r.Context(), settlementID, userID, domain.PaymentMethod(req.Method), req.Note)
 	if err != nil {
-		if err == domain.ErrForbidden {
-			http.Error(w, err.Error(), http.StatusForbidden)
-			return
-		}
-		if err == domain.ErrNotFound {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -143,17 +188,128 @@ func (h *SettlementHandler) Update(w http.ResponseWriter, r *http.Request) {
 	settlementID := r.PathValue("id")
 
 	var req dto.UpdateSettlementRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
-	settlement, err := h.interactor.UpdateSettlement(r.Context(), settlementID, req.Title, req.Amount, req.DueAt)
+	settlement, err := h.interactor.UpdateSettlement(r.Context(), settlementID, req.Title, req.Amount, toLineItems(req.LineItems), req.DueAt, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(settlement)
 }
+
+// toLineItems converts requested line items to domain line items.
+func toLineItems(items []dto.SettlementLineItem) []domain.SettlementLineItem {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]domain.SettlementLineItem, len(items))
+	for i, li := range items {
+		out[i] = domain.SettlementLineItem{Label: li.Label, Amount: li.Amount, Overrides: li.Overrides}
+	}
+	return out
+}
+
+// toPricingRules converts requested pricing rules to domain pricing rules.
+func toPricingRules(rules []dto.PricingRule) []domain.PricingRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make([]domain.PricingRule, len(rules))
+	for i, r := range rules {
+		out[i] = domain.PricingRule{Match: domain.PricingMatch(r.Match), Value: r.Value, Percent: r.Percent, Amount: r.Amount}
+	}
+	return out
+}
+
+// SetPricingRules handles PUT /settlements/{id}/pricing-rules.
+func (h *SettlementHandler) SetPricingRules(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetPricingRulesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	summary, err := h.interactor.SetPricingRules(r.Context(), r.PathValue("id"), toPricingRules(req.PricingRules), getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// GetPayments handles GET /settlements/{id}/payments.
+func (h *SettlementHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
+	settlementID := r.PathValue("id")
+	payments, err := h.interactor.GetPayments(r.Context(), settlementID, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payments)
+}
+
+// ConfirmPayment handles POST /settlements/{id}/payments/{userId}/confirm.
+func (h *SettlementHandler) ConfirmPayment(w http.ResponseWriter, r *http.Request) {
+	settlementID := r.PathValue("id")
+	userID := r.PathValue("userId")
+
+	payment, err := h.interactor.ConfirmPayment(r.Context(), settlementID, userID, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// RejectPayment handles POST /settlements/{id}/payments/{userId}/reject.
+func (h *SettlementHandler) RejectPayment(w http.ResponseWriter, r *http.Request) {
+	settlementID := r.PathValue("id")
+	userID := r.PathValue("userId")
+
+	var req dto.RejectPaymentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	payment, err := h.interactor.RejectPayment(r.Context(), settlementID, userID, req.Reason, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// BulkConfirmPayments handles POST /settlements/{id}/payments/confirm.
+func (h *SettlementHandler) BulkConfirmPayments(w http.ResponseWriter, r *http.Request) {
+	settlementID := r.PathValue("id")
+
+	var req dto.BulkConfirmPaymentsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	payments, err := h.interactor.BulkConfirmPayments(r.Context(), settlementID, req.UserIDs, getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payments)
+}
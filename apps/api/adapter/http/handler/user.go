@@ -5,6 +5,9 @@ import (
 	"net/http"
 
 	"github.com/noa/circle-app/api/adapter/http/dto"
+	"github.com/noa/circle-app/api/adapter/http/middleware"
+	"github.com/noa/circle-app/api/adapter/http/response"
+	"github.com/noa/circle-app/api/domain"
 	"github.com/noa/circle-app/api/usecase"
 )
 
@@ -18,22 +21,24 @@ func NewUserHandler(i *usecase.UserInteractor) *UserHandler {
 	return &UserHandler{interactor: i}
 }
 
-// CreateOrUpdate handles POST /users.
+// CreateOrUpdate handles POST /users for the authenticated user.
 func (h *UserHandler) CreateOrUpdate(w http.ResponseWriter, r *http.Request) {
 	var req dto.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
 		return
 	}
 
 	user, err := h.interactor.UpdateUser(
 		r.Context(),
-		req.ID,
+		getUserID(r),
 		req.Name,
+		req.Email,
 		req.AvatarURL,
+		middleware.VerifiedEmailFromContext(r.Context()),
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, err)
 		return
 	}
 
@@ -44,12 +49,87 @@ func (h *UserHandler) CreateOrUpdate(w http.ResponseWriter, r *http.Request) {
 // Get handles GET /users/{userId}.
 func (h *UserHandler) Get(w http.ResponseWriter, r *http.Request) {
 	userID := r.PathValue("userId")
-	user, err := h.interactor.GetUser(r.Context(), userID)
+	user, err := h.interactor.GetUser(r.Context(), userID, getUserID(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		response.Error(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
+
+// GetNotificationSettings handles GET /users/me/notifications.
+func (h *UserHandler) GetNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.interactor.GetNotificationSettings(r.Context(), getUserID(r))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateNotificationPreferences handles PUT /users/me/notifications.
+func (h *UserHandler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateNotificationPreferencesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	channels := make([]domain.NotificationChannel, len(req.Channels))
+	for i, ch := range req.Channels {
+		channels[i] = domain.NotificationChannel(ch)
+	}
+	settings, err := h.interactor.UpdateNotificationPreferences(r.Context(), getUserID(r), channels, req.LINEUserID, req.WebhookURL)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// AddPushSubscription handles POST /users/me/push-subscriptions.
+func (h *UserHandler) AddPushSubscription(w http.ResponseWriter, r *http.Request) {
+	var req dto.PushSubscriptionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	sub := domain.PushSubscription{
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}
+	settings, err := h.interactor.AddPushSubscription(r.Context(), getUserID(r), sub)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// RemovePushSubscription handles DELETE /users/me/push-subscriptions.
+func (h *UserHandler) RemovePushSubscription(w http.ResponseWriter, r *http.Request) {
+	var req dto.RemovePushSubscriptionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	settings, err := h.interactor.RemovePushSubscription(r.Context(), getUserID(r), req.Endpoint)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
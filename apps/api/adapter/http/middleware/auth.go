@@ -0,0 +1,98 @@
+// Package middleware provides HTTP middleware.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/noa/circle-app/api/adapter/http/response"
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	verifiedEmailKey
+)
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user ID, or "" if none.
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
+
+// WithVerifiedEmail returns a copy of ctx carrying the authenticated user's
+// verified email address.
+func WithVerifiedEmail(ctx context.Context, email string) context.Context {
+	return context.WithValue(ctx, verifiedEmailKey, email)
+}
+
+// VerifiedEmailFromContext returns the authenticated user's verified email
+// address, or "" if the token did not carry one.
+func VerifiedEmailFromContext(ctx context.Context) string {
+	email, _ := ctx.Value(verifiedEmailKey).(string)
+	return email
+}
+
+// Authenticate verifies the "Authorization: Bearer <token>" header and stores
+// the verified user ID and email address in the request context. Requests
+// without a valid token are rejected with 401.
+func Authenticate(verifier port.TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				response.Error(w, fmt.Errorf("%w: bearer token required", domain.ErrUnauthenticated))
+				return
+			}
+
+			id, err := verifier.VerifyToken(r.Context(), token)
+			if err != nil {
+				log.Printf("Rejected bearer token: %v", err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				response.Error(w, fmt.Errorf("%w: invalid token", domain.ErrUnauthenticated))
+				return
+			}
+
+			ctx := WithVerifiedEmail(WithUserID(r.Context(), id.UserID), id.Email)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// InsecureHeaderAuthenticate trusts the X-User-Id header as the user ID.
+// No email address is verified in this mode.
+// It exists only for local development and must never be used in production.
+func InsecureHeaderAuthenticate() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Header.Get("X-User-Id")
+			if userID == "" {
+				response.Error(w, fmt.Errorf("%w: X-User-Id header required", domain.ErrUnauthenticated))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), userID)))
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(h, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
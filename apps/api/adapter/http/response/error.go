@@ -0,0 +1,68 @@
+// Package response writes HTTP responses shared by handlers and middleware.
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// ErrorBody is the JSON body of every error response.
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// errorKind maps a domain error to its status and machine-readable code.
+type errorKind struct {
+	err    error
+	status int
+	code   string
+}
+
+var errorKinds = []errorKind{
+	{domain.ErrNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrUnauthenticated, http.StatusUnauthorized, "UNAUTHENTICATED"},
+	{domain.ErrNotAuthorized, http.StatusForbidden, "FORBIDDEN"},
+	{domain.ErrForbidden, http.StatusForbidden, "FORBIDDEN"},
+	{domain.ErrInvalidInput, http.StatusBadRequest, "INVALID_ARGUMENT"},
+	{domain.ErrConflict, http.StatusConflict, "CONFLICT"},
+	{domain.ErrPreconditionFailed, http.StatusPreconditionFailed, "PRECONDITION_FAILED"},
+	{domain.ErrTooLarge, http.StatusRequestEntityTooLarge, "TOO_LARGE"},
+}
+
+// Error writes err as a JSON error response. Domain errors keep their message;
+// anything else is logged and reported as a generic internal error so that
+// storage details never reach the client.
+func Error(w http.ResponseWriter, err error) {
+	for _, k := range errorKinds {
+		if !errors.Is(err, k.err) {
+			continue
+		}
+		body := ErrorBody{Code: k.code, Message: err.Error()}
+		var verr *domain.ValidationError
+		if errors.As(err, &verr) {
+			body.Message = "validation failed"
+			body.Details = verr.Fields
+		}
+		JSON(w, k.status, body)
+		return
+	}
+
+	log.Printf("Internal error: %v", err)
+	JSON(w, http.StatusInternalServerError, ErrorBody{
+		Code:    "INTERNAL",
+		Message: "internal server error",
+	})
+}
+
+// JSON writes v as a JSON response with the given status code.
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -17,15 +17,15 @@ func Setup(
 	chatHandler *handler.ChatHandler,
 	userHandler *handler.UserHandler,
 	practiceHandler *handler.PracticeHandler,
+	calendarHandler *handler.CalendarHandler,
+	auditHandler *handler.AuditHandler,
+	reminderHandler *handler.ReminderHandler,
+	authenticate func(http.Handler) http.Handler,
 ) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Root: simple message (avoid 404 when opening URL in browser)
-	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
-			return
-		}
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"message":"Circle API","health":"/health"}`))
@@ -36,58 +36,101 @@ func Setup(
 		w.Write([]byte("OK"))
 	})
 
+	// Calendar feeds are fetched by calendar apps, which cannot send bearer
+	// tokens; the feed token in the query authenticates the user instead.
+	mux.HandleFunc("GET /circles/{circleId}/calendar.ics", calendarHandler.GetFeed)
+
+	// Everything else requires an authenticated user
+	api := http.NewServeMux()
+	mux.Handle("/", authenticate(api))
+
 	// User routes
-	mux.HandleFunc("POST /users", userHandler.CreateOrUpdate)
-	mux.HandleFunc("GET /users/{userId}", userHandler.Get)
+	api.HandleFunc("POST /users", userHandler.CreateOrUpdate)
+	api.HandleFunc("GET /users/{userId}", userHandler.Get)
+	api.HandleFunc("GET /users/me/notifications", userHandler.GetNotificationSettings)
+	api.HandleFunc("PUT /users/me/notifications", userHandler.UpdateNotificationPreferences)
+	api.HandleFunc("POST /users/me/push-subscriptions", userHandler.AddPushSubscription)
+	api.HandleFunc("DELETE /users/me/push-subscriptions", userHandler.RemovePushSubscription)
 
 	// Circle routes
-	mux.HandleFunc("POST /circles", circleHandler.Create)
-	mux.HandleFunc("GET /circles/{circleId}", circleHandler.Get)
-	mux.HandleFunc("POST /circles/{circleId}/members", circleHandler.AddMember)
-	mux.HandleFunc("GET /circles/{circleId}/members", circleHandler.GetMembers)
-	mux.HandleFunc("GET /circles/{circleId}/events", eventHandler.GetByCircle)
-	mux.HandleFunc("GET /circles/{circleId}/announcements", announcementHandler.GetByCircle)
-	mux.HandleFunc("GET /circles/{circleId}/practice-categories", practiceHandler.GetCategories)
-	mux.HandleFunc("GET /circles/{circleId}/practice-series", practiceHandler.GetSeriesByCircle)
+	api.HandleFunc("POST /circles", circleHandler.Create)
+	api.HandleFunc("GET /circles/{circleId}", circleHandler.Get)
+	api.HandleFunc("PUT /circles/{circleId}/payment-settings", circleHandler.UpdatePaymentSettings)
+	api.HandleFunc("POST /circles/{circleId}/members", circleHandler.AddMember)
+	api.HandleFunc("GET /circles/{circleId}/members", circleHandler.GetMembers)
+	api.HandleFunc("PUT /circles/{circleId}/members/{userId}/tags", circleHandler.SetMemberTags)
+	api.HandleFunc("GET /circles/{circleId}/events", eventHandler.GetByCircle)
+	api.HandleFunc("POST /circles/{circleId}/events/import/preview", eventHandler.PreviewImport)
+	api.HandleFunc("POST /circles/{circleId}/events/import", eventHandler.Import)
+	api.HandleFunc("GET /circles/{circleId}/announcements", announcementHandler.GetByCircle)
+	api.HandleFunc("GET /circles/{circleId}/practice-categories", practiceHandler.GetCategories)
+	api.HandleFunc("GET /circles/{circleId}/practice-series", practiceHandler.GetSeriesByCircle)
+	api.HandleFunc("GET /circles/{circleId}/settlements", settlementHandler.GetByCircle)
+	api.HandleFunc("POST /circles/{circleId}/practice-invoices/preview", practiceHandler.PreviewMonthlyInvoices)
+	api.HandleFunc("POST /circles/{circleId}/practice-invoices", practiceHandler.CreateMonthlyInvoices)
+	api.HandleFunc("GET /circles/{circleId}/calendar-exceptions", practiceHandler.GetExceptions)
+	api.HandleFunc("POST /circles/{circleId}/calendar-exceptions", practiceHandler.CreateException)
+	api.HandleFunc("POST /circles/{circleId}/calendar-exceptions/holidays", practiceHandler.ImportHolidays)
+	api.HandleFunc("GET /circles/{circleId}/calendar-feed", calendarHandler.GetFeedLink)
+	api.HandleFunc("POST /circles/{circleId}/calendar-feed/rotate", calendarHandler.RotateFeedLink)
+	api.HandleFunc("GET /circles/{circleId}/audit-log", auditHandler.GetByCircle)
+	api.HandleFunc("GET /circles/{circleId}/reminder-policy", reminderHandler.GetPolicy)
+	api.HandleFunc("PUT /circles/{circleId}/reminder-policy", reminderHandler.UpdatePolicy)
+	api.HandleFunc("GET /circles/{circleId}/reminders", reminderHandler.GetJobs)
 
 	// Event routes
-	mux.HandleFunc("POST /events", eventHandler.Create)
-	mux.HandleFunc("GET /events/{eventId}", eventHandler.Get)
-	mux.HandleFunc("PUT /events/{eventId}", eventHandler.Update)
-	mux.HandleFunc("DELETE /events/{eventId}", eventHandler.Delete)
-	mux.HandleFunc("GET /events/{eventId}/announcements", announcementHandler.GetByEvent)
-	mux.HandleFunc("POST /events/{eventId}/rsvp", rsvpHandler.Submit)
-	mux.HandleFunc("GET /events/{eventId}/rsvp/me", rsvpHandler.GetMy)
-	mux.HandleFunc("GET /events/{eventId}/rsvps", rsvpHandler.GetByEvent)
-	mux.HandleFunc("GET /events/{eventId}/settlements", settlementHandler.GetByEvent)
+	api.HandleFunc("POST /events", eventHandler.Create)
+	api.HandleFunc("GET /events/{eventId}", eventHandler.Get)
+	api.HandleFunc("PUT /events/{eventId}", eventHandler.Update)
+	api.HandleFunc("DELETE /events/{eventId}", eventHandler.Delete)
+	api.HandleFunc("GET /events/{eventId}/announcements", announcementHandler.GetByEvent)
+	api.HandleFunc("POST /events/{eventId}/rsvp", rsvpHandler.Submit)
+	api.HandleFunc("GET /events/{eventId}/rsvp/me", rsvpHandler.GetMy)
+	api.HandleFunc("GET /events/{eventId}/rsvps", rsvpHandler.GetByEvent)
+	api.HandleFunc("GET /events/{eventId}/rsvps/summary", rsvpHandler.GetSummary)
+	api.HandleFunc("PUT /events/{eventId}/rsvps/{userId}", rsvpHandler.Override)
+	api.HandleFunc("GET /events/{eventId}/settlements", settlementHandler.GetByEvent)
+	api.HandleFunc("POST /events/{eventId}/settlements/split", settlementHandler.CreateSplit)
 
 	// Announcement routes
-	mux.HandleFunc("POST /announcements", announcementHandler.Create)
-	mux.HandleFunc("GET /announcements/{id}", announcementHandler.Get)
-	mux.HandleFunc("PUT /announcements/{id}", announcementHandler.Update)
-	mux.HandleFunc("DELETE /announcements/{id}", announcementHandler.Delete)
+	api.HandleFunc("POST /announcements", announcementHandler.Create)
+	api.HandleFunc("GET /announcements/{id}", announcementHandler.Get)
+	api.HandleFunc("PUT /announcements/{id}", announcementHandler.Update)
+	api.HandleFunc("DELETE /announcements/{id}", announcementHandler.Delete)
 
 	// Settlement routes
-	mux.HandleFunc("POST /settlements", settlementHandler.Create)
-	mux.HandleFunc("GET /settlements/me", settlementHandler.GetMy)
-	mux.HandleFunc("POST /settlements/{id}/report", settlementHandler.ReportPayment)
-	mux.HandleFunc("PUT /settlements/{id}", settlementHandler.Update)
+	api.HandleFunc("POST /settlements", settlementHandler.Create)
+	api.HandleFunc("GET /settlements/me", settlementHandler.GetMy)
+	api.HandleFunc("POST /settlements/{id}/report", settlementHandler.ReportPayment)
+	api.HandleFunc("PUT /settlements/{id}", settlementHandler.Update)
+	api.HandleFunc("GET /settlements/{id}/summary", settlementHandler.GetSummary)
+	api.HandleFunc("PUT /settlements/{id}/pricing-rules", settlementHandler.SetPricingRules)
+	api.HandleFunc("GET /settlements/{id}/payments", settlementHandler.GetPayments)
+	api.HandleFunc("POST /settlements/{id}/payments/confirm", settlementHandler.BulkConfirmPayments)
+	api.HandleFunc("POST /settlements/{id}/payments/{userId}/confirm", settlementHandler.ConfirmPayment)
+	api.HandleFunc("POST /settlements/{id}/payments/{userId}/reject", settlementHandler.RejectPayment)
 
 	// Practice routes
-	mux.HandleFunc("POST /practice-categories", practiceHandler.CreateCategory)
-	mux.HandleFunc("DELETE /practice-categories/{id}", practiceHandler.DeleteCategory)
-	mux.HandleFunc("POST /practice-series", practiceHandler.CreateSeries)
-	mux.HandleFunc("GET /practice-series/{id}", practiceHandler.GetSeriesDetail)
-	mux.HandleFunc("PUT /practice-series/{id}", practiceHandler.UpdateSeries)
-	mux.HandleFunc("DELETE /practice-series/{id}", practiceHandler.DeleteSeries)
-	mux.HandleFunc("POST /practice-series/{id}/sessions", practiceHandler.CreateSession)
-	mux.HandleFunc("POST /practice-series/{id}/bulk-rsvp", practiceHandler.BulkRSVP)
-	mux.HandleFunc("POST /practice-series/{id}/settlements", practiceHandler.CreateSettlements) // Added
-	mux.HandleFunc("POST /practice-sessions/{id}/rsvp", practiceHandler.SubmitRSVP)
-	mux.HandleFunc("GET /practice-sessions/{id}/rsvps", practiceHandler.GetSessionRSVPs)
+	api.HandleFunc("POST /practice-categories", practiceHandler.CreateCategory)
+	api.HandleFunc("DELETE /practice-categories/{id}", practiceHandler.DeleteCategory)
+	api.HandleFunc("POST /practice-series", practiceHandler.CreateSeries)
+	api.HandleFunc("GET /practice-series/{id}", practiceHandler.GetSeriesDetail)
+	api.HandleFunc("PUT /practice-series/{id}", practiceHandler.UpdateSeries)
+	api.HandleFunc("DELETE /practice-series/{id}", practiceHandler.DeleteSeries)
+	api.HandleFunc("POST /practice-series/{id}/sessions", practiceHandler.CreateSession)
+	api.HandleFunc("POST /practice-series/{id}/sessions/generate", practiceHandler.GenerateSessions)
+	api.HandleFunc("POST /practice-series/{id}/bulk-rsvp", practiceHandler.BulkRSVP)
+	api.HandleFunc("POST /practice-series/{id}/settlements", practiceHandler.CreateSettlements) // Added
+	api.HandleFunc("POST /practice-sessions/{id}/rsvp", practiceHandler.SubmitRSVP)
+	api.HandleFunc("GET /practice-sessions/{id}/rsvps", practiceHandler.GetSessionRSVPs)
+	api.HandleFunc("PUT /practice-sessions/{id}/rsvps/{userId}", practiceHandler.OverrideRSVP)
+	api.HandleFunc("PUT /practice-sessions/{id}", practiceHandler.UpdateSession)
+	api.HandleFunc("POST /practice-sessions/{id}/cancel", practiceHandler.CancelSession)
+	api.HandleFunc("POST /practice-sessions/{id}/reschedule", practiceHandler.RescheduleSession)
+	api.HandleFunc("DELETE /calendar-exceptions/{id}", practiceHandler.DeleteException)
 
 	// AI Chat routes
-	mux.HandleFunc("POST /ai/chat", chatHandler.Ask)
+	api.HandleFunc("POST /ai/chat", chatHandler.Ask)
 
 	return mux
 }
@@ -0,0 +1,86 @@
+// Command generate-sessions materializes upcoming practice sessions for every
+// practice series. It is meant to run from a scheduler such as Cloud Scheduler
+// with Cloud Run jobs, e.g. once a day:
+//
+//	GCP_PROJECT_ID=my-project go run ./cmd/generate-sessions -weeks 8
+//
+// Sessions that already exist are skipped, so overlapping runs are harmless.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	firestoreRepo "github.com/noa/circle-app/api/infra/firestore"
+	"github.com/noa/circle-app/api/infra/holiday"
+	"github.com/noa/circle-app/api/infra/notify"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+func main() {
+	weeks := flag.Int("weeks", usecase.DefaultGenerationWeeks, "number of weeks ahead to generate")
+	flag.Parse()
+	if err := checkWeeks(*weeks); err != nil {
+		log.Fatal(err)
+	}
+
+	projectID := os.Getenv("GCP_PROJECT_ID")
+	if projectID == "" {
+		projectID = os.Getenv("GCP_PROJECT")
+	}
+	if projectID == "" {
+		log.Fatal("GCP_PROJECT_ID or GCP_PROJECT is required")
+	}
+
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx, projectID)
+	if err != nil {
+		log.Fatalf("Failed to create Firestore client: %v", err)
+	}
+	defer client.Close()
+
+	holidays, err := holiday.NewJapanCalendar()
+	if err != nil {
+		log.Fatalf("Failed to load holiday data: %v", err)
+	}
+	notifier, err := notify.New(notify.ConfigFromEnv(), firestoreRepo.NewUserRepository(client))
+	if err != nil {
+		log.Fatalf("Failed to configure notifications: %v", err)
+	}
+
+	practiceUseCase := usecase.NewPracticeUseCase(
+		firestoreRepo.NewPracticeCategoryRepository(client),
+		firestoreRepo.NewPracticeSeriesRepository(client),
+		firestoreRepo.NewPracticeSessionRepository(client),
+		firestoreRepo.NewPracticeRSVPRepository(client),
+		firestoreRepo.NewSettlementRepository(client),
+		firestoreRepo.NewPaymentRepository(client),
+		firestoreRepo.NewCircleRepository(client),
+		firestoreRepo.NewCalendarExceptionRepository(client),
+		firestoreRepo.NewAuditLogRepository(client),
+		firestoreRepo.NewTransactor(client),
+		holidays,
+		notifier,
+		usecase.NewAuthorizer(firestoreRepo.NewMembershipRepository(client)),
+	)
+
+	created, err := practiceUseCase.GenerateUpcomingSessions(ctx, time.Now(), *weeks)
+	log.Printf("Generated %d practice sessions for the next %d weeks", created, *weeks)
+	if err != nil {
+		log.Fatalf("Some series failed: %v", err)
+	}
+}
+
+// checkWeeks rejects a -weeks value every series would fail on, before
+// connecting to anything.
+func checkWeeks(weeks int) error {
+	if weeks < 1 || time.Duration(weeks)*7*24*time.Hour > usecase.MaxGenerationRange {
+		return fmt.Errorf("-weeks must be between 1 and %d", int(usecase.MaxGenerationRange/(7*24*time.Hour)))
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+func TestCheckWeeks(t *testing.T) {
+	for weeks, ok := range map[int]bool{-1: false, 0: false, 1: true, 8: true, 52: true, 53: false} {
+		if err := checkWeeks(weeks); (err == nil) != ok {
+			t.Errorf("checkWeeks(%d) = %v, want ok %v", weeks, err, ok)
+		}
+	}
+}
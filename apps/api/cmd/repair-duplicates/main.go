@@ -0,0 +1,210 @@
+// Command repair-duplicates merges duplicate RSVP, practice RSVP and payment
+// documents left behind by the old query-then-add upserts, and moves every
+// remaining document to its deterministic ID (see domain.RSVPID,
+// domain.PracticeRSVPID and domain.PaymentID).
+//
+// Run it once after deploying deterministic IDs, ideally while the API is not
+// serving writes:
+//
+//	GCP_PROJECT_ID=my-project go run ./cmd/repair-duplicates -dry-run
+//	GCP_PROJECT_ID=my-project go run ./cmd/repair-duplicates
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"maps"
+	"os"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/noa/circle-app/api/domain"
+	"google.golang.org/api/iterator"
+)
+
+// collection describes how to key and merge the documents of one collection.
+type collection struct {
+	name      string
+	parentKey string // field holding the parent ID (event, session or settlement)
+	id        func(parentID, userID string) string
+	// better reports whether document a should be kept over document b.
+	better func(a, b map[string]interface{}) bool
+}
+
+var collections = []collection{
+	{name: "rsvps", parentKey: "eventId", id: domain.RSVPID, better: newerUpdate},
+	{name: "practice_rsvps", parentKey: "sessionId", id: domain.PracticeRSVPID, better: newerUpdate},
+	{name: "payments", parentKey: "settlementId", id: domain.PaymentID, better: furtherPayment},
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing")
+	flag.Parse()
+
+	projectID := os.Getenv("GCP_PROJECT_ID")
+	if projectID == "" {
+		projectID = os.Getenv("GCP_PROJECT")
+	}
+	if projectID == "" {
+		log.Fatal("GCP_PROJECT_ID or GCP_PROJECT is required")
+	}
+
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx, projectID)
+	if err != nil {
+		log.Fatalf("Failed to create Firestore client: %v", err)
+	}
+	defer client.Close()
+
+	for _, c := range collections {
+		moved, merged, err := repair(ctx, client, c, *dryRun)
+		if err != nil {
+			log.Fatalf("%s: %v", c.name, err)
+		}
+		log.Printf("%s: %d documents moved to deterministic IDs, %d duplicates removed", c.name, moved, merged)
+	}
+	if *dryRun {
+		log.Printf("Dry run: nothing was written")
+	}
+}
+
+// document is a stored document: its ID and fields.
+type document struct {
+	id   string
+	data map[string]interface{}
+}
+
+// merge is the repair of one parent and user: keep is written unchanged,
+// apart from its "id" field, under the deterministic id and every document in
+// remove is deleted.
+type merge struct {
+	id     string
+	keep   document
+	remove []string
+	moved  bool // keep was stored under another ID
+	// duplicates is the number of documents merged into keep.
+	duplicates int
+}
+
+// repair groups the documents of a collection by parent and user, keeps the
+// best document of each group under its deterministic ID and deletes the rest.
+func repair(ctx context.Context, client *firestore.Client, c collection, dryRun bool) (moved, merged int, err error) {
+	var docs []document
+	iter := client.Collection(c.name).Documents(ctx)
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return moved, merged, err
+		}
+		docs = append(docs, document{id: doc.Ref.ID, data: doc.Data()})
+	}
+
+	for _, m := range plan(c, docs) {
+		if m.moved {
+			moved++
+		}
+		merged += m.duplicates
+		log.Printf("%s/%s: keeping %s of %d document(s)", c.name, m.id, m.keep.id, m.duplicates+1)
+		if dryRun {
+			continue
+		}
+
+		coll := client.Collection(c.name)
+		err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			if err := tx.Set(coll.Doc(m.id), m.keep.data); err != nil {
+				return err
+			}
+			for _, id := range m.remove {
+				if err := tx.Delete(coll.Doc(id)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return moved, merged, err
+		}
+	}
+	return moved, merged, nil
+}
+
+// plan decides the merges of a collection, ordered by deterministic ID.
+// Documents already stored alone under their deterministic ID are left out,
+// as are documents missing the parent or user ID.
+func plan(c collection, docs []document) []merge {
+	groups := make(map[string][]document)
+	var ids []string
+	for _, doc := range docs {
+		parentID, _ := doc.data[c.parentKey].(string)
+		userID, _ := doc.data["userId"].(string)
+		if parentID == "" || userID == "" {
+			log.Printf("%s/%s: missing %s or userId, skipped", c.name, doc.id, c.parentKey)
+			continue
+		}
+		id := c.id(parentID, userID)
+		if groups[id] == nil {
+			ids = append(ids, id)
+		}
+		groups[id] = append(groups[id], doc)
+	}
+	sort.Strings(ids)
+
+	var merges []merge
+	for _, id := range ids {
+		group := groups[id]
+		if len(group) == 1 && group[0].id == id {
+			continue
+		}
+
+		keep := group[0]
+		for _, doc := range group[1:] {
+			if c.better(doc.data, keep.data) {
+				keep = doc
+			}
+		}
+		m := merge{id: id, keep: keep, moved: keep.id != id, duplicates: len(group) - 1}
+		for _, doc := range group {
+			if doc.id != id {
+				m.remove = append(m.remove, doc.id)
+			}
+		}
+		m.keep.data = maps.Clone(keep.data)
+		m.keep.data["id"] = id
+		merges = append(merges, m)
+	}
+	return merges
+}
+
+// newerUpdate prefers the most recently updated RSVP.
+func newerUpdate(a, b map[string]interface{}) bool {
+	return timeField(a, "updatedAt").After(timeField(b, "updatedAt"))
+}
+
+// paymentRank orders payment statuses by how far the payment has progressed.
+var paymentRank = map[string]int{
+	string(domain.PaymentUnpaid):       0,
+	string(domain.PaymentPaidReported): 1,
+	string(domain.PaymentConfirmed):    2,
+}
+
+// furtherPayment prefers the payment that progressed furthest, then the latest report,
+// so merging never loses a reported or confirmed payment.
+func furtherPayment(a, b map[string]interface{}) bool {
+	ra, _ := a["status"].(string)
+	rb, _ := b["status"].(string)
+	if paymentRank[ra] != paymentRank[rb] {
+		return paymentRank[ra] > paymentRank[rb]
+	}
+	return timeField(a, "reportedAt").After(timeField(b, "reportedAt"))
+}
+
+func timeField(data map[string]interface{}, key string) time.Time {
+	t, _ := data[key].(time.Time)
+	return t
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+var day = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func collectionNamed(t *testing.T, name string) collection {
+	t.Helper()
+	for _, c := range collections {
+		if c.name == name {
+			return c
+		}
+	}
+	t.Fatalf("no collection %q", name)
+	return collection{}
+}
+
+func TestPlanKeepsTheFurthestPayment(t *testing.T) {
+	payments := collectionNamed(t, "payments")
+	confirmed := map[string]interface{}{
+		"settlementId": "s1", "userId": "u1", "status": "CONFIRMED", "method": "BANK",
+		"reportedAt": day, "confirmedBy": "admin", "confirmedAt": day.Add(time.Hour),
+		"rejectionReason": "", "note": "振込済み",
+	}
+	docs := []document{
+		{id: "random1", data: map[string]interface{}{"settlementId": "s1", "userId": "u1", "status": "PAID_REPORTED", "reportedAt": day.Add(48 * time.Hour)}},
+		{id: "random2", data: confirmed},
+		{id: "s1_u1", data: map[string]interface{}{"settlementId": "s1", "userId": "u1", "status": "UNPAID"}},
+		// Rejected and reported again: the later report wins a tie on status.
+		{id: "old", data: map[string]interface{}{"settlementId": "s1", "userId": "u2", "status": "PAID_REPORTED", "reportedAt": day, "rejectionReason": "金額違い"}},
+		{id: "new", data: map[string]interface{}{"settlementId": "s1", "userId": "u2", "status": "PAID_REPORTED", "reportedAt": day.Add(time.Hour), "rejectionReason": "金額違い", "rejectedBy": "admin"}},
+		{id: "s1_u3", data: map[string]interface{}{"settlementId": "s1", "userId": "u3", "status": "UNPAID"}},
+		{id: "orphan", data: map[string]interface{}{"userId": "u4", "status": "CONFIRMED"}},
+	}
+
+	merges := plan(payments, docs)
+	if len(merges) != 2 {
+		t.Fatalf("got %d merges, want 2: %+v", len(merges), merges)
+	}
+
+	m := merges[0]
+	if m.id != "s1_u1" || m.keep.id != "random2" || !m.moved || m.duplicates != 2 {
+		t.Errorf("u1 merge = %+v, want random2 kept over 2 duplicates", m)
+	}
+	want := make(map[string]interface{})
+	for k, v := range confirmed {
+		want[k] = v
+	}
+	want["id"] = "s1_u1"
+	if !reflect.DeepEqual(m.keep.data, want) {
+		t.Errorf("kept data = %v, want %v", m.keep.data, want)
+	}
+	if _, ok := confirmed["id"]; ok {
+		t.Error("plan changed the data it read")
+	}
+	// The document already at s1_u1 is overwritten rather than deleted.
+	if want := []string{"random1", "random2"}; !slices.Equal(m.remove, want) {
+		t.Errorf("removed %q, want %q", m.remove, want)
+	}
+
+	m = merges[1]
+	if m.id != "s1_u2" || m.keep.id != "new" || m.keep.data["rejectedBy"] != "admin" {
+		t.Errorf("u2 merge = %+v, want the latest report kept", m)
+	}
+	if want := []string{"old", "new"}; !slices.Equal(m.remove, want) {
+		t.Errorf("removed %q, want %q", m.remove, want)
+	}
+}
+
+func TestPlanKeepsTheNewestRSVP(t *testing.T) {
+	rsvps := collectionNamed(t, "rsvps")
+	if rsvps.id("e1", "u1") != domain.RSVPID("e1", "u1") {
+		t.Fatal("rsvps not keyed by RSVPID")
+	}
+	docs := []document{
+		{id: "a", data: map[string]interface{}{"eventId": "e1", "userId": "u1", "status": "GO", "updatedAt": day}},
+		{id: "b", data: map[string]interface{}{"eventId": "e1", "userId": "u1", "status": "NO", "note": "体調不良", "updatedAt": day.Add(time.Minute)}},
+		{id: "c", data: map[string]interface{}{"eventId": "e1", "userId": "u2", "status": "GO", "waitlisted": true, "waitlistedAt": day, "updatedAt": day}},
+	}
+
+	merges := plan(rsvps, docs)
+	if len(merges) != 2 {
+		t.Fatalf("got %d merges, want 2: %+v", len(merges), merges)
+	}
+	if m := merges[0]; m.id != "e1_u1" || m.keep.id != "b" || m.keep.data["status"] != "NO" || m.keep.data["note"] != "体調不良" {
+		t.Errorf("u1 merge = %+v, want the newest RSVP kept", m)
+	}
+	// A single document under a random ID is moved, keeping its waitlist place.
+	if m := merges[1]; m.id != "e1_u2" || !m.moved || m.duplicates != 0 || m.keep.data["waitlistedAt"] != day {
+		t.Errorf("u2 merge = %+v, want c moved as is", m)
+	}
+}
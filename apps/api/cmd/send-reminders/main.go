@@ -0,0 +1,68 @@
+// Command send-reminders plans and sends due reminders for every circle once.
+// It is an alternative to the API's in-process scheduler for deployments that
+// scale to zero, meant to run from a scheduler such as Cloud Scheduler with
+// Cloud Run jobs, e.g. every 15 minutes:
+//
+//	GCP_PROJECT_ID=my-project go run ./cmd/send-reminders
+//
+// Set REMINDER_INTERVAL=0 on the API when using it. Reminders are claimed
+// before they are sent, so overlapping runs do not send them twice.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"cloud.google.com/go/firestore"
+	"github.com/noa/circle-app/api/infra/clock"
+	firestoreRepo "github.com/noa/circle-app/api/infra/firestore"
+	"github.com/noa/circle-app/api/infra/notify"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+func main() {
+	projectID := os.Getenv("GCP_PROJECT_ID")
+	if projectID == "" {
+		projectID = os.Getenv("GCP_PROJECT")
+	}
+	if projectID == "" {
+		log.Fatal("GCP_PROJECT_ID or GCP_PROJECT is required")
+	}
+
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx, projectID)
+	if err != nil {
+		log.Fatalf("Failed to create Firestore client: %v", err)
+	}
+	defer client.Close()
+
+	notifier, err := notify.New(notify.ConfigFromEnv(), firestoreRepo.NewUserRepository(client))
+	if err != nil {
+		log.Fatalf("Failed to configure notifications: %v", err)
+	}
+
+	membershipRepo := firestoreRepo.NewMembershipRepository(client)
+	reminderInteractor := usecase.NewReminderInteractor(
+		firestoreRepo.NewReminderPolicyRepository(client),
+		firestoreRepo.NewReminderJobRepository(client),
+		firestoreRepo.NewCircleRepository(client),
+		firestoreRepo.NewEventRepository(client),
+		firestoreRepo.NewRSVPRepository(client),
+		membershipRepo,
+		firestoreRepo.NewSettlementRepository(client),
+		firestoreRepo.NewPaymentRepository(client),
+		firestoreRepo.NewTransactor(client),
+		notifier,
+		clock.System{},
+		usecase.NewAuthorizer(membershipRepo),
+	)
+
+	run, err := reminderInteractor.RunReminders(ctx)
+	if run != nil {
+		log.Printf("Reminders: planned %d, sent %d, skipped %d, failed %d", run.Planned, run.Sent, run.Skipped, run.Failed)
+	}
+	if err != nil {
+		log.Fatalf("Some reminders failed: %v", err)
+	}
+}
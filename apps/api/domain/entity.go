@@ -3,26 +3,45 @@
 package domain
 
 import (
+	"strconv"
 	"time"
 )
 
 // User represents a user.
 type User struct {
-	ID        string    `json:"id" firestore:"id"`
-	Name      string    `json:"name" firestore:"name"`
-	AvatarURL string    `json:"avatarUrl" firestore:"avatarUrl"`
-	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+	ID            string    `json:"id" firestore:"id"`
+	Name          string    `json:"name" firestore:"name"`
+	Email         string    `json:"email,omitempty" firestore:"email"`                 // only shown to the user themselves
+	EmailVerified bool      `json:"emailVerified,omitempty" firestore:"emailVerified"` // the identity provider verified Email
+	AvatarURL     string    `json:"avatarUrl" firestore:"avatarUrl"`
+	CreatedAt     time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt" firestore:"updatedAt"`
+
+	Notifications *NotificationPreferences `json:"notifications,omitempty" firestore:"notifications"` // only shown to the user themselves
+}
+
+// VerifiedEmail returns u's email address if it was verified, or "".
+// Anything sent to or matched on an address must use this instead of Email,
+// which users can set to any address.
+func (u *User) VerifiedEmail() string {
+	if !u.EmailVerified {
+		return ""
+	}
+	return u.Email
 }
 
 // Circle represents a circle group.
 type Circle struct {
-	ID          string    `json:"id" firestore:"id"`
-	Name        string    `json:"name" firestore:"name"`
-	Description string    `json:"description" firestore:"description"`
-	LogoURL     string    `json:"logoUrl" firestore:"logoUrl"`
-	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt" firestore:"updatedAt"`
+	ID          string `json:"id" firestore:"id"`
+	Name        string `json:"name" firestore:"name"`
+	Description string `json:"description" firestore:"description"`
+	LogoURL     string `json:"logoUrl" firestore:"logoUrl"`
+	// BankInfo and PayPayInfo are the payment instructions of settlements
+	// the circle bills automatically, such as monthly practice fees.
+	BankInfo   string    `json:"bankInfo" firestore:"bankInfo"`
+	PayPayInfo string    `json:"paypayInfo" firestore:"paypayInfo"`
+	CreatedAt  time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt" firestore:"updatedAt"`
 }
 
 // MemberRole represents a member's role in a circle.
@@ -33,15 +52,26 @@ const (
 	RoleMember MemberRole = "MEMBER"
 )
 
+// Valid reports whether r is a known role.
+func (r MemberRole) Valid() bool {
+	return r == RoleAdmin || r == RoleMember
+}
+
 // Membership represents a user's membership in a circle.
 type Membership struct {
 	ID       string     `json:"id" firestore:"id"`
 	CircleID string     `json:"circleId" firestore:"circleId"`
 	UserID   string     `json:"userId" firestore:"userId"`
 	Role     MemberRole `json:"role" firestore:"role"`
+	Tags     []string   `json:"tags,omitempty" firestore:"tags"` // e.g. "1年", for pricing rules
 	JoinedAt time.Time  `json:"joinedAt" firestore:"joinedAt"`
+	// FeedTokenVersion is bumped to revoke the member's calendar feed URL.
+	FeedTokenVersion int `json:"-" firestore:"feedTokenVersion"`
 }
 
+// MaxMemberTags bounds the tags of a membership.
+const MaxMemberTags = 10
+
 // Event represents an event in a circle.
 type Event struct {
 	ID                string    `json:"id" firestore:"id"`
@@ -51,8 +81,21 @@ type Event struct {
 	Location          string    `json:"location" firestore:"location"`
 	CoverImageURL     string    `json:"coverImageUrl" firestore:"coverImageUrl"`
 	RSVPTargetUserIDs []string  `json:"rsvpTargetUserIds" firestore:"rsvpTargetUserIds"`
+	Capacity          int       `json:"capacity" firestore:"capacity"`         // 0 means unlimited
+	RSVPDeadline      time.Time `json:"rsvpDeadline" firestore:"rsvpDeadline"` // zero means until the event starts
 	CreatedBy         string    `json:"createdBy" firestore:"createdBy"`
 	CreatedAt         time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt" firestore:"updatedAt"`
+	Sequence          int       `json:"sequence" firestore:"sequence"` // revision, counting updates
+}
+
+// RSVPClosesAt returns when members can no longer change their RSVP: the
+// deadline, or the start of the event if that is earlier or no deadline is set.
+func (e *Event) RSVPClosesAt() time.Time {
+	if e.RSVPDeadline.IsZero() || e.RSVPDeadline.After(e.StartAt) {
+		return e.StartAt
+	}
+	return e.RSVPDeadline
 }
 
 // Announcement represents an announcement for an event.
@@ -77,14 +120,43 @@ const (
 	RSVPEarly RSVPStatus = "EARLY"
 )
 
+// Valid reports whether s is a known RSVP status.
+func (s RSVPStatus) Valid() bool {
+	switch s {
+	case RSVPGo, RSVPNo, RSVPLate, RSVPEarly:
+		return true
+	}
+	return false
+}
+
+// Attending reports whether s means the user will come (GO, LATE or EARLY).
+func (s RSVPStatus) Attending() bool {
+	return s == RSVPGo || s == RSVPLate || s == RSVPEarly
+}
+
 // RSVP represents a user's RSVP for an event.
+// Attending RSVPs beyond the event's capacity are waitlisted and promoted in
+// the order they joined the waitlist as seats free up.
 type RSVP struct {
-	ID        string     `json:"id" firestore:"id"`
-	EventID   string     `json:"eventId" firestore:"eventId"`
-	UserID    string     `json:"userId" firestore:"userId"`
-	Status    RSVPStatus `json:"status" firestore:"status"`
-	Note      string     `json:"note" firestore:"note"`
-	UpdatedAt time.Time  `json:"updatedAt" firestore:"updatedAt"`
+	ID           string     `json:"id" firestore:"id"`
+	EventID      string     `json:"eventId" firestore:"eventId"`
+	UserID       string     `json:"userId" firestore:"userId"`
+	Status       RSVPStatus `json:"status" firestore:"status"`
+	Note         string     `json:"note" firestore:"note"`
+	Waitlisted   bool       `json:"waitlisted" firestore:"waitlisted"`
+	WaitlistedAt time.Time  `json:"waitlistedAt" firestore:"waitlistedAt"`
+	UpdatedAt    time.Time  `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// Confirmed reports whether the RSVP holds a seat at the event.
+func (r *RSVP) Confirmed() bool {
+	return r.Status.Attending() && !r.Waitlisted
+}
+
+// RSVPID returns the document ID of a user's RSVP for an event.
+// Each event/user pair has exactly one RSVP document.
+func RSVPID(eventID, userID string) string {
+	return eventID + "_" + userID
 }
 
 // Settlement represents a payment request.
@@ -98,7 +170,82 @@ type Settlement struct {
 	TargetUserIDs []string  `json:"targetUserIds" firestore:"targetUserIds"`
 	BankInfo      string    `json:"bankInfo" firestore:"bankInfo"`
 	PayPayInfo    string    `json:"paypayInfo" firestore:"paypayInfo"`
-	CreatedAt     time.Time `json:"createdAt" firestore:"createdAt"`
+	// SeriesID, Month and SessionIDs are set on practice fee settlements:
+	// the series, the "2006-01" month and the attended sessions billed.
+	// Monthly invoices span every series of the circle and leave SeriesID
+	// empty.
+	SeriesID   string   `json:"seriesId,omitempty" firestore:"seriesId"`
+	Month      string   `json:"month,omitempty" firestore:"month"`
+	SessionIDs []string `json:"sessionIds,omitempty" firestore:"sessionIds"`
+	// LineItems break Amount down, such as transport, lodging and food, or
+	// the sessions on a monthly invoice. Amount is their sum when set.
+	LineItems []SettlementLineItem `json:"lineItems,omitempty" firestore:"lineItems"`
+	// PricingRules set what some targets owe instead, such as less for
+	// first-years or nothing for officers; see PriceFor.
+	PricingRules []PricingRule `json:"pricingRules,omitempty" firestore:"pricingRules"`
+	// Split is set on settlements split from a total expense among the
+	// attendees of the event.
+	Split     *SettlementSplit `json:"split,omitempty" firestore:"split"`
+	CreatedAt time.Time        `json:"createdAt" firestore:"createdAt"`
+}
+
+// SettlementLineItem is one part of what each target of a settlement owes.
+type SettlementLineItem struct {
+	Label  string `json:"label" firestore:"label"`
+	Amount int    `json:"amount" firestore:"amount"` // per target
+	// Overrides replace Amount for some targets, by user ID; 0 exempts a
+	// target from the item.
+	Overrides map[string]int `json:"overrides,omitempty" firestore:"overrides"`
+	// SeriesID, SessionID and Date are set on the items of monthly
+	// practice invoices.
+	SeriesID  string    `json:"seriesId,omitempty" firestore:"seriesId"`
+	SessionID string    `json:"sessionId,omitempty" firestore:"sessionId"`
+	Date      time.Time `json:"date" firestore:"date"`
+}
+
+// AmountFor returns the amount of the item for userID.
+func (li *SettlementLineItem) AmountFor(userID string) int {
+	if amount, ok := li.Overrides[userID]; ok {
+		return amount
+	}
+	return li.Amount
+}
+
+// SumLineItems returns the amount a target without overrides owes.
+func SumLineItems(items []SettlementLineItem) int {
+	sum := 0
+	for _, li := range items {
+		sum += li.Amount
+	}
+	return sum
+}
+
+// AmountFor returns what userID owes: Amount, or the sum of the line items
+// with the user's overrides applied.
+func (s *Settlement) AmountFor(userID string) int {
+	if len(s.LineItems) == 0 {
+		return s.Amount
+	}
+	sum := 0
+	for i := range s.LineItems {
+		sum += s.LineItems[i].AmountFor(userID)
+	}
+	return sum
+}
+
+// BreakdownFor returns the line items with the amounts userID owes and
+// without the overrides of other targets.
+func (s *Settlement) BreakdownFor(userID string) []SettlementLineItem {
+	if len(s.LineItems) == 0 {
+		return nil
+	}
+	items := make([]SettlementLineItem, len(s.LineItems))
+	for i, li := range s.LineItems {
+		li.Amount = li.AmountFor(userID)
+		li.Overrides = nil
+		items[i] = li
+	}
+	return items
 }
 
 // PaymentStatus represents payment status.
@@ -118,6 +265,11 @@ const (
 	PaymentMethodPayPay PaymentMethod = "PAYPAY"
 )
 
+// Valid reports whether m is a known payment method.
+func (m PaymentMethod) Valid() bool {
+	return m == PaymentMethodBank || m == PaymentMethodPayPay
+}
+
 // Payment represents individual user's payment for a settlement.
 type Payment struct {
 	ID           string        `json:"id" firestore:"id"`
@@ -127,6 +279,37 @@ type Payment struct {
 	Method       PaymentMethod `json:"method" firestore:"method"`
 	Note         string        `json:"note" firestore:"note"`
 	ReportedAt   time.Time     `json:"reportedAt" firestore:"reportedAt"`
+	// ConfirmedBy and ConfirmedAt record the admin who confirmed the payment.
+	ConfirmedBy string    `json:"confirmedBy,omitempty" firestore:"confirmedBy"`
+	ConfirmedAt time.Time `json:"confirmedAt" firestore:"confirmedAt"`
+	// RejectionReason is set when an admin rejects a reported payment.
+	RejectionReason string    `json:"rejectionReason,omitempty" firestore:"rejectionReason"`
+	RejectedBy      string    `json:"rejectedBy,omitempty" firestore:"rejectedBy"`
+	RejectedAt      time.Time `json:"rejectedAt" firestore:"rejectedAt"`
+	// Amount is what the user owes, priced when the payment is created and
+	// again whenever the settlement changes while the payment is unpaid.
+	// Payments from before per-user pricing have none; see Owed.
+	Amount *int `json:"amount,omitempty" firestore:"amount"`
+}
+
+// PaymentID returns the document ID of a user's payment for a settlement.
+// Each settlement/user pair has exactly one payment document.
+func PaymentID(settlementID, userID string) string {
+	return settlementID + "_" + userID
+}
+
+// PracticeFeeSettlementID returns the document ID of a user's practice fee
+// settlement for a series and month, so that billing a month twice finds the
+// settlement of the first run.
+func PracticeFeeSettlementID(seriesID, month, userID string) string {
+	return "practice_" + seriesID + "_" + month + "_" + userID
+}
+
+// MonthlyInvoiceSettlementID returns the document ID of the seq-th monthly
+// invoice of a user, counting from 1. Later invoices of the month bill only
+// the sessions the earlier ones missed.
+func MonthlyInvoiceSettlementID(circleID, month, userID string, seq int) string {
+	return "invoice_" + circleID + "_" + month + "_" + userID + "_" + strconv.Itoa(seq)
 }
 
 // ChatReference represents a referenced announcement in chat.
@@ -154,17 +337,22 @@ type PracticeCategory struct {
 
 // PracticeSeries represents a recurring practice definition.
 type PracticeSeries struct {
-	ID         string    `json:"id" firestore:"id"`
-	CircleID   string    `json:"circleId" firestore:"circleId"`
-	CategoryID string    `json:"categoryId" firestore:"categoryId"`
-	Name       string    `json:"name" firestore:"name"`
-	DayOfWeek  int       `json:"dayOfWeek" firestore:"dayOfWeek"` // 0=Sun..6=Sat
-	StartTime  string    `json:"startTime" firestore:"startTime"` // "14:00"
-	Location   string    `json:"location" firestore:"location"`
-	Fee        int       `json:"fee" firestore:"fee"` // per session
-	CreatedBy  string    `json:"createdBy" firestore:"createdBy"`
-	CreatedAt  time.Time `json:"createdAt" firestore:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt" firestore:"updatedAt"`
+	ID              string    `json:"id" firestore:"id"`
+	CircleID        string    `json:"circleId" firestore:"circleId"`
+	CategoryID      string    `json:"categoryId" firestore:"categoryId"`
+	Name            string    `json:"name" firestore:"name"`
+	DayOfWeek       int       `json:"dayOfWeek" firestore:"dayOfWeek"`             // 0=Sun..6=Sat; first BYDAY when Recurrence is set
+	StartTime       string    `json:"startTime" firestore:"startTime"`             // "14:00"
+	DurationMinutes int       `json:"durationMinutes" firestore:"durationMinutes"` // 0 = unknown
+	Recurrence      string    `json:"recurrence,omitempty" firestore:"recurrence"` // RRULE, e.g. "FREQ=MONTHLY;BYDAY=1SA,3SA"; empty = weekly on DayOfWeek
+	StartDate       string    `json:"startDate,omitempty" firestore:"startDate"`   // "2025-04-05", first possible date (DTSTART)
+	ExDates         []string  `json:"exDates,omitempty" firestore:"exDates"`       // "2025-08-16", dates without practice (EXDATE)
+	Location        string    `json:"location" firestore:"location"`
+	Fee             int       `json:"fee" firestore:"fee"` // per session
+	CreatedBy       string    `json:"createdBy" firestore:"createdBy"`
+	CreatedAt       time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt" firestore:"updatedAt"`
+	Sequence        int       `json:"sequence" firestore:"sequence"` // revision, counting updates
 }
 
 // PracticeSession represents a single practice occurrence.
@@ -172,9 +360,48 @@ type PracticeSession struct {
 	ID        string    `json:"id" firestore:"id"`
 	SeriesID  string    `json:"seriesId" firestore:"seriesId"`
 	Date      time.Time `json:"date" firestore:"date"`
+	EndAt     time.Time `json:"endAt" firestore:"endAt"`
 	Cancelled bool      `json:"cancelled" firestore:"cancelled"`
 	Note      string    `json:"note" firestore:"note"`
 	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+	Sequence  int       `json:"sequence" firestore:"sequence"` // revision, counting updates
+
+	CancelReason     string    `json:"cancelReason,omitempty" firestore:"cancelReason,omitempty"`
+	ExceptionID      string    `json:"exceptionId,omitempty" firestore:"exceptionId,omitempty"` // calendar exception that cancelled it
+	OriginalDate     time.Time `json:"originalDate" firestore:"originalDate"`                   // set when rescheduled
+	RescheduleReason string    `json:"rescheduleReason,omitempty" firestore:"rescheduleReason,omitempty"`
+	RSVPDeadline     time.Time `json:"rsvpDeadline" firestore:"rsvpDeadline"` // zero means until the session starts
+}
+
+// RSVPClosesAt returns when members can no longer change their RSVP: the
+// deadline, or the start of the session if that is earlier (for example after
+// it was moved) or no deadline is set.
+func (s *PracticeSession) RSVPClosesAt() time.Time {
+	if s.RSVPDeadline.IsZero() || s.RSVPDeadline.After(s.Date) {
+		return s.Date
+	}
+	return s.RSVPDeadline
+}
+
+// Move changes the start and end of a session, remembering the date it was
+// originally planned for. A zero endAt keeps the session's duration.
+func (s *PracticeSession) Move(date, endAt time.Time) error {
+	if date.IsZero() {
+		return NewValidationError("date", "is required")
+	}
+	if endAt.IsZero() && !s.EndAt.IsZero() {
+		endAt = date.Add(s.EndAt.Sub(s.Date))
+	}
+	if !endAt.IsZero() && !endAt.After(date) {
+		return NewValidationError("endAt", "must be after date")
+	}
+	if !date.Equal(s.Date) && s.OriginalDate.IsZero() {
+		s.OriginalDate = s.Date
+	}
+	s.Date = date
+	s.EndAt = endAt
+	return nil
 }
 
 // PracticeRSVPStatus represents practice attendance status.
@@ -185,6 +412,11 @@ const (
 	PracticeRSVPNo PracticeRSVPStatus = "NO"
 )
 
+// Valid reports whether s is a known practice attendance status.
+func (s PracticeRSVPStatus) Valid() bool {
+	return s == PracticeRSVPGo || s == PracticeRSVPNo
+}
+
 // PracticeRSVP represents a user's attendance for a practice session.
 type PracticeRSVP struct {
 	ID        string             `json:"id" firestore:"id"`
@@ -193,3 +425,107 @@ type PracticeRSVP struct {
 	Status    PracticeRSVPStatus `json:"status" firestore:"status"`
 	UpdatedAt time.Time          `json:"updatedAt" firestore:"updatedAt"`
 }
+
+// PracticeRSVPID returns the document ID of a user's RSVP for a practice session.
+// Each session/user pair has exactly one RSVP document.
+func PracticeRSVPID(sessionID, userID string) string {
+	return sessionID + "_" + userID
+}
+
+// ExceptionKind classifies a circle calendar exception.
+type ExceptionKind string
+
+const (
+	ExceptionClosure ExceptionKind = "CLOSURE" // venue closed
+	ExceptionExam    ExceptionKind = "EXAM"    // exam period
+	ExceptionHoliday ExceptionKind = "HOLIDAY" // national holiday
+	ExceptionOther   ExceptionKind = "OTHER"
+)
+
+// Valid reports whether k is a known exception kind.
+func (k ExceptionKind) Valid() bool {
+	switch k {
+	case ExceptionClosure, ExceptionExam, ExceptionHoliday, ExceptionOther:
+		return true
+	}
+	return false
+}
+
+// ExceptionAction is what happens to practice sessions on exception dates.
+type ExceptionAction string
+
+const (
+	ExceptionCancel ExceptionAction = "CANCEL" // sessions are kept but marked cancelled
+	ExceptionSkip   ExceptionAction = "SKIP"   // sessions are not generated at all
+)
+
+// Valid reports whether a is a known exception action.
+func (a ExceptionAction) Valid() bool {
+	return a == ExceptionCancel || a == ExceptionSkip
+}
+
+// CalendarException is a date range on a circle's calendar without practice.
+// Existing sessions in the range are cancelled; generated ones are cancelled
+// or skipped according to Action.
+type CalendarException struct {
+	ID        string          `json:"id" firestore:"id"`
+	CircleID  string          `json:"circleId" firestore:"circleId"`
+	Kind      ExceptionKind   `json:"kind" firestore:"kind"`
+	Title     string          `json:"title" firestore:"title"`
+	StartDate string          `json:"startDate" firestore:"startDate"` // "2025-07-20"
+	EndDate   string          `json:"endDate" firestore:"endDate"`     // inclusive
+	Action    ExceptionAction `json:"action" firestore:"action"`
+	CreatedBy string          `json:"createdBy" firestore:"createdBy"`
+	CreatedAt time.Time       `json:"createdAt" firestore:"createdAt"`
+}
+
+// Covers reports whether t's date falls within the exception.
+func (e *CalendarException) Covers(t time.Time) bool {
+	d := t.In(Location).Format("2006-01-02")
+	return d >= e.StartDate && d <= e.EndDate
+}
+
+// Holiday is a national holiday.
+type Holiday struct {
+	Date string `json:"date"` // "2025-01-01"
+	Name string `json:"name"`
+}
+
+// AuditAction identifies what an audit entry records.
+type AuditAction string
+
+const (
+	// AuditRSVPOverride is an admin changing an RSVP after its deadline.
+	AuditRSVPOverride AuditAction = "RSVP_OVERRIDE"
+)
+
+// AuditTarget is the kind of resource an audit entry is about.
+type AuditTarget string
+
+const (
+	AuditTargetEvent           AuditTarget = "EVENT"
+	AuditTargetPracticeSession AuditTarget = "PRACTICE_SESSION"
+)
+
+// AuditEntry records an admin action that bypassed a rule members are held to.
+type AuditEntry struct {
+	ID         string      `json:"id" firestore:"id"`
+	CircleID   string      `json:"circleId" firestore:"circleId"`
+	Action     AuditAction `json:"action" firestore:"action"`
+	TargetType AuditTarget `json:"targetType" firestore:"targetType"`
+	TargetID   string      `json:"targetId" firestore:"targetId"`
+	UserID     string      `json:"userId" firestore:"userId"` // member the action was about
+	Before     string      `json:"before" firestore:"before"`
+	After      string      `json:"after" firestore:"after"`
+	Reason     string      `json:"reason,omitempty" firestore:"reason,omitempty"`
+	ActorID    string      `json:"actorId" firestore:"actorId"`
+	CreatedAt  time.Time   `json:"createdAt" firestore:"createdAt"`
+}
+
+// Notification is a message to a set of users.
+type Notification struct {
+	UserIDs  []string
+	CircleID string
+	Title    string
+	Body     string
+}
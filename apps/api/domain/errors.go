@@ -1,11 +1,61 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 // Domain errors.
 var (
-	ErrNotFound      = errors.New("not found")
-	ErrNotAuthorized = errors.New("not authorized")
-	ErrForbidden     = errors.New("forbidden: not a target user")
-	ErrInvalidInput  = errors.New("invalid input")
+	ErrNotFound           = errors.New("not found")
+	ErrUnauthenticated    = errors.New("unauthenticated")
+	ErrNotAuthorized      = errors.New("not authorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrInvalidInput       = errors.New("invalid input")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrTooLarge           = errors.New("too large")
 )
+
+// FieldError describes why a single input field is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when input fails validation.
+// It matches ErrInvalidInput with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError creates a ValidationError for a single field.
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add records an invalid field.
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// ErrOrNil returns e if any field was recorded, otherwise nil.
+func (e *ValidationError) ErrOrNil() error {
+	if e == nil || len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "invalid input: " + strings.Join(msgs, "; ")
+}
+
+// Is reports whether target is ErrInvalidInput.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// NotificationChannel is a way of reaching users outside the web app.
+type NotificationChannel string
+
+const (
+	ChannelEmail   NotificationChannel = "EMAIL"    // to User.VerifiedEmail
+	ChannelLINE    NotificationChannel = "LINE"     // LINE Messaging API push message
+	ChannelWebhook NotificationChannel = "WEBHOOK"  // signed JSON POST to the user's URL
+	ChannelWebPush NotificationChannel = "WEB_PUSH" // Web Push to the user's browsers
+)
+
+// MaxPushSubscriptions bounds the browsers a user receives Web Push on.
+// Subscribing another browser drops the oldest subscription.
+const MaxPushSubscriptions = 10
+
+// PushSubscription is a browser's Web Push subscription, as returned by
+// PushSubscription.toJSON() in the browser.
+type PushSubscription struct {
+	Endpoint  string    `json:"endpoint" firestore:"endpoint"`
+	P256dh    string    `json:"p256dh" firestore:"p256dh"` // base64url
+	Auth      string    `json:"auth" firestore:"auth"`     // base64url
+	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
+}
+
+// NotificationPreferences are the channels a user wants to be notified on
+// and the addresses used for them. Email goes to User.VerifiedEmail.
+type NotificationPreferences struct {
+	Channels          []NotificationChannel `json:"channels" firestore:"channels"`
+	LINEUserID        string                `json:"lineUserId,omitempty" firestore:"lineUserId"`
+	WebhookURL        string                `json:"webhookUrl,omitempty" firestore:"webhookUrl"`
+	PushSubscriptions []PushSubscription    `json:"pushSubscriptions" firestore:"pushSubscriptions"`
+}
+
+// Reachable reports whether u has enabled ch and set up what it needs.
+func (u *User) Reachable(ch NotificationChannel) bool {
+	p := u.Notifications
+	if p == nil || !slices.Contains(p.Channels, ch) {
+		return false
+	}
+	switch ch {
+	case ChannelEmail:
+		return u.VerifiedEmail() != ""
+	case ChannelLINE:
+		return p.LINEUserID != ""
+	case ChannelWebhook:
+		return p.WebhookURL != ""
+	case ChannelWebPush:
+		return len(p.PushSubscriptions) > 0
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package domain
+
+import "slices"
+
+// PricingMatch is what a pricing rule selects targets of a settlement by.
+type PricingMatch string
+
+const (
+	PricingByRole PricingMatch = "ROLE" // Value is a MemberRole
+	PricingByTag  PricingMatch = "TAG"  // Value is a membership tag
+	PricingByRSVP PricingMatch = "RSVP" // Value is an RSVPStatus on the settlement's event
+	PricingByUser PricingMatch = "USER" // Value is a user ID
+)
+
+// Valid reports whether m is a known match.
+func (m PricingMatch) Valid() bool {
+	switch m {
+	case PricingByRole, PricingByTag, PricingByRSVP, PricingByUser:
+		return true
+	}
+	return false
+}
+
+// MaxPricingRules bounds the pricing rules of a settlement.
+const MaxPricingRules = 20
+
+// PricingRule changes what the matching targets of a settlement owe, either
+// to a percentage of what they would owe or to a fixed amount. Exactly one
+// of Percent and Amount is set.
+type PricingRule struct {
+	Match   PricingMatch `json:"match" firestore:"match"`
+	Value   string       `json:"value" firestore:"value"`
+	Percent *int         `json:"percent,omitempty" firestore:"percent"` // 0-100, rounded to the yen
+	Amount  *int         `json:"amount,omitempty" firestore:"amount"`
+}
+
+// PricingSubject is what pricing rules look at in a target of a settlement.
+type PricingSubject struct {
+	UserID     string
+	Role       MemberRole
+	Tags       []string
+	RSVPStatus RSVPStatus // empty without an RSVP to the settlement's event
+}
+
+// Matches reports whether the rule applies to subj.
+func (r *PricingRule) Matches(subj PricingSubject) bool {
+	switch r.Match {
+	case PricingByRole:
+		return string(subj.Role) == r.Value
+	case PricingByTag:
+		return slices.Contains(subj.Tags, r.Value)
+	case PricingByRSVP:
+		return string(subj.RSVPStatus) == r.Value
+	case PricingByUser:
+		return subj.UserID == r.Value
+	}
+	return false
+}
+
+// PriceFor returns what a target owes under the pricing rules. A USER rule
+// for the target wins; otherwise the first matching rule applies, and
+// targets no rule matches owe AmountFor.
+func (s *Settlement) PriceFor(subj PricingSubject) int {
+	var rule *PricingRule
+	for i := range s.PricingRules {
+		r := &s.PricingRules[i]
+		if !r.Matches(subj) {
+			continue
+		}
+		if r.Match == PricingByUser {
+			rule = r
+			break
+		}
+		if rule == nil {
+			rule = r
+		}
+	}
+
+	base := s.AmountFor(subj.UserID)
+	switch {
+	case rule == nil:
+		return base
+	case rule.Amount != nil:
+		return *rule.Amount
+	default:
+		percent := *rule.Percent
+		return (base*percent + 50) / 100
+	}
+}
+
+// Owed returns what the payer owes for s: the priced amount, or AmountFor
+// for payments created before per-user pricing.
+func (p *Payment) Owed(s *Settlement) int {
+	if p.Amount != nil {
+		return *p.Amount
+	}
+	return s.AmountFor(p.UserID)
+}
@@ -0,0 +1,476 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Location is the time zone practice schedules are defined in. Circles are
+// Japanese, and Japan has no daylight saving time, so a fixed zone avoids
+// depending on tzdata being installed.
+var Location = time.FixedZone("Asia/Tokyo", 9*60*60)
+
+// ParseClock parses an "HH:MM" time of day.
+func ParseClock(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil || len(s) != 5 {
+		return 0, 0, NewValidationError("startTime", "must be a time in HH:MM format")
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// ParseDate parses a "YYYY-MM-DD" date as midnight in Location.
+func ParseDate(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, Location)
+}
+
+// StartOfDay returns midnight of t's date in Location.
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.In(Location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, Location)
+}
+
+// DateKey returns t's date in Location as "20060102".
+func DateKey(t time.Time) string {
+	return t.In(Location).Format("20060102")
+}
+
+// Frequency is the FREQ of a recurrence rule.
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+)
+
+// WeekdayNum is a BYDAY entry such as "SA", "1SA" (first Saturday) or
+// "-1FR" (last Friday). Ordinal 0 means every such weekday.
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// RecurrenceRule is the subset of an iCalendar (RFC 5545) RRULE that practice
+// series support: FREQ, INTERVAL, BYDAY (with ordinals for MONTHLY), UNTIL
+// and COUNT. Weeks start on Monday.
+type RecurrenceRule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Until    time.Time // inclusive; zero means no end date
+	Count    int       // zero means unlimited
+}
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ParseRecurrenceRule parses an RRULE value such as
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;UNTIL=20250331". A leading "RRULE:" is
+// accepted. Date-only UNTIL values are interpreted in Location.
+func ParseRecurrenceRule(s string) (*RecurrenceRule, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return NewValidationError("recurrence", fmt.Sprintf(format, args...))
+	}
+
+	r := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, invalid("malformed part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			if r.Freq != FreqDaily && r.Freq != FreqWeekly && r.Freq != FreqMonthly {
+				return nil, invalid("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 52 {
+				return nil, invalid("INTERVAL must be between 1 and 52")
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, invalid("COUNT must be a positive number")
+			}
+			r.Count = n
+		case "UNTIL":
+			t, err := parseUntil(value)
+			if err != nil {
+				return nil, invalid("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+			}
+			r.Until = t
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(strings.ToUpper(code))
+				if err != nil {
+					return nil, invalid("invalid BYDAY entry %q", code)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				return nil, invalid("only WKST=MO is supported")
+			}
+		default:
+			return nil, invalid("unsupported rule part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, invalid("FREQ is required")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, invalid("COUNT and UNTIL cannot both be set")
+	}
+	for _, wd := range r.ByDay {
+		if wd.Ordinal != 0 && r.Freq != FreqMonthly {
+			return nil, invalid("BYDAY ordinals are only allowed with FREQ=MONTHLY")
+		}
+	}
+	return r, nil
+}
+
+func parseUntil(s string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("20060102", s, Location)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil // whole day
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("too short")
+	}
+	code, num := s[len(s)-2:], s[:len(s)-2]
+	wd := -1
+	for i, c := range weekdayCodes {
+		if c == code {
+			wd = i
+		}
+	}
+	if wd < 0 {
+		return WeekdayNum{}, fmt.Errorf("unknown weekday")
+	}
+	var ordinal int
+	if num != "" {
+		n, err := strconv.Atoi(num)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("bad ordinal")
+		}
+		ordinal = n
+	}
+	return WeekdayNum{Ordinal: ordinal, Weekday: time.Weekday(wd)}, nil
+}
+
+// String formats the rule as an RRULE value.
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			codes[i] = weekdayCodes[wd.Weekday]
+			if wd.Ordinal != 0 {
+				codes[i] = strconv.Itoa(wd.Ordinal) + codes[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// maxRecurrenceDays bounds how far expansion walks past from.
+const maxRecurrenceDays = 10 * 366
+
+// Expand returns the dates matched by the rule starting at dtstart, whose
+// date is always the first occurrence, from the date of from up to (but
+// excluding) end. Each result has dtstart's time of day. COUNT is applied
+// from dtstart regardless of from and end.
+func (r *RecurrenceRule) Expand(dtstart, from, end time.Time) []time.Time {
+	start := dtstart.In(Location)
+	byDay := r.ByDay
+	if len(byDay) == 0 && r.Freq == FreqWeekly {
+		byDay = []WeekdayNum{{Weekday: start.Weekday()}}
+	}
+
+	// Without COUNT, occurrences before from need not be walked: start at
+	// the first period of the rule that can hold one on or after from.
+	firstDay, fromDay := StartOfDay(start), StartOfDay(from)
+	day := firstDay
+	if r.Count == 0 && fromDay.After(firstDay) {
+		day = r.periodStart(firstDay, fromDay)
+	}
+	limit := int(fromDay.Sub(day).Hours()+12)/24 + maxRecurrenceDays
+
+	var result []time.Time
+	n := 0
+	for i := 0; i < limit; i, day = i+1, day.AddDate(0, 0, 1) {
+		occ := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, Location)
+		if !occ.Before(end) || (!r.Until.IsZero() && occ.After(r.Until)) {
+			break
+		}
+		if !day.Equal(firstDay) && !r.matches(start, day, byDay) {
+			continue
+		}
+		n++
+		if !day.Before(fromDay) {
+			result = append(result, occ)
+		}
+		if r.Count > 0 && n >= r.Count {
+			break
+		}
+	}
+	return result
+}
+
+// periodStart returns the first day of the last period of the rule, in step
+// with its INTERVAL from firstDay, that starts on or before day.
+func (r *RecurrenceRule) periodStart(firstDay, day time.Time) time.Time {
+	var start time.Time
+	switch r.Freq {
+	case FreqDaily:
+		days := int(day.Sub(firstDay).Hours()+12) / 24
+		start = firstDay.AddDate(0, 0, days-days%r.Interval)
+	case FreqWeekly:
+		weeks := int(weekStart(day).Sub(weekStart(firstDay)).Hours()+12) / 24 / 7
+		start = weekStart(firstDay).AddDate(0, 0, 7*(weeks-weeks%r.Interval))
+	case FreqMonthly:
+		months := (day.Year()-firstDay.Year())*12 + int(day.Month()-firstDay.Month())
+		start = time.Date(firstDay.Year(), firstDay.Month()+time.Month(months-months%r.Interval), 1, 0, 0, 0, 0, Location)
+	default:
+		return firstDay
+	}
+	if start.Before(firstDay) {
+		return firstDay
+	}
+	return start
+}
+
+// matches reports whether day is an occurrence of a rule that started on start.
+func (r *RecurrenceRule) matches(start, day time.Time, byDay []WeekdayNum) bool {
+	switch r.Freq {
+	case FreqDaily:
+		days := int(day.Sub(StartOfDay(start)).Hours()+12) / 24
+		return days%r.Interval == 0 && (len(byDay) == 0 || matchesWeekday(day, byDay))
+	case FreqWeekly:
+		weeks := int(weekStart(day).Sub(weekStart(start)).Hours()+12) / 24 / 7
+		return weeks%r.Interval == 0 && matchesWeekday(day, byDay)
+	case FreqMonthly:
+		months := (day.Year()-start.Year())*12 + int(day.Month()-start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		if len(byDay) == 0 {
+			return day.Day() == start.Day()
+		}
+		return matchesWeekday(day, byDay)
+	}
+	return false
+}
+
+// matchesWeekday reports whether day matches any BYDAY entry within its month.
+func matchesWeekday(day time.Time, byDay []WeekdayNum) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, Location).Day()
+	for _, wd := range byDay {
+		if wd.Weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case wd.Ordinal == 0:
+			return true
+		case wd.Ordinal > 0 && (day.Day()-1)/7+1 == wd.Ordinal:
+			return true
+		case wd.Ordinal < 0 && (daysInMonth-day.Day())/7+1 == -wd.Ordinal:
+			return true
+		}
+	}
+	return false
+}
+
+// weekStart returns the Monday starting t's week.
+func weekStart(t time.Time) time.Time {
+	d := StartOfDay(t)
+	return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+}
+
+// Rule returns the series' recurrence rule. Series without a Recurrence
+// repeat weekly on DayOfWeek.
+func (s *PracticeSeries) Rule() (*RecurrenceRule, error) {
+	if s.Recurrence == "" {
+		return &RecurrenceRule{
+			Freq:     FreqWeekly,
+			Interval: 1,
+			ByDay:    []WeekdayNum{{Weekday: time.Weekday(s.DayOfWeek)}},
+		}, nil
+	}
+	return ParseRecurrenceRule(s.Recurrence)
+}
+
+// firstStart returns the start time of the series' first possible session:
+// StartDate (or the creation date for older series) at StartTime.
+func (s *PracticeSeries) firstStart(fallback time.Time) (time.Time, error) {
+	hour, minute, err := ParseClock(s.StartTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	day := StartOfDay(fallback)
+	switch {
+	case s.StartDate != "":
+		if day, err = ParseDate(s.StartDate); err != nil {
+			return time.Time{}, NewValidationError("startDate", "must be a date in YYYY-MM-DD format")
+		}
+	case !s.CreatedAt.IsZero():
+		day = StartOfDay(s.CreatedAt)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, Location), nil
+}
+
+// Occurrences returns the start times of the series' sessions whose dates
+// fall within [from, to), in chronological order, honouring the recurrence
+// rule and excluding ExDates.
+func (s *PracticeSeries) Occurrences(from, to time.Time) ([]time.Time, error) {
+	rule, err := s.Rule()
+	if err != nil {
+		return nil, err
+	}
+	dtstart, err := s.firstStart(from)
+	if err != nil {
+		return nil, err
+	}
+	// RFC 5545 leaves a DTSTART that does not match the rule undefined;
+	// start from the first matching date instead.
+	dtstart = firstMatch(rule, dtstart)
+
+	excluded := make(map[string]bool, len(s.ExDates))
+	for _, d := range s.ExDates {
+		if t, err := ParseDate(d); err == nil {
+			excluded[DateKey(t)] = true
+		}
+	}
+
+	var result []time.Time
+	for _, occ := range rule.Expand(dtstart, from, to) {
+		if excluded[DateKey(occ)] {
+			continue
+		}
+		result = append(result, occ)
+	}
+	return result, nil
+}
+
+// firstMatch returns the first date on or after dtstart that satisfies the
+// rule's BYDAY and monthly constraints, keeping dtstart's time of day.
+func firstMatch(r *RecurrenceRule, dtstart time.Time) time.Time {
+	if len(r.ByDay) == 0 {
+		return dtstart
+	}
+	for i := 0; i < 366; i++ {
+		day := dtstart.AddDate(0, 0, i)
+		if matchesWeekday(day, r.ByDay) {
+			return day
+		}
+	}
+	return dtstart
+}
+
+// Duration returns how long each session lasts, or zero if unknown.
+func (s *PracticeSeries) Duration() time.Duration {
+	return time.Duration(s.DurationMinutes) * time.Minute
+}
+
+// PracticeSessionID returns the document ID of the generated session of a
+// series on the date of t, so a date is generated at most once.
+func PracticeSessionID(seriesID string, t time.Time) string {
+	return seriesID + "_" + DateKey(t)
+}
+
+// SetEndTime sets DurationMinutes from an "HH:MM" end time on the same day as StartTime.
+func (s *PracticeSeries) SetEndTime(endTime string) error {
+	sh, sm, err := ParseClock(s.StartTime)
+	if err != nil {
+		return err
+	}
+	eh, em, err := ParseClock(endTime)
+	if err != nil {
+		return NewValidationError("endTime", "must be a time in HH:MM format")
+	}
+	minutes := (eh*60 + em) - (sh*60 + sm)
+	if minutes <= 0 {
+		return NewValidationError("endTime", "must be after startTime")
+	}
+	s.DurationMinutes = minutes
+	return nil
+}
+
+// NormalizeSchedule validates the series' schedule fields and normalizes
+// them: Recurrence is rewritten in canonical form, DayOfWeek follows its first
+// BYDAY, and ExDates are sorted and deduplicated.
+func (s *PracticeSeries) NormalizeSchedule() error {
+	verr := &ValidationError{}
+	if _, _, err := ParseClock(s.StartTime); err != nil {
+		verr.Add("startTime", "must be a time in HH:MM format")
+	}
+	if s.DurationMinutes < 0 || s.DurationMinutes > 24*60 {
+		verr.Add("durationMinutes", "must be between 0 and 1440")
+	}
+	if s.StartDate != "" {
+		if _, err := ParseDate(s.StartDate); err != nil {
+			verr.Add("startDate", "must be a date in YYYY-MM-DD format")
+		}
+	}
+	seen := make(map[string]bool, len(s.ExDates))
+	exDates := make([]string, 0, len(s.ExDates))
+	for i, d := range s.ExDates {
+		if _, err := ParseDate(d); err != nil {
+			verr.Add(fmt.Sprintf("exDates[%d]", i), "must be a date in YYYY-MM-DD format")
+			continue
+		}
+		if !seen[d] {
+			seen[d] = true
+			exDates = append(exDates, d)
+		}
+	}
+	sort.Strings(exDates)
+	s.ExDates = exDates
+
+	if s.Recurrence != "" {
+		rule, err := ParseRecurrenceRule(s.Recurrence)
+		if err != nil {
+			var ruleErr *ValidationError
+			if errors.As(err, &ruleErr) {
+				verr.Fields = append(verr.Fields, ruleErr.Fields...)
+			}
+		} else {
+			s.Recurrence = rule.String()
+			if len(rule.ByDay) > 0 {
+				s.DayOfWeek = int(rule.ByDay[0].Weekday)
+			}
+		}
+	} else if s.DayOfWeek < 0 || s.DayOfWeek > 6 {
+		verr.Add("dayOfWeek", "must be between 0 and 6")
+	}
+	return verr.ErrOrNil()
+}
@@ -0,0 +1,175 @@
+package domain_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// date parses a YYYY-MM-DD date in domain.Location and fails the test on error.
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := domain.ParseDate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name               string
+		rule               string
+		dtstart, from, end string // from defaults to dtstart
+		want               []string
+	}{
+		{
+			name:    "first and third Saturdays",
+			rule:    "FREQ=MONTHLY;BYDAY=1SA,3SA",
+			dtstart: "2025-01-04",
+			end:     "2025-03-01",
+			want:    []string{"2025-01-04", "2025-01-18", "2025-02-01", "2025-02-15"},
+		},
+		{
+			name:    "last Friday",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart: "2025-01-31",
+			end:     "2025-04-01",
+			want:    []string{"2025-01-31", "2025-02-28", "2025-03-28"},
+		},
+		{
+			name:    "every other week on two days",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			dtstart: "2025-01-07",
+			end:     "2025-02-01",
+			want:    []string{"2025-01-07", "2025-01-09", "2025-01-21", "2025-01-23"},
+		},
+		{
+			name:    "count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: "2025-01-01",
+			end:     "2025-02-01",
+			want:    []string{"2025-01-01", "2025-01-02", "2025-01-03"},
+		},
+		{
+			name:    "count from before the window",
+			rule:    "FREQ=DAILY;COUNT=5",
+			dtstart: "2025-01-01",
+			from:    "2025-01-04",
+			end:     "2025-02-01",
+			want:    []string{"2025-01-04", "2025-01-05"},
+		},
+		{
+			name:    "until is inclusive",
+			rule:    "FREQ=WEEKLY;BYDAY=WE;UNTIL=20250115",
+			dtstart: "2025-01-01",
+			end:     "2025-02-01",
+			want:    []string{"2025-01-01", "2025-01-08", "2025-01-15"},
+		},
+		{
+			name:    "monthly on the 31st skips short months",
+			rule:    "FREQ=MONTHLY",
+			dtstart: "2025-01-31",
+			end:     "2025-08-01",
+			want:    []string{"2025-01-31", "2025-03-31", "2025-05-31", "2025-07-31"},
+		},
+		{
+			name:    "weekly series started eleven years earlier",
+			rule:    "FREQ=WEEKLY;BYDAY=SA",
+			dtstart: "2014-01-04",
+			from:    "2025-01-01",
+			end:     "2025-01-26",
+			want:    []string{"2025-01-04", "2025-01-11", "2025-01-18", "2025-01-25"},
+		},
+		{
+			name:    "interval kept across years",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA",
+			dtstart: "2014-01-04",
+			from:    "2025-01-01",
+			end:     "2025-02-01",
+			want:    []string{"2025-01-04", "2025-01-18"},
+		},
+		{
+			name:    "quarterly across years",
+			rule:    "FREQ=MONTHLY;INTERVAL=3",
+			dtstart: "2014-01-15",
+			from:    "2025-01-01",
+			end:     "2026-01-01",
+			want:    []string{"2025-01-15", "2025-04-15", "2025-07-15", "2025-10-15"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := domain.ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dtstart := date(t, tt.dtstart).Add(14 * time.Hour)
+			from := dtstart
+			if tt.from != "" {
+				from = date(t, tt.from)
+			}
+			var got []string
+			for _, occ := range rule.Expand(dtstart, from, date(t, tt.end)) {
+				if occ.Hour() != 14 {
+					t.Errorf("occurrence %v lost the time of day", occ)
+				}
+				got = append(got, occ.Format("2006-01-02"))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOccurrencesOfOldSeries(t *testing.T) {
+	s := &domain.PracticeSeries{StartDate: "2014-01-01", StartTime: "14:00", Recurrence: "FREQ=WEEKLY;BYDAY=SA", ExDates: []string{"2025-01-11"}}
+	got, err := s.Occurrences(date(t, "2025-01-01"), date(t, "2025-02-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var days []string
+	for _, occ := range got {
+		days = append(days, occ.Format("2006-01-02 15:04"))
+	}
+	want := []string{"2025-01-04 14:00", "2025-01-18 14:00", "2025-01-25 14:00"}
+	if !slices.Equal(days, want) {
+		t.Errorf("got %v, want %v", days, want)
+	}
+}
+
+func TestParseRecurrenceRule(t *testing.T) {
+	rule, err := domain.ParseRecurrenceRule("RRULE:freq=monthly;interval=2;byday=-1fr,1sa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rule.String(), "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR,1SA"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	for _, s := range []string{
+		"",
+		"FREQ",
+		"FREQ=YEARLY",
+		"INTERVAL=2",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;INTERVAL=53",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;UNTIL=2025",
+		"FREQ=DAILY;COUNT=3;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1SA",
+		"FREQ=MONTHLY;BYDAY=6SA",
+		"FREQ=MONTHLY;BYDAY=0SA",
+		"FREQ=WEEKLY;WKST=SU",
+		"FREQ=WEEKLY;BYMONTH=1",
+	} {
+		if _, err := domain.ParseRecurrenceRule(s); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("%q: got error %v, want invalid input", s, err)
+		}
+	}
+}
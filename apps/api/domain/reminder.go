@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ReminderKind identifies what a reminder chases.
+type ReminderKind string
+
+const (
+	// ReminderRSVP goes to targets who have not responded to an event.
+	ReminderRSVP ReminderKind = "RSVP"
+	// ReminderPayment goes to payers whose payment is still unpaid.
+	ReminderPayment ReminderKind = "PAYMENT"
+)
+
+// ReminderStatus represents the state of a reminder job.
+type ReminderStatus string
+
+const (
+	ReminderPending ReminderStatus = "PENDING"
+	ReminderSent    ReminderStatus = "SENT"
+	ReminderSkipped ReminderStatus = "SKIPPED" // nobody to remind, superseded or no longer configured
+	ReminderFailed  ReminderStatus = "FAILED"  // delivery kept failing
+)
+
+// MaxReminderOffsets bounds the number of reminders of each kind per target.
+const MaxReminderOffsets = 5
+
+// MaxReminderDays bounds how far from the reference time a reminder can be.
+const MaxReminderDays = 60
+
+// ReminderPolicy configures when the reminders of a circle are sent.
+// Offsets are whole days relative to Event.StartAt or Settlement.DueAt.
+type ReminderPolicy struct {
+	CircleID          string    `json:"circleId" firestore:"circleId"`
+	Enabled           bool      `json:"enabled" firestore:"enabled"`
+	RSVPDaysBefore    []int     `json:"rsvpDaysBefore" firestore:"rsvpDaysBefore"`
+	PaymentDaysBefore []int     `json:"paymentDaysBefore" firestore:"paymentDaysBefore"`
+	PaymentDaysAfter  []int     `json:"paymentDaysAfter" firestore:"paymentDaysAfter"` // overdue reminders
+	UpdatedBy         string    `json:"updatedBy,omitempty" firestore:"updatedBy"`
+	UpdatedAt         time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// DefaultReminderPolicy returns the policy of circles that have not set one:
+// RSVP reminders 3 days and 1 day before events, and payment reminders
+// 3 days before and 1 and 7 days after the due date.
+func DefaultReminderPolicy(circleID string) *ReminderPolicy {
+	return &ReminderPolicy{
+		CircleID:          circleID,
+		Enabled:           true,
+		RSVPDaysBefore:    []int{3, 1},
+		PaymentDaysBefore: []int{3},
+		PaymentDaysAfter:  []int{1, 7},
+	}
+}
+
+// Normalize validates the offsets of p and sorts them from the earliest
+// reminder to the latest, removing duplicates.
+func (p *ReminderPolicy) Normalize() error {
+	verr := &ValidationError{}
+	p.RSVPDaysBefore = normalizeOffsets(verr, "rsvpDaysBefore", p.RSVPDaysBefore, true)
+	p.PaymentDaysBefore = normalizeOffsets(verr, "paymentDaysBefore", p.PaymentDaysBefore, true)
+	p.PaymentDaysAfter = normalizeOffsets(verr, "paymentDaysAfter", p.PaymentDaysAfter, false)
+	return verr.ErrOrNil()
+}
+
+func normalizeOffsets(verr *ValidationError, field string, days []int, before bool) []int {
+	out := make([]int, 0, len(days))
+	for _, d := range days {
+		if d < 1 || d > MaxReminderDays {
+			verr.Add(field, fmt.Sprintf("days must be between 1 and %d", MaxReminderDays))
+			return days
+		}
+		if !slices.Contains(out, d) {
+			out = append(out, d)
+		}
+	}
+	if len(out) > MaxReminderOffsets {
+		verr.Add(field, fmt.Sprintf("must have at most %d entries", MaxReminderOffsets))
+		return days
+	}
+	slices.Sort(out)
+	if before {
+		slices.Reverse(out) // 3 days before comes before 1 day before
+	}
+	return out
+}
+
+// Offsets returns the configured offsets in days for a kind of reminder;
+// reminders before the reference time have negative offsets.
+func (p *ReminderPolicy) Offsets(kind ReminderKind) []int {
+	var offsets []int
+	switch kind {
+	case ReminderRSVP:
+		for _, d := range p.RSVPDaysBefore {
+			offsets = append(offsets, -d)
+		}
+	case ReminderPayment:
+		for _, d := range p.PaymentDaysBefore {
+			offsets = append(offsets, -d)
+		}
+		offsets = append(offsets, p.PaymentDaysAfter...)
+	}
+	return offsets
+}
+
+// ReminderJob is one scheduled reminder about an event or settlement.
+// Its ID is derived from what it is about and its offset, so planning the
+// same reminder twice, even from two processes, yields a single job.
+type ReminderJob struct {
+	ID         string         `json:"id" firestore:"id"`
+	CircleID   string         `json:"circleId" firestore:"circleId"`
+	Kind       ReminderKind   `json:"kind" firestore:"kind"`
+	TargetID   string         `json:"targetId" firestore:"targetId"` // event or settlement ID
+	OffsetDays int            `json:"offsetDays" firestore:"offsetDays"`
+	DueAt      time.Time      `json:"dueAt" firestore:"dueAt"`
+	Status     ReminderStatus `json:"status" firestore:"status"`
+	Attempts   int            `json:"attempts" firestore:"attempts"`
+	RetryAt    time.Time      `json:"retryAt" firestore:"retryAt"` // next attempt after a failed delivery
+	Recipients []string       `json:"recipients" firestore:"recipients"`
+	Note       string         `json:"note,omitempty" firestore:"note"` // why it was skipped or failed
+	SentAt     time.Time      `json:"sentAt" firestore:"sentAt"`
+	CreatedAt  time.Time      `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// ReminderJobID returns the document ID of a reminder job.
+func ReminderJobID(kind ReminderKind, targetID string, offsetDays int) string {
+	return fmt.Sprintf("%s_%s_%d", kind, targetID, offsetDays)
+}
@@ -0,0 +1,50 @@
+package domain
+
+// SplitRounding decides who covers the yen left over when a total expense
+// does not divide evenly among the attendees.
+type SplitRounding string
+
+const (
+	// SplitOrganizerAbsorbs rounds shares down; the organizer covers the rest.
+	SplitOrganizerAbsorbs SplitRounding = "ORGANIZER_ABSORBS"
+	// SplitRoundUp rounds shares up; the surplus stays with the organizer.
+	SplitRoundUp SplitRounding = "ROUND_UP"
+	// SplitSpread has some attendees pay 1 yen more so that the shares add
+	// up to the total.
+	SplitSpread SplitRounding = "SPREAD"
+)
+
+// Valid reports whether r is a known rounding strategy.
+func (r SplitRounding) Valid() bool {
+	switch r {
+	case SplitOrganizerAbsorbs, SplitRoundUp, SplitSpread:
+		return true
+	}
+	return false
+}
+
+// Split divides total among n attendees. It returns the share each pays and
+// how many of them pay 1 yen more.
+func (r SplitRounding) Split(total, n int) (share, plusOne int) {
+	share, remainder := total/n, total%n
+	switch {
+	case remainder == 0:
+		return share, 0
+	case r == SplitRoundUp:
+		return share + 1, 0
+	case r == SplitSpread:
+		return share, remainder
+	default:
+		return share, 0
+	}
+}
+
+// SettlementSplit records how a settlement was split from a total expense.
+type SettlementSplit struct {
+	TotalAmount int           `json:"totalAmount" firestore:"totalAmount"`
+	Rounding    SplitRounding `json:"rounding" firestore:"rounding"`
+	Attendees   int           `json:"attendees" firestore:"attendees"`
+	// OrganizerShare is the part of the total the shares leave over: what
+	// the organizer covers when positive, a surplus when negative.
+	OrganizerShare int `json:"organizerShare" firestore:"organizerShare"`
+}
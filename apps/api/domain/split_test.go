@@ -0,0 +1,32 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		rounding       domain.SplitRounding
+		total, n       int
+		share, plusOne int
+	}{
+		{domain.SplitOrganizerAbsorbs, 1000, 4, 250, 0},
+		{domain.SplitOrganizerAbsorbs, 1003, 4, 250, 0},
+		{domain.SplitRoundUp, 1003, 4, 251, 0},
+		{domain.SplitRoundUp, 1000, 4, 250, 0},
+		{domain.SplitSpread, 1003, 4, 250, 3},
+		{domain.SplitSpread, 1000, 3, 333, 1},
+		{domain.SplitSpread, 5, 5, 1, 0},
+	}
+	for _, tt := range tests {
+		share, plusOne := tt.rounding.Split(tt.total, tt.n)
+		if share != tt.share || plusOne != tt.plusOne {
+			t.Errorf("%s.Split(%d, %d) = %d, %d; want %d, %d", tt.rounding, tt.total, tt.n, share, plusOne, tt.share, tt.plusOne)
+		}
+		if tt.rounding == domain.SplitSpread && share*tt.n+plusOne != tt.total {
+			t.Errorf("%s.Split(%d, %d) shares add up to %d", tt.rounding, tt.total, tt.n, share*tt.n+plusOne)
+		}
+	}
+}
@@ -7,6 +7,7 @@ require (
 	github.com/google/generative-ai-go v0.8.0
 	github.com/rs/cors v1.10.1
 	google.golang.org/api v0.155.0
+	google.golang.org/grpc v1.60.1
 )
 
 require (
@@ -41,6 +42,5 @@ require (
 	google.golang.org/genproto v0.0.0-20231211222908-989df2bf70f3 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20231211222908-989df2bf70f3 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20231212172506-995d672761c0 // indirect
-	google.golang.org/grpc v1.60.1 // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
 )
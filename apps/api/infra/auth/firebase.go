@@ -0,0 +1,14 @@
+package auth
+
+// firebaseJWKSURL serves the public keys used to sign Firebase ID tokens.
+const firebaseJWKSURL = "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com"
+
+// NewFirebaseVerifier creates a Verifier for Firebase Authentication ID tokens
+// issued for the given Firebase/GCP project.
+func NewFirebaseVerifier(projectID string) *Verifier {
+	return NewVerifier(
+		NewRemoteKeySet(firebaseJWKSURL),
+		"https://securetoken.google.com/"+projectID,
+		projectID,
+	)
+}
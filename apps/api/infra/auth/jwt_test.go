@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example"
+	testAudience = "circle-app"
+)
+
+// testKeys are signing keys generated once for the package's tests.
+type testKeys struct {
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	hmac []byte
+}
+
+var (
+	keysOnce sync.Once
+	keys     testKeys
+)
+
+func generatedKeys(t *testing.T) testKeys {
+	t.Helper()
+	keysOnce.Do(func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+		keys = testKeys{rsa: rsaKey, ec: ecKey, hmac: secret}
+	})
+	return keys
+}
+
+// validClaims returns claims the test verifiers accept.
+func validClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss": testIssuer,
+		"aud": testAudience,
+		"sub": "user-1",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+// sign builds a token with the given header alg and kid, signed with key:
+// an *rsa.PrivateKey, an *ecdsa.PrivateKey or a []byte HMAC secret. A nil
+// key leaves the signature empty.
+func sign(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	input := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch k := key.(type) {
+	case nil:
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	default:
+		t.Fatalf("unsupported key %T", key)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newTestVerifier(t *testing.T) *Verifier {
+	k := generatedKeys(t)
+	return NewVerifier(NewStaticKeySet(map[string]crypto.PublicKey{
+		"rsa-1":  &k.rsa.PublicKey,
+		"ec-1":   &k.ec.PublicKey,
+		"hmac-1": k.hmac,
+	}), testIssuer, testAudience)
+}
+
+func TestVerifyAcceptsValidTokens(t *testing.T) {
+	k := generatedKeys(t)
+	v := newTestVerifier(t)
+
+	tests := []struct {
+		alg, kid string
+		key      any
+	}{
+		{"RS256", "rsa-1", k.rsa},
+		{"ES256", "ec-1", k.ec},
+		{"HS256", "hmac-1", k.hmac},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			id, err := v.VerifyToken(context.Background(), sign(t, tt.alg, tt.kid, tt.key, validClaims()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id.UserID != "user-1" {
+				t.Errorf("user ID = %q, want user-1", id.UserID)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenReturnsOnlyVerifiedEmail(t *testing.T) {
+	k := generatedKeys(t)
+	v := newTestVerifier(t)
+
+	for _, verified := range []bool{true, false} {
+		claims := validClaims()
+		claims["email"] = "user@example.com"
+		claims["email_verified"] = verified
+		id, err := v.VerifyToken(context.Background(), sign(t, "RS256", "rsa-1", k.rsa, claims))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := ""
+		if verified {
+			want = "user@example.com"
+		}
+		if id.Email != want {
+			t.Errorf("email_verified %v: email = %q, want %q", verified, id.Email, want)
+		}
+	}
+}
+
+func TestVerifyAcceptsAudienceList(t *testing.T) {
+	k := generatedKeys(t)
+	claims := validClaims()
+	claims["aud"] = []string{"other", testAudience}
+
+	if _, err := newTestVerifier(t).Verify(context.Background(), sign(t, "RS256", "rsa-1", k.rsa, claims)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	k := generatedKeys(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&k.rsa.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	with := func(name string, value any) map[string]any {
+		c := validClaims()
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", sign(t, "RS256", "rsa-1", k.rsa, with("exp", now.Add(-2*time.Minute).Unix()))},
+		{"not yet valid", sign(t, "RS256", "rsa-1", k.rsa, with("nbf", now.Add(5*time.Minute).Unix()))},
+		{"issued in the future", sign(t, "RS256", "rsa-1", k.rsa, with("iat", now.Add(5*time.Minute).Unix()))},
+		{"missing exp", sign(t, "RS256", "rsa-1", k.rsa, with("exp", nil))},
+		{"missing sub", sign(t, "RS256", "rsa-1", k.rsa, with("sub", nil))},
+		{"wrong issuer", sign(t, "RS256", "rsa-1", k.rsa, with("iss", "https://evil.example"))},
+		{"wrong audience", sign(t, "RS256", "rsa-1", k.rsa, with("aud", "other-app"))},
+		{"alg none", sign(t, "none", "rsa-1", nil, validClaims())},
+		{"alg none without kid", sign(t, "none", "", nil, validClaims())},
+		{"HS256 signed with the RSA public key", sign(t, "HS256", "rsa-1", publicDER, validClaims())},
+		{"ES256 header on an RSA key", sign(t, "ES256", "rsa-1", k.ec, validClaims())},
+		{"RS256 signed by another key", sign(t, "RS256", "rsa-1", other, validClaims())},
+		{"unknown kid", sign(t, "RS256", "rsa-2", k.rsa, validClaims())},
+		{"wrong HMAC secret", sign(t, "HS256", "hmac-1", []byte("guessed"), validClaims())},
+		{"malformed", "not-a-token"},
+	}
+	v := newTestVerifier(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("got %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAllowsClockSkew(t *testing.T) {
+	k := generatedKeys(t)
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	claims["nbf"] = time.Now().Add(30 * time.Second).Unix()
+
+	if _, err := newTestVerifier(t).Verify(context.Background(), sign(t, "RS256", "rsa-1", k.rsa, claims)); err != nil {
+		t.Errorf("token within the leeway rejected: %v", err)
+	}
+}
+
+func TestHMACKeySetWithoutKid(t *testing.T) {
+	k := generatedKeys(t)
+	v := NewVerifier(NewHMACKeySet(k.hmac), "", "")
+
+	if _, err := v.Verify(context.Background(), sign(t, "HS256", "", k.hmac, validClaims())); err != nil {
+		t.Fatal(err)
+	}
+	_, err := v.Verify(context.Background(), sign(t, "RS256", "", k.rsa, validClaims()))
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("RS256 token against an HMAC secret: got %v, want ErrInvalidToken", err)
+	}
+}
+
+// jwksServer serves a JSON Web Key Set that tests can replace.
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches int
+}
+
+func (s *jwksServer) set(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches++
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func TestRemoteKeySetFollowsRotation(t *testing.T) {
+	k := generatedKeys(t)
+	next, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := &jwksServer{}
+	jwks.set(rsaJWK("old", &k.rsa.PublicKey), ecJWK("ec", &k.ec.PublicKey))
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	keySet := NewRemoteKeySet(srv.URL)
+	v := NewVerifier(keySet, testIssuer, testAudience)
+	ctx := context.Background()
+	oldToken := sign(t, "RS256", "old", k.rsa, validClaims())
+	newToken := sign(t, "RS256", "new", next, validClaims())
+
+	for _, token := range []string{oldToken, sign(t, "ES256", "ec", k.ec, validClaims())} {
+		if _, err := v.Verify(ctx, token); err != nil {
+			t.Fatalf("token from the key set rejected: %v", err)
+		}
+	}
+	if jwks.fetches != 1 {
+		t.Errorf("key set fetched %d times, want cached after 1", jwks.fetches)
+	}
+
+	// The provider rotates to a new key. Unknown kids refetch the set at most
+	// once per minRefreshInterval.
+	jwks.set(rsaJWK("new", &next.PublicKey))
+	if _, err := v.Verify(ctx, newToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("new kid right after a fetch: got %v, want ErrInvalidToken", err)
+	}
+	keySet.mu.Lock()
+	keySet.lastRefresh = keySet.lastRefresh.Add(-minRefreshInterval)
+	keySet.mu.Unlock()
+
+	if _, err := v.Verify(ctx, newToken); err != nil {
+		t.Fatalf("token signed with the rotated key rejected: %v", err)
+	}
+	if _, err := v.Verify(ctx, oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token with a retired kid: got %v, want ErrInvalidToken", err)
+	}
+	if jwks.fetches != 2 {
+		t.Errorf("key set fetched %d times, want 2", jwks.fetches)
+	}
+}
@@ -25,7 +25,7 @@ func (r *AnnouncementRepository) Create(ctx context.Context, a *domain.Announcem
 	a.CreatedAt = time.Now()
 	docRef, _, err := r.client.Collection("announcements").Add(ctx, a)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 	a.ID = docRef.ID
 	return nil
@@ -35,11 +35,11 @@ func (r *AnnouncementRepository) Create(ctx context.Context, a *domain.Announcem
 func (r *AnnouncementRepository) GetByID(ctx context.Context, id string) (*domain.Announcement, error) {
 	doc, err := r.client.Collection("announcements").Doc(id).Get(ctx)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	var a domain.Announcement
 	if err := doc.DataTo(&a); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	a.ID = doc.Ref.ID
 	return &a, nil
@@ -59,11 +59,11 @@ func (r *AnnouncementRepository) GetByEvent(ctx context.Context, eventID string)
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var a domain.Announcement
 		if err := doc.DataTo(&a); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		a.ID = doc.Ref.ID
 		announcements = append(announcements, &a)
@@ -88,11 +88,11 @@ func (r *AnnouncementRepository) GetByCircle(ctx context.Context, circleID strin
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var a domain.Announcement
 		if err := doc.DataTo(&a); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		a.ID = doc.Ref.ID
 		announcements = append(announcements, &a)
@@ -110,11 +110,11 @@ func (r *AnnouncementRepository) GetByCircle(ctx context.Context, circleID strin
 func (r *AnnouncementRepository) Update(ctx context.Context, a *domain.Announcement) error {
 	a.UpdatedAt = time.Now()
 	_, err := r.client.Collection("announcements").Doc(a.ID).Set(ctx, a)
-	return err
+	return translateError(err)
 }
 
 // Delete deletes an announcement.
 func (r *AnnouncementRepository) Delete(ctx context.Context, id string) error {
 	_, err := r.client.Collection("announcements").Doc(id).Delete(ctx)
-	return err
+	return translateError(err)
 }
@@ -0,0 +1,59 @@
+package firestore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/noa/circle-app/api/domain"
+	"google.golang.org/api/iterator"
+)
+
+type AuditLogRepository struct {
+	client *firestore.Client
+}
+
+func NewAuditLogRepository(client *firestore.Client) *AuditLogRepository {
+	return &AuditLogRepository{client: client}
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, e *domain.AuditEntry) error {
+	e.CreatedAt = time.Now()
+	docRef := r.client.Collection("audit_log").NewDoc()
+	if err := createDoc(ctx, docRef, e); err != nil {
+		return translateError(err)
+	}
+	e.ID = docRef.ID
+	return nil
+}
+
+func (r *AuditLogRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.AuditEntry, error) {
+	iter := r.client.Collection("audit_log").
+		Where("circleId", "==", circleID).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var entries []*domain.AuditEntry
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, translateError(err)
+		}
+		var e domain.AuditEntry
+		if err := doc.DataTo(&e); err != nil {
+			return nil, translateError(err)
+		}
+		e.ID = doc.Ref.ID
+		entries = append(entries, &e)
+	}
+
+	// Sort by createdAt descending in Go instead of Firestore OrderBy (avoids needing composite index)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
@@ -0,0 +1,77 @@
+package firestore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/noa/circle-app/api/domain"
+	"google.golang.org/api/iterator"
+)
+
+type CalendarExceptionRepository struct {
+	client *firestore.Client
+}
+
+func NewCalendarExceptionRepository(client *firestore.Client) *CalendarExceptionRepository {
+	return &CalendarExceptionRepository{client: client}
+}
+
+func (r *CalendarExceptionRepository) Create(ctx context.Context, e *domain.CalendarException) error {
+	e.CreatedAt = time.Now()
+	docRef := r.client.Collection("calendar_exceptions").NewDoc()
+	if err := createDoc(ctx, docRef, e); err != nil {
+		return translateError(err)
+	}
+	e.ID = docRef.ID
+	return nil
+}
+
+func (r *CalendarExceptionRepository) GetByID(ctx context.Context, id string) (*domain.CalendarException, error) {
+	doc, err := r.client.Collection("calendar_exceptions").Doc(id).Get(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	var e domain.CalendarException
+	if err := doc.DataTo(&e); err != nil {
+		return nil, translateError(err)
+	}
+	e.ID = doc.Ref.ID
+	return &e, nil
+}
+
+func (r *CalendarExceptionRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.CalendarException, error) {
+	iter := r.client.Collection("calendar_exceptions").
+		Where("circleId", "==", circleID).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var exceptions []*domain.CalendarException
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, translateError(err)
+		}
+		var e domain.CalendarException
+		if err := doc.DataTo(&e); err != nil {
+			return nil, translateError(err)
+		}
+		e.ID = doc.Ref.ID
+		exceptions = append(exceptions, &e)
+	}
+
+	// Sort by start date in Go instead of Firestore OrderBy (avoids needing composite index)
+	sort.Slice(exceptions, func(i, j int) bool {
+		return exceptions[i].StartDate < exceptions[j].StartDate
+	})
+	return exceptions, nil
+}
+
+func (r *CalendarExceptionRepository) Delete(ctx context.Context, id string) error {
+	err := deleteDoc(ctx, r.client.Collection("calendar_exceptions").Doc(id))
+	return translateError(err)
+}
@@ -25,7 +25,7 @@ func (r *CircleRepository) Create(ctx context.Context, c *domain.Circle) error {
 	c.CreatedAt = time.Now()
 	docRef, _, err := r.client.Collection("circles").Add(ctx, c)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 	c.ID = docRef.ID
 	return nil
@@ -35,16 +35,47 @@ func (r *CircleRepository) Create(ctx context.Context, c *domain.Circle) error {
 func (r *CircleRepository) GetByID(ctx context.Context, id string) (*domain.Circle, error) {
 	doc, err := r.client.Collection("circles").Doc(id).Get(ctx)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	var c domain.Circle
 	if err := doc.DataTo(&c); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	c.ID = doc.Ref.ID
 	return &c, nil
 }
 
+// Update updates a circle.
+func (r *CircleRepository) Update(ctx context.Context, c *domain.Circle) error {
+	c.UpdatedAt = time.Now()
+	_, err := r.client.Collection("circles").Doc(c.ID).Set(ctx, c)
+	return translateError(err)
+}
+
+// List returns all circles.
+func (r *CircleRepository) List(ctx context.Context) ([]*domain.Circle, error) {
+	iter := r.client.Collection("circles").Documents(ctx)
+	defer iter.Stop()
+
+	var circles []*domain.Circle
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, translateError(err)
+		}
+		var c domain.Circle
+		if err := doc.DataTo(&c); err != nil {
+			return nil, translateError(err)
+		}
+		c.ID = doc.Ref.ID
+		circles = append(circles, &c)
+	}
+	return circles, nil
+}
+
 // MembershipRepository implements port.MembershipRepository.
 type MembershipRepository struct {
 	client *firestore.Client
@@ -58,9 +89,9 @@ func NewMembershipRepository(client *firestore.Client) *MembershipRepository {
 // Create creates a new membership.
 func (r *MembershipRepository) Create(ctx context.Context, m *domain.Membership) error {
 	m.JoinedAt = time.Now()
-	docRef, _, err := r.client.Collection("memberships").Add(ctx, m)
-	if err != nil {
-		return err
+	docRef := r.client.Collection("memberships").NewDoc()
+	if err := createDoc(ctx, docRef, m); err != nil {
+		return translateError(err)
 	}
 	m.ID = docRef.ID
 	return nil
@@ -68,7 +99,7 @@ func (r *MembershipRepository) Create(ctx context.Context, m *domain.Membership)
 
 // GetByCircle returns all memberships for a circle.
 func (r *MembershipRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.Membership, error) {
-	iter := r.client.Collection("memberships").Where("circleId", "==", circleID).Documents(ctx)
+	iter := queryDocs(ctx, r.client.Collection("memberships").Where("circleId", "==", circleID))
 	defer iter.Stop()
 
 	var memberships []*domain.Membership
@@ -78,11 +109,11 @@ func (r *MembershipRepository) GetByCircle(ctx context.Context, circleID string)
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var m domain.Membership
 		if err := doc.DataTo(&m); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		m.ID = doc.Ref.ID
 		memberships = append(memberships, &m)
@@ -90,13 +121,18 @@ func (r *MembershipRepository) GetByCircle(ctx context.Context, circleID string)
 	return memberships, nil
 }
 
+// Update updates a membership.
+func (r *MembershipRepository) Update(ctx context.Context, m *domain.Membership) error {
+	err := setDoc(ctx, r.client.Collection("memberships").Doc(m.ID), m)
+	return translateError(err)
+}
+
 // GetByCircleAndUser returns membership for a specific user in a circle.
 func (r *MembershipRepository) GetByCircleAndUser(ctx context.Context, circleID, userID string) (*domain.Membership, error) {
-	iter := r.client.Collection("memberships").
+	iter := queryDocs(ctx, r.client.Collection("memberships").
 		Where("circleId", "==", circleID).
 		Where("userId", "==", userID).
-		Limit(1).
-		Documents(ctx)
+		Limit(1))
 	defer iter.Stop()
 
 	doc, err := iter.Next()
@@ -104,12 +140,12 @@ func (r *MembershipRepository) GetByCircleAndUser(ctx context.Context, circleID,
 		return nil, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	var m domain.Membership
 	if err := doc.DataTo(&m); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	m.ID = doc.Ref.ID
 	return &m, nil
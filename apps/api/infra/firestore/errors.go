@@ -0,0 +1,37 @@
+package firestore
+
+import (
+	"fmt"
+
+	"github.com/noa/circle-app/api/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// translateError converts Firestore (gRPC) errors into domain errors so that
+// callers never depend on storage-specific error values. Errors that do not
+// come from gRPC, such as domain errors returned inside a transaction, are
+// returned unchanged.
+//
+// FailedPrecondition is deliberately not translated: no repository issues a
+// conditional write (creates report AlreadyExists), so it only comes from
+// problems such as a missing composite index and must surface as an internal
+// error rather than a 412.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); !ok {
+		return err
+	}
+	switch status.Code(err) {
+	case codes.NotFound:
+		return domain.ErrNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return domain.ErrConflict
+	case codes.InvalidArgument:
+		return domain.ErrInvalidInput
+	default:
+		return fmt.Errorf("firestore: %w", err)
+	}
+}
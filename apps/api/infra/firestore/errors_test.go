@@ -0,0 +1,42 @@
+package firestore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/noa/circle-app/api/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error // nil: not a domain error
+	}{
+		{status.Error(codes.NotFound, "no document"), domain.ErrNotFound},
+		{status.Error(codes.AlreadyExists, "document exists"), domain.ErrConflict},
+		{status.Error(codes.Aborted, "too much contention"), domain.ErrConflict},
+		{status.Error(codes.FailedPrecondition, "The query requires an index"), nil},
+		{status.Error(codes.Unavailable, "try again"), nil},
+		{domain.ErrForbidden, domain.ErrForbidden},
+	}
+	domainErrs := []error{domain.ErrNotFound, domain.ErrConflict, domain.ErrPreconditionFailed, domain.ErrInvalidInput, domain.ErrForbidden}
+	for _, tt := range tests {
+		got := translateError(tt.err)
+		if tt.want != nil {
+			if !errors.Is(got, tt.want) {
+				t.Errorf("translateError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+			continue
+		}
+		for _, derr := range domainErrs {
+			if errors.Is(got, derr) {
+				t.Errorf("translateError(%v) = %v, want an internal error", tt.err, got)
+			}
+		}
+	}
+	if translateError(nil) != nil {
+		t.Error("translateError(nil) != nil")
+	}
+}
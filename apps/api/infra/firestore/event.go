@@ -23,9 +23,10 @@ func NewEventRepository(client *firestore.Client) *EventRepository {
 // Create creates a new event.
 func (r *EventRepository) Create(ctx context.Context, e *domain.Event) error {
 	e.CreatedAt = time.Now()
-	docRef, _, err := r.client.Collection("events").Add(ctx, e)
-	if err != nil {
-		return err
+	e.UpdatedAt = e.CreatedAt
+	docRef := r.client.Collection("events").NewDoc()
+	if err := createDoc(ctx, docRef, e); err != nil {
+		return translateError(err)
 	}
 	e.ID = docRef.ID
 	return nil
@@ -33,13 +34,13 @@ func (r *EventRepository) Create(ctx context.Context, e *domain.Event) error {
 
 // GetByID returns an event by ID.
 func (r *EventRepository) GetByID(ctx context.Context, id string) (*domain.Event, error) {
-	doc, err := r.client.Collection("events").Doc(id).Get(ctx)
+	doc, err := getDoc(ctx, r.client.Collection("events").Doc(id))
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	var e domain.Event
 	if err := doc.DataTo(&e); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	e.ID = doc.Ref.ID
 	return &e, nil
@@ -47,9 +48,8 @@ func (r *EventRepository) GetByID(ctx context.Context, id string) (*domain.Event
 
 // GetByCircle returns all events for a circle.
 func (r *EventRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.Event, error) {
-	iter := r.client.Collection("events").
-		Where("circleId", "==", circleID).
-		Documents(ctx)
+	iter := queryDocs(ctx, r.client.Collection("events").
+		Where("circleId", "==", circleID))
 	defer iter.Stop()
 
 	var events []*domain.Event
@@ -59,11 +59,11 @@ func (r *EventRepository) GetByCircle(ctx context.Context, circleID string) ([]*
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var e domain.Event
 		if err := doc.DataTo(&e); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		e.ID = doc.Ref.ID
 		events = append(events, &e)
@@ -79,12 +79,14 @@ func (r *EventRepository) GetByCircle(ctx context.Context, circleID string) ([]*
 
 // Update updates an event.
 func (r *EventRepository) Update(ctx context.Context, e *domain.Event) error {
-	_, err := r.client.Collection("events").Doc(e.ID).Set(ctx, e)
-	return err
+	e.UpdatedAt = time.Now()
+	e.Sequence++
+	err := setDoc(ctx, r.client.Collection("events").Doc(e.ID), e)
+	return translateError(err)
 }
 
 // Delete deletes an event.
 func (r *EventRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.client.Collection("events").Doc(id).Delete(ctx)
-	return err
+	err := deleteDoc(ctx, r.client.Collection("events").Doc(id))
+	return translateError(err)
 }
@@ -22,12 +22,25 @@ func (r *PracticeCategoryRepository) Create(ctx context.Context, c *domain.Pract
 	c.CreatedAt = time.Now()
 	docRef, _, err := r.client.Collection("practice_categories").Add(ctx, c)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 	c.ID = docRef.ID
 	return nil
 }
 
+func (r *PracticeCategoryRepository) GetByID(ctx context.Context, id string) (*domain.PracticeCategory, error) {
+	doc, err := r.client.Collection("practice_categories").Doc(id).Get(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	var c domain.PracticeCategory
+	if err := doc.DataTo(&c); err != nil {
+		return nil, translateError(err)
+	}
+	c.ID = doc.Ref.ID
+	return &c, nil
+}
+
 func (r *PracticeCategoryRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.PracticeCategory, error) {
 	iter := r.client.Collection("practice_categories").
 		Where("circleId", "==", circleID).
@@ -41,11 +54,11 @@ func (r *PracticeCategoryRepository) GetByCircle(ctx context.Context, circleID s
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var c domain.PracticeCategory
 		if err := doc.DataTo(&c); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		c.ID = doc.Ref.ID
 		categories = append(categories, &c)
@@ -61,10 +74,10 @@ func (r *PracticeCategoryRepository) GetByCircle(ctx context.Context, circleID s
 
 func (r *PracticeCategoryRepository) Update(ctx context.Context, c *domain.PracticeCategory) error {
 	_, err := r.client.Collection("practice_categories").Doc(c.ID).Set(ctx, c)
-	return err
+	return translateError(err)
 }
 
 func (r *PracticeCategoryRepository) Delete(ctx context.Context, id string) error {
 	_, err := r.client.Collection("practice_categories").Doc(id).Delete(ctx)
-	return err
+	return translateError(err)
 }
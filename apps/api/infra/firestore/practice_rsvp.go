@@ -7,6 +7,8 @@ import (
 	"cloud.google.com/go/firestore"
 	"github.com/noa/circle-app/api/domain"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type PracticeRSVPRepository struct {
@@ -17,64 +19,34 @@ func NewPracticeRSVPRepository(client *firestore.Client) *PracticeRSVPRepository
 	return &PracticeRSVPRepository{client: client}
 }
 
+// Upsert creates or updates a practice RSVP. The document ID is derived from
+// the session and user, so concurrent submissions converge on one document.
 func (r *PracticeRSVPRepository) Upsert(ctx context.Context, rsvp *domain.PracticeRSVP) error {
+	rsvp.ID = domain.PracticeRSVPID(rsvp.SessionID, rsvp.UserID)
 	rsvp.UpdatedAt = time.Now()
-
-	// Check existing
-	iter := r.client.Collection("practice_rsvps").
-		Where("sessionId", "==", rsvp.SessionID).
-		Where("userId", "==", rsvp.UserID).
-		Limit(1).
-		Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err == iterator.Done {
-		// Create new
-		docRef, _, err := r.client.Collection("practice_rsvps").Add(ctx, rsvp)
-		if err != nil {
-			return err
-		}
-		rsvp.ID = docRef.ID
-		return nil
-	}
-	if err != nil {
-		return err
-	}
-
-	// Update existing
-	rsvp.ID = doc.Ref.ID
-	_, err = r.client.Collection("practice_rsvps").Doc(rsvp.ID).Set(ctx, rsvp)
-	return err
+	err := setDoc(ctx, r.client.Collection("practice_rsvps").Doc(rsvp.ID), rsvp)
+	return translateError(err)
 }
 
 func (r *PracticeRSVPRepository) GetBySessionAndUser(ctx context.Context, sessionID, userID string) (*domain.PracticeRSVP, error) {
-	iter := r.client.Collection("practice_rsvps").
-		Where("sessionId", "==", sessionID).
-		Where("userId", "==", userID).
-		Limit(1).
-		Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err == iterator.Done {
+	doc, err := getDoc(ctx, r.client.Collection("practice_rsvps").Doc(domain.PracticeRSVPID(sessionID, userID)))
+	if status.Code(err) == codes.NotFound {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	var rsvp domain.PracticeRSVP
 	if err := doc.DataTo(&rsvp); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	rsvp.ID = doc.Ref.ID
 	return &rsvp, nil
 }
 
 func (r *PracticeRSVPRepository) GetBySession(ctx context.Context, sessionID string) ([]*domain.PracticeRSVP, error) {
-	iter := r.client.Collection("practice_rsvps").
-		Where("sessionId", "==", sessionID).
-		Documents(ctx)
+	iter := queryDocs(ctx, r.client.Collection("practice_rsvps").
+		Where("sessionId", "==", sessionID))
 	defer iter.Stop()
 
 	var rsvps []*domain.PracticeRSVP
@@ -84,11 +56,11 @@ func (r *PracticeRSVPRepository) GetBySession(ctx context.Context, sessionID str
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var rsvp domain.PracticeRSVP
 		if err := doc.DataTo(&rsvp); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		rsvp.ID = doc.Ref.ID
 		rsvps = append(rsvps, &rsvp)
@@ -98,9 +70,8 @@ func (r *PracticeRSVPRepository) GetBySession(ctx context.Context, sessionID str
 
 func (r *PracticeRSVPRepository) GetBySeriesAndUser(ctx context.Context, seriesID, userID string) ([]*domain.PracticeRSVP, error) {
 	// First get all sessions for the series
-	sessionIter := r.client.Collection("practice_sessions").
-		Where("seriesId", "==", seriesID).
-		Documents(ctx)
+	sessionIter := queryDocs(ctx, r.client.Collection("practice_sessions").
+		Where("seriesId", "==", seriesID))
 	defer sessionIter.Stop()
 
 	var sessionIDs []string
@@ -110,7 +81,7 @@ func (r *PracticeRSVPRepository) GetBySeriesAndUser(ctx context.Context, seriesI
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		sessionIDs = append(sessionIDs, doc.Ref.ID)
 	}
@@ -122,11 +93,10 @@ func (r *PracticeRSVPRepository) GetBySeriesAndUser(ctx context.Context, seriesI
 	// Query RSVPs for each session (Firestore doesn't support IN queries with compound filters well)
 	var rsvps []*domain.PracticeRSVP
 	for _, sid := range sessionIDs {
-		iter := r.client.Collection("practice_rsvps").
+		iter := queryDocs(ctx, r.client.Collection("practice_rsvps").
 			Where("sessionId", "==", sid).
 			Where("userId", "==", userID).
-			Limit(1).
-			Documents(ctx)
+			Limit(1))
 
 		doc, err := iter.Next()
 		iter.Stop()
@@ -134,11 +104,11 @@ func (r *PracticeRSVPRepository) GetBySeriesAndUser(ctx context.Context, seriesI
 			continue
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var rsvp domain.PracticeRSVP
 		if err := doc.DataTo(&rsvp); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		rsvp.ID = doc.Ref.ID
 		rsvps = append(rsvps, &rsvp)
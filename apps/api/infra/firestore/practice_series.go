@@ -21,7 +21,7 @@ func (r *PracticeSeriesRepository) Create(ctx context.Context, s *domain.Practic
 	s.CreatedAt = time.Now()
 	docRef, _, err := r.client.Collection("practice_series").Add(ctx, s)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 	s.ID = docRef.ID
 	return nil
@@ -30,20 +30,43 @@ func (r *PracticeSeriesRepository) Create(ctx context.Context, s *domain.Practic
 func (r *PracticeSeriesRepository) GetByID(ctx context.Context, id string) (*domain.PracticeSeries, error) {
 	doc, err := r.client.Collection("practice_series").Doc(id).Get(ctx)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	var s domain.PracticeSeries
 	if err := doc.DataTo(&s); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	s.ID = doc.Ref.ID
 	return &s, nil
 }
 
 func (r *PracticeSeriesRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.PracticeSeries, error) {
-	iter := r.client.Collection("practice_series").
-		Where("circleId", "==", circleID).
-		Documents(ctx)
+	iter := queryDocs(ctx, r.client.Collection("practice_series").
+		Where("circleId", "==", circleID))
+	defer iter.Stop()
+
+	var series []*domain.PracticeSeries
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, translateError(err)
+		}
+		var s domain.PracticeSeries
+		if err := doc.DataTo(&s); err != nil {
+			return nil, translateError(err)
+		}
+		s.ID = doc.Ref.ID
+		series = append(series, &s)
+	}
+	return series, nil
+}
+
+// List returns every practice series.
+func (r *PracticeSeriesRepository) List(ctx context.Context) ([]*domain.PracticeSeries, error) {
+	iter := r.client.Collection("practice_series").Documents(ctx)
 	defer iter.Stop()
 
 	var series []*domain.PracticeSeries
@@ -53,11 +76,11 @@ func (r *PracticeSeriesRepository) GetByCircle(ctx context.Context, circleID str
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var s domain.PracticeSeries
 		if err := doc.DataTo(&s); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		s.ID = doc.Ref.ID
 		series = append(series, &s)
@@ -79,11 +102,11 @@ func (r *PracticeSeriesRepository) GetByCategory(ctx context.Context, categoryID
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var s domain.PracticeSeries
 		if err := doc.DataTo(&s); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		s.ID = doc.Ref.ID
 		series = append(series, &s)
@@ -93,11 +116,12 @@ func (r *PracticeSeriesRepository) GetByCategory(ctx context.Context, categoryID
 
 func (r *PracticeSeriesRepository) Update(ctx context.Context, s *domain.PracticeSeries) error {
 	s.UpdatedAt = time.Now()
+	s.Sequence++
 	_, err := r.client.Collection("practice_series").Doc(s.ID).Set(ctx, s)
-	return err
+	return translateError(err)
 }
 
 func (r *PracticeSeriesRepository) Delete(ctx context.Context, id string) error {
 	_, err := r.client.Collection("practice_series").Doc(id).Delete(ctx)
-	return err
+	return translateError(err)
 }
@@ -20,31 +20,35 @@ func NewPracticeSessionRepository(client *firestore.Client) *PracticeSessionRepo
 
 func (r *PracticeSessionRepository) Create(ctx context.Context, s *domain.PracticeSession) error {
 	s.CreatedAt = time.Now()
-	docRef, _, err := r.client.Collection("practice_sessions").Add(ctx, s)
-	if err != nil {
-		return err
+	s.UpdatedAt = s.CreatedAt
+	coll := r.client.Collection("practice_sessions")
+	docRef := coll.NewDoc()
+	if s.ID != "" {
+		docRef = coll.Doc(s.ID)
+	}
+	if err := createDoc(ctx, docRef, s); err != nil {
+		return translateError(err)
 	}
 	s.ID = docRef.ID
 	return nil
 }
 
 func (r *PracticeSessionRepository) GetByID(ctx context.Context, id string) (*domain.PracticeSession, error) {
-	doc, err := r.client.Collection("practice_sessions").Doc(id).Get(ctx)
+	doc, err := getDoc(ctx, r.client.Collection("practice_sessions").Doc(id))
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	var s domain.PracticeSession
 	if err := doc.DataTo(&s); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	s.ID = doc.Ref.ID
 	return &s, nil
 }
 
 func (r *PracticeSessionRepository) GetBySeries(ctx context.Context, seriesID string) ([]*domain.PracticeSession, error) {
-	iter := r.client.Collection("practice_sessions").
-		Where("seriesId", "==", seriesID).
-		Documents(ctx)
+	iter := queryDocs(ctx, r.client.Collection("practice_sessions").
+		Where("seriesId", "==", seriesID))
 	defer iter.Stop()
 
 	var sessions []*domain.PracticeSession
@@ -54,11 +58,11 @@ func (r *PracticeSessionRepository) GetBySeries(ctx context.Context, seriesID st
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var s domain.PracticeSession
 		if err := doc.DataTo(&s); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		s.ID = doc.Ref.ID
 		sessions = append(sessions, &s)
@@ -71,6 +75,8 @@ func (r *PracticeSessionRepository) GetBySeries(ctx context.Context, seriesID st
 }
 
 func (r *PracticeSessionRepository) Update(ctx context.Context, s *domain.PracticeSession) error {
-	_, err := r.client.Collection("practice_sessions").Doc(s.ID).Set(ctx, s)
-	return err
+	s.UpdatedAt = time.Now()
+	s.Sequence++
+	err := setDoc(ctx, r.client.Collection("practice_sessions").Doc(s.ID), s)
+	return translateError(err)
 }
@@ -0,0 +1,117 @@
+package firestore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/noa/circle-app/api/domain"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ReminderPolicyRepository struct {
+	client *firestore.Client
+}
+
+func NewReminderPolicyRepository(client *firestore.Client) *ReminderPolicyRepository {
+	return &ReminderPolicyRepository{client: client}
+}
+
+func (r *ReminderPolicyRepository) Get(ctx context.Context, circleID string) (*domain.ReminderPolicy, error) {
+	doc, err := getDoc(ctx, r.client.Collection("reminder_policies").Doc(circleID))
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	var p domain.ReminderPolicy
+	if err := doc.DataTo(&p); err != nil {
+		return nil, translateError(err)
+	}
+	p.CircleID = doc.Ref.ID
+	return &p, nil
+}
+
+func (r *ReminderPolicyRepository) Upsert(ctx context.Context, p *domain.ReminderPolicy) error {
+	p.UpdatedAt = time.Now()
+	err := setDoc(ctx, r.client.Collection("reminder_policies").Doc(p.CircleID), p)
+	return translateError(err)
+}
+
+type ReminderJobRepository struct {
+	client *firestore.Client
+}
+
+func NewReminderJobRepository(client *firestore.Client) *ReminderJobRepository {
+	return &ReminderJobRepository{client: client}
+}
+
+func (r *ReminderJobRepository) Create(ctx context.Context, j *domain.ReminderJob) error {
+	j.CreatedAt = time.Now()
+	j.UpdatedAt = j.CreatedAt
+	err := createDoc(ctx, r.client.Collection("reminder_jobs").Doc(j.ID), j)
+	return translateError(err)
+}
+
+func (r *ReminderJobRepository) GetByID(ctx context.Context, id string) (*domain.ReminderJob, error) {
+	doc, err := getDoc(ctx, r.client.Collection("reminder_jobs").Doc(id))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	var j domain.ReminderJob
+	if err := doc.DataTo(&j); err != nil {
+		return nil, translateError(err)
+	}
+	j.ID = doc.Ref.ID
+	return &j, nil
+}
+
+func (r *ReminderJobRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.ReminderJob, error) {
+	jobs, err := r.query(ctx, r.client.Collection("reminder_jobs").Where("circleId", "==", circleID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort by dueAt descending in Go instead of Firestore OrderBy (avoids needing composite index)
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].DueAt.After(jobs[j].DueAt)
+	})
+	return jobs, nil
+}
+
+func (r *ReminderJobRepository) GetPending(ctx context.Context) ([]*domain.ReminderJob, error) {
+	return r.query(ctx, r.client.Collection("reminder_jobs").Where("status", "==", string(domain.ReminderPending)))
+}
+
+func (r *ReminderJobRepository) query(ctx context.Context, q firestore.Query) ([]*domain.ReminderJob, error) {
+	iter := queryDocs(ctx, q)
+	defer iter.Stop()
+
+	var jobs []*domain.ReminderJob
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, translateError(err)
+		}
+		var j domain.ReminderJob
+		if err := doc.DataTo(&j); err != nil {
+			return nil, translateError(err)
+		}
+		j.ID = doc.Ref.ID
+		jobs = append(jobs, &j)
+	}
+	return jobs, nil
+}
+
+func (r *ReminderJobRepository) Update(ctx context.Context, j *domain.ReminderJob) error {
+	j.UpdatedAt = time.Now()
+	err := setDoc(ctx, r.client.Collection("reminder_jobs").Doc(j.ID), j)
+	return translateError(err)
+}
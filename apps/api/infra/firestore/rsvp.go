@@ -7,6 +7,8 @@ import (
 	"cloud.google.com/go/firestore"
 	"github.com/noa/circle-app/api/domain"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // RSVPRepository implements port.RSVPRepository.
@@ -19,50 +21,28 @@ func NewRSVPRepository(client *firestore.Client) *RSVPRepository {
 	return &RSVPRepository{client: client}
 }
 
-// Upsert creates or updates an RSVP.
+// Upsert creates or updates an RSVP. The document ID is derived from the
+// event and user, so concurrent submissions converge on one document.
 func (r *RSVPRepository) Upsert(ctx context.Context, rsvp *domain.RSVP) error {
-	// Check if exists
-	existing, err := r.GetByEventAndUser(ctx, rsvp.EventID, rsvp.UserID)
-	if err != nil {
-		return err
-	}
-
+	rsvp.ID = domain.RSVPID(rsvp.EventID, rsvp.UserID)
 	rsvp.UpdatedAt = time.Now()
-
-	if existing != nil {
-		rsvp.ID = existing.ID
-		_, err = r.client.Collection("rsvps").Doc(rsvp.ID).Set(ctx, rsvp)
-		return err
-	}
-
-	docRef, _, err := r.client.Collection("rsvps").Add(ctx, rsvp)
-	if err != nil {
-		return err
-	}
-	rsvp.ID = docRef.ID
-	return nil
+	err := setDoc(ctx, r.client.Collection("rsvps").Doc(rsvp.ID), rsvp)
+	return translateError(err)
 }
 
 // GetByEventAndUser returns RSVP for a specific event and user.
 func (r *RSVPRepository) GetByEventAndUser(ctx context.Context, eventID, userID string) (*domain.RSVP, error) {
-	iter := r.client.Collection("rsvps").
-		Where("eventId", "==", eventID).
-		Where("userId", "==", userID).
-		Limit(1).
-		Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err == iterator.Done {
+	doc, err := getDoc(ctx, r.client.Collection("rsvps").Doc(domain.RSVPID(eventID, userID)))
+	if status.Code(err) == codes.NotFound {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	var rsvp domain.RSVP
 	if err := doc.DataTo(&rsvp); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	rsvp.ID = doc.Ref.ID
 	return &rsvp, nil
@@ -70,9 +50,8 @@ func (r *RSVPRepository) GetByEventAndUser(ctx context.Context, eventID, userID
 
 // GetByEvent returns all RSVPs for a specific event.
 func (r *RSVPRepository) GetByEvent(ctx context.Context, eventID string) ([]*domain.RSVP, error) {
-	iter := r.client.Collection("rsvps").
-		Where("eventId", "==", eventID).
-		Documents(ctx)
+	iter := queryDocs(ctx, r.client.Collection("rsvps").
+		Where("eventId", "==", eventID))
 	defer iter.Stop()
 
 	var rsvps []*domain.RSVP
@@ -82,7 +61,7 @@ func (r *RSVPRepository) GetByEvent(ctx context.Context, eventID string) ([]*dom
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 
 		var rsvp domain.RSVP
@@ -2,11 +2,14 @@ package firestore
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/noa/circle-app/api/domain"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // SettlementRepository implements port.SettlementRepository.
@@ -22,9 +25,13 @@ func NewSettlementRepository(client *firestore.Client) *SettlementRepository {
 // Create creates a new settlement.
 func (r *SettlementRepository) Create(ctx context.Context, s *domain.Settlement) error {
 	s.CreatedAt = time.Now()
-	docRef, _, err := r.client.Collection("settlements").Add(ctx, s)
-	if err != nil {
-		return err
+	coll := r.client.Collection("settlements")
+	docRef := coll.NewDoc()
+	if s.ID != "" {
+		docRef = coll.Doc(s.ID)
+	}
+	if err := createDoc(ctx, docRef, s); err != nil {
+		return translateError(err)
 	}
 	s.ID = docRef.ID
 	return nil
@@ -32,13 +39,13 @@ func (r *SettlementRepository) Create(ctx context.Context, s *domain.Settlement)
 
 // GetByID returns a settlement by ID.
 func (r *SettlementRepository) GetByID(ctx context.Context, id string) (*domain.Settlement, error) {
-	doc, err := r.client.Collection("settlements").Doc(id).Get(ctx)
+	doc, err := getDoc(ctx, r.client.Collection("settlements").Doc(id))
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	var s domain.Settlement
 	if err := doc.DataTo(&s); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	s.ID = doc.Ref.ID
 	return &s, nil
@@ -46,9 +53,8 @@ func (r *SettlementRepository) GetByID(ctx context.Context, id string) (*domain.
 
 // GetByEvent returns all settlements for an event.
 func (r *SettlementRepository) GetByEvent(ctx context.Context, eventID string) ([]*domain.Settlement, error) {
-	iter := r.client.Collection("settlements").
-		Where("eventId", "==", eventID).
-		Documents(ctx)
+	iter := queryDocs(ctx, r.client.Collection("settlements").
+		Where("eventId", "==", eventID))
 	defer iter.Stop()
 
 	var settlements []*domain.Settlement
@@ -58,11 +64,11 @@ func (r *SettlementRepository) GetByEvent(ctx context.Context, eventID string) (
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var s domain.Settlement
 		if err := doc.DataTo(&s); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		s.ID = doc.Ref.ID
 		settlements = append(settlements, &s)
@@ -70,10 +76,45 @@ func (r *SettlementRepository) GetByEvent(ctx context.Context, eventID string) (
 	return settlements, nil
 }
 
+// GetByCircle returns all settlements for a circle, newest first.
+func (r *SettlementRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.Settlement, error) {
+	iter := queryDocs(ctx, r.client.Collection("settlements").
+		Where("circleId", "==", circleID))
+	defer iter.Stop()
+
+	var settlements []*domain.Settlement
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, translateError(err)
+		}
+		var s domain.Settlement
+		if err := doc.DataTo(&s); err != nil {
+			return nil, translateError(err)
+		}
+		s.ID = doc.Ref.ID
+		settlements = append(settlements, &s)
+	}
+	// Sort in Go instead of Firestore OrderBy (avoids needing composite index)
+	sort.Slice(settlements, func(i, j int) bool {
+		return settlements[i].CreatedAt.After(settlements[j].CreatedAt)
+	})
+	return settlements, nil
+}
+
 // Update updates a settlement.
 func (r *SettlementRepository) Update(ctx context.Context, s *domain.Settlement) error {
-	_, err := r.client.Collection("settlements").Doc(s.ID).Set(ctx, s)
-	return err
+	err := setDoc(ctx, r.client.Collection("settlements").Doc(s.ID), s)
+	return translateError(err)
+}
+
+// Delete deletes a settlement. Its payments are left to the caller.
+func (r *SettlementRepository) Delete(ctx context.Context, id string) error {
+	err := deleteDoc(ctx, r.client.Collection("settlements").Doc(id))
+	return translateError(err)
 }
 
 // PaymentRepository implements port.PaymentRepository.
@@ -86,46 +127,36 @@ func NewPaymentRepository(client *firestore.Client) *PaymentRepository {
 	return &PaymentRepository{client: client}
 }
 
-// Create creates a new payment.
+// Create creates a new payment. The document ID is derived from the settlement
+// and user, so it fails with domain.ErrConflict if the user already has one.
 func (r *PaymentRepository) Create(ctx context.Context, p *domain.Payment) error {
-	docRef, _, err := r.client.Collection("payments").Add(ctx, p)
-	if err != nil {
-		return err
-	}
-	p.ID = docRef.ID
-	return nil
+	p.ID = domain.PaymentID(p.SettlementID, p.UserID)
+	err := createDoc(ctx, r.client.Collection("payments").Doc(p.ID), p)
+	return translateError(err)
 }
 
 // GetBySettlementAndUser returns payment for a specific settlement and user.
 func (r *PaymentRepository) GetBySettlementAndUser(ctx context.Context, settlementID, userID string) (*domain.Payment, error) {
-	iter := r.client.Collection("payments").
-		Where("settlementId", "==", settlementID).
-		Where("userId", "==", userID).
-		Limit(1).
-		Documents(ctx)
-	defer iter.Stop()
-
-	doc, err := iter.Next()
-	if err == iterator.Done {
+	doc, err := getDoc(ctx, r.client.Collection("payments").Doc(domain.PaymentID(settlementID, userID)))
+	if status.Code(err) == codes.NotFound {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	var p domain.Payment
 	if err := doc.DataTo(&p); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	p.ID = doc.Ref.ID
 	return &p, nil
 }
 
-// GetByUser returns all payments for a user.
-func (r *PaymentRepository) GetByUser(ctx context.Context, userID string) ([]*domain.Payment, error) {
-	iter := r.client.Collection("payments").
-		Where("userId", "==", userID).
-		Documents(ctx)
+// GetBySettlement returns all payments for a settlement.
+func (r *PaymentRepository) GetBySettlement(ctx context.Context, settlementID string) ([]*domain.Payment, error) {
+	iter := queryDocs(ctx, r.client.Collection("payments").
+		Where("settlementId", "==", settlementID))
 	defer iter.Stop()
 
 	var payments []*domain.Payment
@@ -135,11 +166,11 @@ func (r *PaymentRepository) GetByUser(ctx context.Context, userID string) ([]*do
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		var p domain.Payment
 		if err := doc.DataTo(&p); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		p.ID = doc.Ref.ID
 		payments = append(payments, &p)
@@ -147,31 +178,39 @@ func (r *PaymentRepository) GetByUser(ctx context.Context, userID string) ([]*do
 	return payments, nil
 }
 
-// Update updates a payment.
-func (r *PaymentRepository) Update(ctx context.Context, p *domain.Payment) error {
-	_, err := r.client.Collection("payments").Doc(p.ID).Set(ctx, p)
-	return err
-}
-
-// DeleteBySettlementAndUser deletes a payment for a specific settlement and user.
-func (r *PaymentRepository) DeleteBySettlementAndUser(ctx context.Context, settlementID, userID string) error {
-	iter := r.client.Collection("payments").
-		Where("settlementId", "==", settlementID).
-		Where("userId", "==", userID).
-		Documents(ctx)
+// GetByUser returns all payments for a user.
+func (r *PaymentRepository) GetByUser(ctx context.Context, userID string) ([]*domain.Payment, error) {
+	iter := queryDocs(ctx, r.client.Collection("payments").
+		Where("userId", "==", userID))
 	defer iter.Stop()
 
+	var payments []*domain.Payment
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return err
+			return nil, translateError(err)
 		}
-		if _, err := doc.Ref.Delete(ctx); err != nil {
-			return err
+		var p domain.Payment
+		if err := doc.DataTo(&p); err != nil {
+			return nil, translateError(err)
 		}
+		p.ID = doc.Ref.ID
+		payments = append(payments, &p)
 	}
-	return nil
+	return payments, nil
+}
+
+// Update updates a payment.
+func (r *PaymentRepository) Update(ctx context.Context, p *domain.Payment) error {
+	err := setDoc(ctx, r.client.Collection("payments").Doc(p.ID), p)
+	return translateError(err)
+}
+
+// Delete deletes a payment.
+func (r *PaymentRepository) Delete(ctx context.Context, id string) error {
+	err := deleteDoc(ctx, r.client.Collection("payments").Doc(id))
+	return translateError(err)
 }
@@ -0,0 +1,83 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+)
+
+// txKey stores the running *firestore.Transaction in a context.
+type txKey struct{}
+
+// Transactor implements port.Transactor with Firestore transactions.
+//
+// Repositories called with the transaction context read and write through the
+// transaction. Firestore requires every read of a transaction to happen before
+// its first write, and may run fn more than once when it contends with other
+// writers, so fn must be free of side effects outside the repositories.
+type Transactor struct {
+	client *firestore.Client
+}
+
+// NewTransactor creates a new Transactor.
+func NewTransactor(client *firestore.Client) *Transactor {
+	return &Transactor{client: client}
+}
+
+// RunInTransaction runs fn atomically.
+func (t *Transactor) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txFrom(ctx) != nil {
+		// Nested call: join the outer transaction.
+		return fn(ctx)
+	}
+	err := t.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	return translateError(err)
+}
+
+func txFrom(ctx context.Context) *firestore.Transaction {
+	tx, _ := ctx.Value(txKey{}).(*firestore.Transaction)
+	return tx
+}
+
+// The helpers below perform a single operation through the transaction in
+// ctx when there is one, and directly otherwise.
+
+func getDoc(ctx context.Context, ref *firestore.DocumentRef) (*firestore.DocumentSnapshot, error) {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.Get(ref)
+	}
+	return ref.Get(ctx)
+}
+
+func queryDocs(ctx context.Context, q firestore.Query) *firestore.DocumentIterator {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.Documents(q)
+	}
+	return q.Documents(ctx)
+}
+
+func setDoc(ctx context.Context, ref *firestore.DocumentRef, data interface{}) error {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.Set(ref, data)
+	}
+	_, err := ref.Set(ctx, data)
+	return err
+}
+
+func createDoc(ctx context.Context, ref *firestore.DocumentRef, data interface{}) error {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.Create(ref, data)
+	}
+	_, err := ref.Create(ctx, data)
+	return err
+}
+
+func deleteDoc(ctx context.Context, ref *firestore.DocumentRef) error {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.Delete(ref)
+	}
+	_, err := ref.Delete(ctx)
+	return err
+}
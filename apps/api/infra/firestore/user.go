@@ -23,18 +23,18 @@ func (r *UserRepository) Create(ctx context.Context, u *domain.User) error {
 	u.CreatedAt = time.Now()
 	u.UpdatedAt = time.Now()
 	_, err := r.client.Collection("users").Doc(u.ID).Set(ctx, u)
-	return err
+	return translateError(err)
 }
 
 // GetByID returns a user by ID.
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	doc, err := r.client.Collection("users").Doc(id).Get(ctx)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	var u domain.User
 	if err := doc.DataTo(&u); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	u.ID = doc.Ref.ID
 	return &u, nil
@@ -43,6 +43,6 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User,
 // Update updates a user.
 func (r *UserRepository) Update(ctx context.Context, u *domain.User) error {
 	u.UpdatedAt = time.Now()
-	_, err := r.client.Collection("users").Doc(u.ID).Set(ctx, u, firestore.MergeAll)
-	return err
+	_, err := r.client.Collection("users").Doc(u.ID).Set(ctx, u)
+	return translateError(err)
 }
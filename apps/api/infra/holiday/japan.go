@@ -0,0 +1,52 @@
+// Package holiday provides national holiday calendars from bundled data files.
+package holiday
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// holidaysJP lists Japanese national holidays, including substitute holidays
+// (振替休日) and citizens' holidays (国民の休日), as "date,name" rows. It follows
+// the Cabinet Office list (https://www8.cao.go.jp/chosei/shukujitsu/gaiyou.html)
+// and has to be extended every year once the equinox dates are announced.
+//
+//go:embed holidays_jp.csv
+var holidaysJP string
+
+// JapanCalendar implements port.HolidayCalendar with the bundled Japanese holidays.
+type JapanCalendar struct {
+	byYear map[int][]domain.Holiday
+}
+
+// NewJapanCalendar parses the bundled holiday data.
+func NewJapanCalendar() (*JapanCalendar, error) {
+	rows, err := csv.NewReader(strings.NewReader(holidaysJP)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("holiday: %w", err)
+	}
+	c := &JapanCalendar{byYear: make(map[int][]domain.Holiday)}
+	for _, row := range rows[1:] { // skip header
+		t, err := domain.ParseDate(row[0])
+		if err != nil {
+			return nil, fmt.Errorf("holiday: bad date %q: %w", row[0], err)
+		}
+		c.byYear[t.Year()] = append(c.byYear[t.Year()], domain.Holiday{Date: row[0], Name: row[1]})
+	}
+	return c, nil
+}
+
+// Holidays returns the holidays of a year in date order. Years outside the
+// bundled data are rejected rather than silently returning no holidays.
+func (c *JapanCalendar) Holidays(ctx context.Context, year int) ([]domain.Holiday, error) {
+	holidays, ok := c.byYear[year]
+	if !ok {
+		return nil, fmt.Errorf("%w: no holiday data for %d", domain.ErrInvalidInput, year)
+	}
+	return holidays, nil
+}
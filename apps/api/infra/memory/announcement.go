@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// AnnouncementRepository implements port.AnnouncementRepository.
+type AnnouncementRepository struct {
+	store *Store
+}
+
+// NewAnnouncementRepository creates a new AnnouncementRepository.
+func NewAnnouncementRepository(store *Store) *AnnouncementRepository {
+	return &AnnouncementRepository{store: store}
+}
+
+// Create creates a new announcement.
+func (r *AnnouncementRepository) Create(ctx context.Context, a *domain.Announcement) error {
+	a.CreatedAt = time.Now()
+	a.ID = newID()
+
+	defer r.store.lock(ctx)()
+	r.store.announcements[a.ID] = clone(a)
+	return nil
+}
+
+// GetByID returns an announcement by ID.
+func (r *AnnouncementRepository) GetByID(ctx context.Context, id string) (*domain.Announcement, error) {
+	defer r.store.rlock(ctx)()
+	a, ok := r.store.announcements[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return clone(a), nil
+}
+
+// GetByEvent returns announcements for an event, newest first.
+func (r *AnnouncementRepository) GetByEvent(ctx context.Context, eventID string) ([]*domain.Announcement, error) {
+	defer r.store.rlock(ctx)()
+	announcements := filter(r.store.announcements, func(a *domain.Announcement) bool {
+		return a.EventID == eventID
+	}, clone[domain.Announcement])
+	sortAnnouncements(announcements)
+	return announcements, nil
+}
+
+// GetByCircle returns latest announcements for a circle.
+func (r *AnnouncementRepository) GetByCircle(ctx context.Context, circleID string, limit int) ([]*domain.Announcement, error) {
+	defer r.store.rlock(ctx)()
+	announcements := filter(r.store.announcements, func(a *domain.Announcement) bool {
+		return a.CircleID == circleID
+	}, clone[domain.Announcement])
+	sortAnnouncements(announcements)
+	if limit > 0 && len(announcements) > limit {
+		announcements = announcements[:limit]
+	}
+	return announcements, nil
+}
+
+// Update updates an announcement.
+func (r *AnnouncementRepository) Update(ctx context.Context, a *domain.Announcement) error {
+	a.UpdatedAt = time.Now()
+
+	defer r.store.lock(ctx)()
+	r.store.announcements[a.ID] = clone(a)
+	return nil
+}
+
+// Delete deletes an announcement.
+func (r *AnnouncementRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+	delete(r.store.announcements, id)
+	return nil
+}
+
+func sortAnnouncements(announcements []*domain.Announcement) {
+	sort.SliceStable(announcements, func(i, j int) bool {
+		return announcements[i].CreatedAt.After(announcements[j].CreatedAt)
+	})
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// AuditLogRepository implements port.AuditLogRepository.
+type AuditLogRepository struct {
+	store *Store
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository.
+func NewAuditLogRepository(store *Store) *AuditLogRepository {
+	return &AuditLogRepository{store: store}
+}
+
+// Create records a new audit entry.
+func (r *AuditLogRepository) Create(ctx context.Context, e *domain.AuditEntry) error {
+	e.CreatedAt = time.Now()
+	e.ID = newID()
+
+	defer r.store.lock(ctx)()
+	r.store.auditLog[e.ID] = clone(e)
+	return nil
+}
+
+// GetByCircle returns the audit entries of a circle, newest first.
+func (r *AuditLogRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.AuditEntry, error) {
+	defer r.store.rlock(ctx)()
+	entries := filter(r.store.auditLog, func(e *domain.AuditEntry) bool {
+		return e.CircleID == circleID
+	}, clone[domain.AuditEntry])
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
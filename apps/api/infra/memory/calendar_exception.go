@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// CalendarExceptionRepository implements port.CalendarExceptionRepository.
+type CalendarExceptionRepository struct {
+	store *Store
+}
+
+// NewCalendarExceptionRepository creates a new CalendarExceptionRepository.
+func NewCalendarExceptionRepository(store *Store) *CalendarExceptionRepository {
+	return &CalendarExceptionRepository{store: store}
+}
+
+// Create creates a new calendar exception.
+func (r *CalendarExceptionRepository) Create(ctx context.Context, e *domain.CalendarException) error {
+	e.CreatedAt = time.Now()
+	e.ID = newID()
+
+	defer r.store.lock(ctx)()
+	r.store.calendarExceptions[e.ID] = clone(e)
+	return nil
+}
+
+// GetByID returns a calendar exception by ID.
+func (r *CalendarExceptionRepository) GetByID(ctx context.Context, id string) (*domain.CalendarException, error) {
+	defer r.store.rlock(ctx)()
+	e, ok := r.store.calendarExceptions[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return clone(e), nil
+}
+
+// GetByCircle returns the exceptions of a circle ordered by start date.
+func (r *CalendarExceptionRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.CalendarException, error) {
+	defer r.store.rlock(ctx)()
+	exceptions := filter(r.store.calendarExceptions, func(e *domain.CalendarException) bool {
+		return e.CircleID == circleID
+	}, clone[domain.CalendarException])
+	sort.SliceStable(exceptions, func(i, j int) bool {
+		return exceptions[i].StartDate < exceptions[j].StartDate
+	})
+	return exceptions, nil
+}
+
+// Delete deletes a calendar exception.
+func (r *CalendarExceptionRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+	delete(r.store.calendarExceptions, id)
+	return nil
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// CircleRepository implements port.CircleRepository.
+type CircleRepository struct {
+	store *Store
+}
+
+// NewCircleRepository creates a new CircleRepository.
+func NewCircleRepository(store *Store) *CircleRepository {
+	return &CircleRepository{store: store}
+}
+
+// Create creates a new circle.
+func (r *CircleRepository) Create(ctx context.Context, c *domain.Circle) error {
+	c.CreatedAt = time.Now()
+	c.ID = newID()
+
+	defer r.store.lock(ctx)()
+	r.store.circles[c.ID] = clone(c)
+	return nil
+}
+
+// GetByID returns a circle by ID.
+func (r *CircleRepository) GetByID(ctx context.Context, id string) (*domain.Circle, error) {
+	defer r.store.rlock(ctx)()
+	c, ok := r.store.circles[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return clone(c), nil
+}
+
+// Update updates a circle.
+func (r *CircleRepository) Update(ctx context.Context, c *domain.Circle) error {
+	c.UpdatedAt = time.Now()
+
+	defer r.store.lock(ctx)()
+	if _, ok := r.store.circles[c.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	r.store.circles[c.ID] = clone(c)
+	return nil
+}
+
+// List returns all circles.
+func (r *CircleRepository) List(ctx context.Context) ([]*domain.Circle, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.circles, func(c *domain.Circle) bool {
+		return true
+	}, clone[domain.Circle]), nil
+}
+
+func cloneMembership(m *domain.Membership) *domain.Membership {
+	c := *m
+	c.Tags = slices.Clone(m.Tags)
+	return &c
+}
+
+// MembershipRepository implements port.MembershipRepository.
+type MembershipRepository struct {
+	store *Store
+}
+
+// NewMembershipRepository creates a new MembershipRepository.
+func NewMembershipRepository(store *Store) *MembershipRepository {
+	return &MembershipRepository{store: store}
+}
+
+// Create creates a new membership.
+func (r *MembershipRepository) Create(ctx context.Context, m *domain.Membership) error {
+	m.JoinedAt = time.Now()
+	m.ID = newID()
+
+	defer r.store.lock(ctx)()
+	r.store.memberships[m.ID] = cloneMembership(m)
+	return nil
+}
+
+// Update updates a membership.
+func (r *MembershipRepository) Update(ctx context.Context, m *domain.Membership) error {
+	defer r.store.lock(ctx)()
+	if _, ok := r.store.memberships[m.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	r.store.memberships[m.ID] = cloneMembership(m)
+	return nil
+}
+
+// GetByCircle returns all memberships for a circle.
+func (r *MembershipRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.Membership, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.memberships, func(m *domain.Membership) bool {
+		return m.CircleID == circleID
+	}, cloneMembership), nil
+}
+
+// GetByCircleAndUser returns membership for a specific user in a circle.
+// It returns nil without error when the user is not a member.
+func (r *MembershipRepository) GetByCircleAndUser(ctx context.Context, circleID, userID string) (*domain.Membership, error) {
+	defer r.store.rlock(ctx)()
+	memberships := filter(r.store.memberships, func(m *domain.Membership) bool {
+		return m.CircleID == circleID && m.UserID == userID
+	}, cloneMembership)
+	if len(memberships) == 0 {
+		return nil, nil
+	}
+	return memberships[0], nil
+}
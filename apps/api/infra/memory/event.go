@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// EventRepository implements port.EventRepository.
+type EventRepository struct {
+	store *Store
+}
+
+// NewEventRepository creates a new EventRepository.
+func NewEventRepository(store *Store) *EventRepository {
+	return &EventRepository{store: store}
+}
+
+func cloneEvent(e *domain.Event) *domain.Event {
+	c := *e
+	c.RSVPTargetUserIDs = slices.Clone(e.RSVPTargetUserIDs)
+	return &c
+}
+
+// Create creates a new event.
+func (r *EventRepository) Create(ctx context.Context, e *domain.Event) error {
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	e.ID = newID()
+
+	defer r.store.lock(ctx)()
+	r.store.events[e.ID] = cloneEvent(e)
+	return nil
+}
+
+// GetByID returns an event by ID.
+func (r *EventRepository) GetByID(ctx context.Context, id string) (*domain.Event, error) {
+	defer r.store.rlock(ctx)()
+	e, ok := r.store.events[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneEvent(e), nil
+}
+
+// GetByCircle returns all events for a circle, newest first.
+func (r *EventRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.Event, error) {
+	defer r.store.rlock(ctx)()
+	events := filter(r.store.events, func(e *domain.Event) bool {
+		return e.CircleID == circleID
+	}, cloneEvent)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+	return events, nil
+}
+
+// Update updates an event.
+func (r *EventRepository) Update(ctx context.Context, e *domain.Event) error {
+	e.UpdatedAt = time.Now()
+	e.Sequence++
+
+	defer r.store.lock(ctx)()
+	r.store.events[e.ID] = cloneEvent(e)
+	return nil
+}
+
+// Delete deletes an event.
+func (r *EventRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+	delete(r.store.events, id)
+	return nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+// Repositories holds every repository over one Store, as wired in main and
+// used by tests.
+type Repositories struct {
+	Circle            *CircleRepository
+	Membership        *MembershipRepository
+	Event             *EventRepository
+	Announcement      *AnnouncementRepository
+	RSVP              *RSVPRepository
+	Settlement        *SettlementRepository
+	Payment           *PaymentRepository
+	User              *UserRepository
+	PracticeCategory  *PracticeCategoryRepository
+	PracticeSeries    *PracticeSeriesRepository
+	PracticeSession   *PracticeSessionRepository
+	PracticeRSVP      *PracticeRSVPRepository
+	CalendarException *CalendarExceptionRepository
+	AuditLog          *AuditLogRepository
+	ReminderPolicy    *ReminderPolicyRepository
+	ReminderJob       *ReminderJobRepository
+	Transactor        *Transactor
+}
+
+// New creates the repositories over a new, empty Store.
+func New() *Repositories {
+	store := NewStore()
+	return &Repositories{
+		Circle:            NewCircleRepository(store),
+		Membership:        NewMembershipRepository(store),
+		Event:             NewEventRepository(store),
+		Announcement:      NewAnnouncementRepository(store),
+		RSVP:              NewRSVPRepository(store),
+		Settlement:        NewSettlementRepository(store),
+		Payment:           NewPaymentRepository(store),
+		User:              NewUserRepository(store),
+		PracticeCategory:  NewPracticeCategoryRepository(store),
+		PracticeSeries:    NewPracticeSeriesRepository(store),
+		PracticeSession:   NewPracticeSessionRepository(store),
+		PracticeRSVP:      NewPracticeRSVPRepository(store),
+		CalendarException: NewCalendarExceptionRepository(store),
+		AuditLog:          NewAuditLogRepository(store),
+		ReminderPolicy:    NewReminderPolicyRepository(store),
+		ReminderJob:       NewReminderJobRepository(store),
+		Transactor:        NewTransactor(store),
+	}
+}
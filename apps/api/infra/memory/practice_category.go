@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// PracticeCategoryRepository implements port.PracticeCategoryRepository.
+type PracticeCategoryRepository struct {
+	store *Store
+}
+
+// NewPracticeCategoryRepository creates a new PracticeCategoryRepository.
+func NewPracticeCategoryRepository(store *Store) *PracticeCategoryRepository {
+	return &PracticeCategoryRepository{store: store}
+}
+
+// Create creates a new practice category.
+func (r *PracticeCategoryRepository) Create(ctx context.Context, c *domain.PracticeCategory) error {
+	c.CreatedAt = time.Now()
+	c.ID = newID()
+
+	defer r.store.lock(ctx)()
+	r.store.practiceCategories[c.ID] = clone(c)
+	return nil
+}
+
+// GetByID returns a practice category by ID.
+func (r *PracticeCategoryRepository) GetByID(ctx context.Context, id string) (*domain.PracticeCategory, error) {
+	defer r.store.rlock(ctx)()
+	c, ok := r.store.practiceCategories[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return clone(c), nil
+}
+
+// GetByCircle returns all categories for a circle ordered by Order.
+func (r *PracticeCategoryRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.PracticeCategory, error) {
+	defer r.store.rlock(ctx)()
+	categories := filter(r.store.practiceCategories, func(c *domain.PracticeCategory) bool {
+		return c.CircleID == circleID
+	}, clone[domain.PracticeCategory])
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].Order < categories[j].Order
+	})
+	return categories, nil
+}
+
+// Update updates a practice category.
+func (r *PracticeCategoryRepository) Update(ctx context.Context, c *domain.PracticeCategory) error {
+	defer r.store.lock(ctx)()
+	r.store.practiceCategories[c.ID] = clone(c)
+	return nil
+}
+
+// Delete deletes a practice category.
+func (r *PracticeCategoryRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+	delete(r.store.practiceCategories, id)
+	return nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// PracticeRSVPRepository implements port.PracticeRSVPRepository.
+type PracticeRSVPRepository struct {
+	store *Store
+}
+
+// NewPracticeRSVPRepository creates a new PracticeRSVPRepository.
+func NewPracticeRSVPRepository(store *Store) *PracticeRSVPRepository {
+	return &PracticeRSVPRepository{store: store}
+}
+
+// Upsert creates or updates a practice RSVP.
+func (r *PracticeRSVPRepository) Upsert(ctx context.Context, rsvp *domain.PracticeRSVP) error {
+	rsvp.ID = domain.PracticeRSVPID(rsvp.SessionID, rsvp.UserID)
+	rsvp.UpdatedAt = time.Now()
+
+	defer r.store.lock(ctx)()
+	r.store.practiceRSVPs[rsvp.ID] = clone(rsvp)
+	return nil
+}
+
+// GetBySessionAndUser returns a user's RSVP for a session.
+// It returns nil without error when the user has not responded.
+func (r *PracticeRSVPRepository) GetBySessionAndUser(ctx context.Context, sessionID, userID string) (*domain.PracticeRSVP, error) {
+	defer r.store.rlock(ctx)()
+	rsvp, ok := r.store.practiceRSVPs[domain.PracticeRSVPID(sessionID, userID)]
+	if !ok {
+		return nil, nil
+	}
+	return clone(rsvp), nil
+}
+
+// GetBySession returns all RSVPs for a session.
+func (r *PracticeRSVPRepository) GetBySession(ctx context.Context, sessionID string) ([]*domain.PracticeRSVP, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.practiceRSVPs, func(x *domain.PracticeRSVP) bool {
+		return x.SessionID == sessionID
+	}, clone[domain.PracticeRSVP]), nil
+}
+
+// GetBySeriesAndUser returns a user's RSVPs for every session of a series.
+func (r *PracticeRSVPRepository) GetBySeriesAndUser(ctx context.Context, seriesID, userID string) ([]*domain.PracticeRSVP, error) {
+	defer r.store.rlock(ctx)()
+	sessions := filter(r.store.practiceSessions, func(s *domain.PracticeSession) bool {
+		return s.SeriesID == seriesID
+	}, clone[domain.PracticeSession])
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	var rsvps []*domain.PracticeRSVP
+	for _, s := range sessions {
+		matched := filter(r.store.practiceRSVPs, func(x *domain.PracticeRSVP) bool {
+			return x.SessionID == s.ID && x.UserID == userID
+		}, clone[domain.PracticeRSVP])
+		if len(matched) > 0 {
+			rsvps = append(rsvps, matched[0])
+		}
+	}
+	return rsvps, nil
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// PracticeSeriesRepository implements port.PracticeSeriesRepository.
+type PracticeSeriesRepository struct {
+	store *Store
+}
+
+// NewPracticeSeriesRepository creates a new PracticeSeriesRepository.
+func NewPracticeSeriesRepository(store *Store) *PracticeSeriesRepository {
+	return &PracticeSeriesRepository{store: store}
+}
+
+// clonePracticeSeries copies a series, including its ExDates.
+func clonePracticeSeries(s *domain.PracticeSeries) *domain.PracticeSeries {
+	c := *s
+	c.ExDates = slices.Clone(s.ExDates)
+	return &c
+}
+
+// Create creates a new practice series.
+func (r *PracticeSeriesRepository) Create(ctx context.Context, s *domain.PracticeSeries) error {
+	s.CreatedAt = time.Now()
+	s.ID = newID()
+
+	defer r.store.lock(ctx)()
+	r.store.practiceSeries[s.ID] = clonePracticeSeries(s)
+	return nil
+}
+
+// GetByID returns a practice series by ID.
+func (r *PracticeSeriesRepository) GetByID(ctx context.Context, id string) (*domain.PracticeSeries, error) {
+	defer r.store.rlock(ctx)()
+	s, ok := r.store.practiceSeries[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return clonePracticeSeries(s), nil
+}
+
+// GetByCircle returns practice series by circle.
+func (r *PracticeSeriesRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.PracticeSeries, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.practiceSeries, func(s *domain.PracticeSeries) bool {
+		return s.CircleID == circleID
+	}, clonePracticeSeries), nil
+}
+
+// List returns every practice series.
+func (r *PracticeSeriesRepository) List(ctx context.Context) ([]*domain.PracticeSeries, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.practiceSeries, func(s *domain.PracticeSeries) bool {
+		return true
+	}, clonePracticeSeries), nil
+}
+
+// GetByCategory returns practice series by category.
+func (r *PracticeSeriesRepository) GetByCategory(ctx context.Context, categoryID string) ([]*domain.PracticeSeries, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.practiceSeries, func(s *domain.PracticeSeries) bool {
+		return s.CategoryID == categoryID
+	}, clonePracticeSeries), nil
+}
+
+// Update updates a practice series.
+func (r *PracticeSeriesRepository) Update(ctx context.Context, s *domain.PracticeSeries) error {
+	s.UpdatedAt = time.Now()
+	s.Sequence++
+
+	defer r.store.lock(ctx)()
+	r.store.practiceSeries[s.ID] = clonePracticeSeries(s)
+	return nil
+}
+
+// Delete deletes a practice series.
+func (r *PracticeSeriesRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+	delete(r.store.practiceSeries, id)
+	return nil
+}
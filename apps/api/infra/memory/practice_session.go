@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// PracticeSessionRepository implements port.PracticeSessionRepository.
+type PracticeSessionRepository struct {
+	store *Store
+}
+
+// NewPracticeSessionRepository creates a new PracticeSessionRepository.
+func NewPracticeSessionRepository(store *Store) *PracticeSessionRepository {
+	return &PracticeSessionRepository{store: store}
+}
+
+// Create creates a new practice session.
+func (r *PracticeSessionRepository) Create(ctx context.Context, s *domain.PracticeSession) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	if s.ID == "" {
+		s.ID = newID()
+	}
+
+	defer r.store.lock(ctx)()
+	if _, ok := r.store.practiceSessions[s.ID]; ok {
+		return domain.ErrConflict
+	}
+	r.store.practiceSessions[s.ID] = clone(s)
+	return nil
+}
+
+// GetByID returns a practice session by ID.
+func (r *PracticeSessionRepository) GetByID(ctx context.Context, id string) (*domain.PracticeSession, error) {
+	defer r.store.rlock(ctx)()
+	s, ok := r.store.practiceSessions[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return clone(s), nil
+}
+
+// GetBySeries returns sessions of a series ordered by date.
+func (r *PracticeSessionRepository) GetBySeries(ctx context.Context, seriesID string) ([]*domain.PracticeSession, error) {
+	defer r.store.rlock(ctx)()
+	sessions := filter(r.store.practiceSessions, func(s *domain.PracticeSession) bool {
+		return s.SeriesID == seriesID
+	}, clone[domain.PracticeSession])
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Date.Before(sessions[j].Date)
+	})
+	return sessions, nil
+}
+
+// Update updates a practice session.
+func (r *PracticeSessionRepository) Update(ctx context.Context, s *domain.PracticeSession) error {
+	s.UpdatedAt = time.Now()
+	s.Sequence++
+
+	defer r.store.lock(ctx)()
+	r.store.practiceSessions[s.ID] = clone(s)
+	return nil
+}
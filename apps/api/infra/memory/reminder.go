@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// ReminderPolicyRepository implements port.ReminderPolicyRepository.
+type ReminderPolicyRepository struct {
+	store *Store
+}
+
+// NewReminderPolicyRepository creates a new ReminderPolicyRepository.
+func NewReminderPolicyRepository(store *Store) *ReminderPolicyRepository {
+	return &ReminderPolicyRepository{store: store}
+}
+
+func cloneReminderPolicy(p *domain.ReminderPolicy) *domain.ReminderPolicy {
+	c := *p
+	c.RSVPDaysBefore = slices.Clone(p.RSVPDaysBefore)
+	c.PaymentDaysBefore = slices.Clone(p.PaymentDaysBefore)
+	c.PaymentDaysAfter = slices.Clone(p.PaymentDaysAfter)
+	return &c
+}
+
+// Get returns the reminder policy of a circle.
+// It returns nil without error when the circle has no policy.
+func (r *ReminderPolicyRepository) Get(ctx context.Context, circleID string) (*domain.ReminderPolicy, error) {
+	defer r.store.rlock(ctx)()
+	p, ok := r.store.reminderPolicies[circleID]
+	if !ok {
+		return nil, nil
+	}
+	return cloneReminderPolicy(p), nil
+}
+
+// Upsert creates or replaces the reminder policy of a circle.
+func (r *ReminderPolicyRepository) Upsert(ctx context.Context, p *domain.ReminderPolicy) error {
+	p.UpdatedAt = time.Now()
+
+	defer r.store.lock(ctx)()
+	r.store.reminderPolicies[p.CircleID] = cloneReminderPolicy(p)
+	return nil
+}
+
+// ReminderJobRepository implements port.ReminderJobRepository.
+type ReminderJobRepository struct {
+	store *Store
+}
+
+// NewReminderJobRepository creates a new ReminderJobRepository.
+func NewReminderJobRepository(store *Store) *ReminderJobRepository {
+	return &ReminderJobRepository{store: store}
+}
+
+func cloneReminderJob(j *domain.ReminderJob) *domain.ReminderJob {
+	c := *j
+	c.Recipients = slices.Clone(j.Recipients)
+	return &c
+}
+
+// Create creates a reminder job with the ID it already has.
+func (r *ReminderJobRepository) Create(ctx context.Context, j *domain.ReminderJob) error {
+	j.CreatedAt = time.Now()
+	j.UpdatedAt = j.CreatedAt
+
+	defer r.store.lock(ctx)()
+	if _, ok := r.store.reminderJobs[j.ID]; ok {
+		return domain.ErrConflict
+	}
+	r.store.reminderJobs[j.ID] = cloneReminderJob(j)
+	return nil
+}
+
+// GetByID returns a reminder job by ID.
+func (r *ReminderJobRepository) GetByID(ctx context.Context, id string) (*domain.ReminderJob, error) {
+	defer r.store.rlock(ctx)()
+	j, ok := r.store.reminderJobs[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneReminderJob(j), nil
+}
+
+// GetByCircle returns the reminder jobs of a circle, latest due first.
+func (r *ReminderJobRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.ReminderJob, error) {
+	defer r.store.rlock(ctx)()
+	jobs := filter(r.store.reminderJobs, func(j *domain.ReminderJob) bool {
+		return j.CircleID == circleID
+	}, cloneReminderJob)
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].DueAt.After(jobs[j].DueAt)
+	})
+	return jobs, nil
+}
+
+// GetPending returns every reminder job that has not been sent or skipped.
+func (r *ReminderJobRepository) GetPending(ctx context.Context) ([]*domain.ReminderJob, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.reminderJobs, func(j *domain.ReminderJob) bool {
+		return j.Status == domain.ReminderPending
+	}, cloneReminderJob), nil
+}
+
+// Update updates a reminder job.
+func (r *ReminderJobRepository) Update(ctx context.Context, j *domain.ReminderJob) error {
+	j.UpdatedAt = time.Now()
+
+	defer r.store.lock(ctx)()
+	r.store.reminderJobs[j.ID] = cloneReminderJob(j)
+	return nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// RSVPRepository implements port.RSVPRepository.
+type RSVPRepository struct {
+	store *Store
+}
+
+// NewRSVPRepository creates a new RSVPRepository.
+func NewRSVPRepository(store *Store) *RSVPRepository {
+	return &RSVPRepository{store: store}
+}
+
+// Upsert creates or updates an RSVP.
+func (r *RSVPRepository) Upsert(ctx context.Context, rsvp *domain.RSVP) error {
+	rsvp.ID = domain.RSVPID(rsvp.EventID, rsvp.UserID)
+	rsvp.UpdatedAt = time.Now()
+
+	defer r.store.lock(ctx)()
+	r.store.rsvps[rsvp.ID] = clone(rsvp)
+	return nil
+}
+
+// GetByEventAndUser returns RSVP for a specific event and user.
+// It returns nil without error when the user has not responded.
+func (r *RSVPRepository) GetByEventAndUser(ctx context.Context, eventID, userID string) (*domain.RSVP, error) {
+	defer r.store.rlock(ctx)()
+	rsvp, ok := r.store.rsvps[domain.RSVPID(eventID, userID)]
+	if !ok {
+		return nil, nil
+	}
+	return clone(rsvp), nil
+}
+
+// GetByEvent returns all RSVPs for a specific event.
+func (r *RSVPRepository) GetByEvent(ctx context.Context, eventID string) ([]*domain.RSVP, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.rsvps, func(x *domain.RSVP) bool {
+		return x.EventID == eventID
+	}, clone[domain.RSVP]), nil
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// SettlementRepository implements port.SettlementRepository.
+type SettlementRepository struct {
+	store *Store
+}
+
+// NewSettlementRepository creates a new SettlementRepository.
+func NewSettlementRepository(store *Store) *SettlementRepository {
+	return &SettlementRepository{store: store}
+}
+
+func cloneSettlement(s *domain.Settlement) *domain.Settlement {
+	c := *s
+	c.TargetUserIDs = slices.Clone(s.TargetUserIDs)
+	c.SessionIDs = slices.Clone(s.SessionIDs)
+	c.LineItems = slices.Clone(s.LineItems)
+	if s.Split != nil {
+		split := *s.Split
+		c.Split = &split
+	}
+	for i := range c.LineItems {
+		c.LineItems[i].Overrides = maps.Clone(c.LineItems[i].Overrides)
+	}
+	return &c
+}
+
+// Create creates a new settlement.
+func (r *SettlementRepository) Create(ctx context.Context, s *domain.Settlement) error {
+	s.CreatedAt = time.Now()
+	if s.ID == "" {
+		s.ID = newID()
+	}
+
+	defer r.store.lock(ctx)()
+	if _, ok := r.store.settlements[s.ID]; ok {
+		return domain.ErrConflict
+	}
+	r.store.settlements[s.ID] = cloneSettlement(s)
+	return nil
+}
+
+// GetByID returns a settlement by ID.
+func (r *SettlementRepository) GetByID(ctx context.Context, id string) (*domain.Settlement, error) {
+	defer r.store.rlock(ctx)()
+	s, ok := r.store.settlements[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneSettlement(s), nil
+}
+
+// GetByEvent returns all settlements for an event.
+func (r *SettlementRepository) GetByEvent(ctx context.Context, eventID string) ([]*domain.Settlement, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.settlements, func(s *domain.Settlement) bool {
+		return s.EventID == eventID
+	}, cloneSettlement), nil
+}
+
+// GetByCircle returns all settlements for a circle, newest first.
+func (r *SettlementRepository) GetByCircle(ctx context.Context, circleID string) ([]*domain.Settlement, error) {
+	defer r.store.rlock(ctx)()
+	settlements := filter(r.store.settlements, func(s *domain.Settlement) bool {
+		return s.CircleID == circleID
+	}, cloneSettlement)
+	sort.SliceStable(settlements, func(i, j int) bool {
+		return settlements[i].CreatedAt.After(settlements[j].CreatedAt)
+	})
+	return settlements, nil
+}
+
+// Update updates a settlement.
+func (r *SettlementRepository) Update(ctx context.Context, s *domain.Settlement) error {
+	defer r.store.lock(ctx)()
+	r.store.settlements[s.ID] = cloneSettlement(s)
+	return nil
+}
+
+// Delete deletes a settlement. Its payments are left to the caller.
+func (r *SettlementRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+	delete(r.store.settlements, id)
+	return nil
+}
+
+// PaymentRepository implements port.PaymentRepository.
+type PaymentRepository struct {
+	store *Store
+}
+
+// NewPaymentRepository creates a new PaymentRepository.
+func NewPaymentRepository(store *Store) *PaymentRepository {
+	return &PaymentRepository{store: store}
+}
+
+// Create creates a new payment. It fails with domain.ErrConflict if the user
+// already has a payment for the settlement.
+func (r *PaymentRepository) Create(ctx context.Context, p *domain.Payment) error {
+	p.ID = domain.PaymentID(p.SettlementID, p.UserID)
+
+	defer r.store.lock(ctx)()
+	if _, ok := r.store.payments[p.ID]; ok {
+		return domain.ErrConflict
+	}
+	r.store.payments[p.ID] = clone(p)
+	return nil
+}
+
+// GetBySettlementAndUser returns payment for a specific settlement and user.
+// It returns nil without error when no payment record exists.
+func (r *PaymentRepository) GetBySettlementAndUser(ctx context.Context, settlementID, userID string) (*domain.Payment, error) {
+	defer r.store.rlock(ctx)()
+	p, ok := r.store.payments[domain.PaymentID(settlementID, userID)]
+	if !ok {
+		return nil, nil
+	}
+	return clone(p), nil
+}
+
+// GetBySettlement returns all payments for a settlement.
+func (r *PaymentRepository) GetBySettlement(ctx context.Context, settlementID string) ([]*domain.Payment, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.payments, func(p *domain.Payment) bool {
+		return p.SettlementID == settlementID
+	}, clone[domain.Payment]), nil
+}
+
+// GetByUser returns all payments for a user.
+func (r *PaymentRepository) GetByUser(ctx context.Context, userID string) ([]*domain.Payment, error) {
+	defer r.store.rlock(ctx)()
+	return filter(r.store.payments, func(p *domain.Payment) bool {
+		return p.UserID == userID
+	}, clone[domain.Payment]), nil
+}
+
+// Update updates a payment.
+func (r *PaymentRepository) Update(ctx context.Context, p *domain.Payment) error {
+	defer r.store.lock(ctx)()
+	r.store.payments[p.ID] = clone(p)
+	return nil
+}
+
+// Delete deletes a payment.
+func (r *PaymentRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+	delete(r.store.payments, id)
+	return nil
+}
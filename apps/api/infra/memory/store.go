@@ -0,0 +1,94 @@
+// Package memory provides in-memory implementations of repositories.
+// It mirrors the behaviour of the Firestore implementations and is meant for
+// tests and local runs without a GCP project.
+package memory
+
+import (
+	"crypto/rand"
+	"sort"
+	"sync"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// Store holds all in-memory collections shared by the repositories.
+type Store struct {
+	mu sync.RWMutex
+
+	users              map[string]*domain.User
+	circles            map[string]*domain.Circle
+	memberships        map[string]*domain.Membership
+	events             map[string]*domain.Event
+	announcements      map[string]*domain.Announcement
+	rsvps              map[string]*domain.RSVP
+	settlements        map[string]*domain.Settlement
+	payments           map[string]*domain.Payment
+	practiceCategories map[string]*domain.PracticeCategory
+	practiceSeries     map[string]*domain.PracticeSeries
+	practiceSessions   map[string]*domain.PracticeSession
+	practiceRSVPs      map[string]*domain.PracticeRSVP
+	calendarExceptions map[string]*domain.CalendarException
+	auditLog           map[string]*domain.AuditEntry
+	reminderPolicies   map[string]*domain.ReminderPolicy
+	reminderJobs       map[string]*domain.ReminderJob
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{
+		users:              make(map[string]*domain.User),
+		circles:            make(map[string]*domain.Circle),
+		memberships:        make(map[string]*domain.Membership),
+		events:             make(map[string]*domain.Event),
+		announcements:      make(map[string]*domain.Announcement),
+		rsvps:              make(map[string]*domain.RSVP),
+		settlements:        make(map[string]*domain.Settlement),
+		payments:           make(map[string]*domain.Payment),
+		practiceCategories: make(map[string]*domain.PracticeCategory),
+		practiceSeries:     make(map[string]*domain.PracticeSeries),
+		practiceSessions:   make(map[string]*domain.PracticeSession),
+		practiceRSVPs:      make(map[string]*domain.PracticeRSVP),
+		calendarExceptions: make(map[string]*domain.CalendarException),
+		auditLog:           make(map[string]*domain.AuditEntry),
+		reminderPolicies:   make(map[string]*domain.ReminderPolicy),
+		reminderJobs:       make(map[string]*domain.ReminderJob),
+	}
+}
+
+const idAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// newID returns a random 20-character ID in the same format as Firestore auto IDs.
+func newID() string {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = idAlphabet[int(b[i])%len(idAlphabet)]
+	}
+	return string(b)
+}
+
+// clone returns a shallow copy of v.
+func clone[T any](v *T) *T {
+	c := *v
+	return &c
+}
+
+// filter returns copies of the documents matching keep, ordered by document ID
+// like an unordered Firestore query.
+func filter[T any](docs map[string]*T, keep func(*T) bool, copyFn func(*T) *T) []*T {
+	ids := make([]string, 0, len(docs))
+	for id := range docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var result []*T
+	for _, id := range ids {
+		if keep(docs[id]) {
+			result = append(result, copyFn(docs[id]))
+		}
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/infra/memory"
+)
+
+func TestGetByIDNotFound(t *testing.T) {
+	ctx := context.Background()
+	m := memory.New()
+
+	lookups := map[string]func() error{
+		"user":               func() error { _, err := m.User.GetByID(ctx, "missing"); return err },
+		"circle":             func() error { _, err := m.Circle.GetByID(ctx, "missing"); return err },
+		"event":              func() error { _, err := m.Event.GetByID(ctx, "missing"); return err },
+		"announcement":       func() error { _, err := m.Announcement.GetByID(ctx, "missing"); return err },
+		"settlement":         func() error { _, err := m.Settlement.GetByID(ctx, "missing"); return err },
+		"practice category":  func() error { _, err := m.PracticeCategory.GetByID(ctx, "missing"); return err },
+		"practice series":    func() error { _, err := m.PracticeSeries.GetByID(ctx, "missing"); return err },
+		"practice session":   func() error { _, err := m.PracticeSession.GetByID(ctx, "missing"); return err },
+		"calendar exception": func() error { _, err := m.CalendarException.GetByID(ctx, "missing"); return err },
+		"reminder job":       func() error { _, err := m.ReminderJob.GetByID(ctx, "missing"); return err },
+		"circle update":      func() error { return m.Circle.Update(ctx, &domain.Circle{ID: "missing"}) },
+		"membership update":  func() error { return m.Membership.Update(ctx, &domain.Membership{ID: "missing"}) },
+	}
+	for name, lookup := range lookups {
+		if err := lookup(); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", name, err)
+		}
+	}
+}
+
+func TestMissingOptionalDocumentsAreNil(t *testing.T) {
+	ctx := context.Background()
+	m := memory.New()
+
+	if got, err := m.Membership.GetByCircleAndUser(ctx, "c", "u"); got != nil || err != nil {
+		t.Errorf("membership: got %v, %v; want nil, nil", got, err)
+	}
+	if got, err := m.RSVP.GetByEventAndUser(ctx, "e", "u"); got != nil || err != nil {
+		t.Errorf("rsvp: got %v, %v; want nil, nil", got, err)
+	}
+	if got, err := m.Payment.GetBySettlementAndUser(ctx, "s", "u"); got != nil || err != nil {
+		t.Errorf("payment: got %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestReturnedDocumentsAreCopies(t *testing.T) {
+	ctx := context.Background()
+	m := memory.New()
+
+	e := &domain.Event{CircleID: "c", Title: "合宿", RSVPTargetUserIDs: []string{"u1"}}
+	if err := m.Event.Create(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	e.Title = "changed"
+	got, err := m.Event.GetByID(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.RSVPTargetUserIDs[0] = "changed"
+
+	again, err := m.Event.GetByID(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Title != "合宿" || again.RSVPTargetUserIDs[0] != "u1" {
+		t.Errorf("stored event changed through a returned copy: %+v", again)
+	}
+}
+
+func TestPaymentCreateConflict(t *testing.T) {
+	ctx := context.Background()
+	m := memory.New()
+
+	if err := m.Payment.Create(ctx, &domain.Payment{SettlementID: "s", UserID: "u"}); err != nil {
+		t.Fatal(err)
+	}
+	err := m.Payment.Create(ctx, &domain.Payment{SettlementID: "s", UserID: "u"})
+	if !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("got %v, want ErrConflict", err)
+	}
+}
+
+func TestRunInTransactionCommits(t *testing.T) {
+	ctx := context.Background()
+	m := memory.New()
+
+	c := &domain.Circle{Name: "c"}
+	err := m.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		return m.Circle.Create(ctx, c)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Circle.GetByID(ctx, c.ID); err != nil {
+		t.Errorf("circle not saved: %v", err)
+	}
+}
+
+func TestRunInTransactionRollsBack(t *testing.T) {
+	ctx := context.Background()
+	m := memory.New()
+
+	existing := &domain.Circle{Name: "before"}
+	if err := m.Circle.Create(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("failure")
+	created := &domain.Event{CircleID: existing.ID, Title: "e"}
+	err := m.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		if err := m.Event.Create(ctx, created); err != nil {
+			return err
+		}
+		c, err := m.Circle.GetByID(ctx, existing.ID)
+		if err != nil {
+			return err
+		}
+		c.Name = "after"
+		if err := m.Circle.Update(ctx, c); err != nil {
+			return err
+		}
+		// A nested transaction joins the outer one and rolls back with it.
+		return m.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+			if err := m.RSVP.Upsert(ctx, &domain.RSVP{EventID: created.ID, UserID: "u", Status: domain.RSVPGo}); err != nil {
+				return err
+			}
+			return failure
+		})
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got %v, want the function's error", err)
+	}
+
+	if _, err := m.Event.GetByID(ctx, created.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("created event: got %v, want ErrNotFound", err)
+	}
+	c, err := m.Circle.GetByID(ctx, existing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "before" {
+		t.Errorf("circle name = %q, want the update rolled back", c.Name)
+	}
+	if r, _ := m.RSVP.GetByEventAndUser(ctx, created.ID, "u"); r != nil {
+		t.Errorf("RSVP of the nested transaction was kept: %+v", r)
+	}
+}
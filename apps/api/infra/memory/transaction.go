@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"maps"
+)
+
+// txKey marks a context as running inside a transaction on a Store.
+type txKey struct{}
+
+// Transactor implements port.Transactor for the in-memory store.
+//
+// A transaction holds the store's write lock for its whole duration, so
+// transactions and plain repository calls are fully serialized. Repositories
+// called with the transaction context skip their own locking. If the function
+// fails, every collection is restored to its state before the transaction.
+type Transactor struct {
+	store *Store
+}
+
+// NewTransactor creates a new Transactor.
+func NewTransactor(store *Store) *Transactor {
+	return &Transactor{store: store}
+}
+
+// RunInTransaction runs fn atomically. fn must not use ctx from other goroutines.
+func (t *Transactor) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.store.inTx(ctx) {
+		// Nested call: join the outer transaction.
+		return fn(ctx)
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	snapshot := t.store.snapshot()
+	if err := fn(context.WithValue(ctx, txKey{}, t.store)); err != nil {
+		t.store.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) inTx(ctx context.Context) bool {
+	tx, _ := ctx.Value(txKey{}).(*Store)
+	return tx == s
+}
+
+// lock acquires the write lock unless ctx already holds it through a
+// transaction, and returns the matching unlock function.
+func (s *Store) lock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// rlock is the read-lock counterpart of lock.
+func (s *Store) rlock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+// snapshot returns a copy of the store's collections. Documents are stored as
+// private copies and replaced rather than mutated, so shallow map copies suffice.
+func (s *Store) snapshot() *Store {
+	return &Store{
+		users:              maps.Clone(s.users),
+		circles:            maps.Clone(s.circles),
+		memberships:        maps.Clone(s.memberships),
+		events:             maps.Clone(s.events),
+		announcements:      maps.Clone(s.announcements),
+		rsvps:              maps.Clone(s.rsvps),
+		settlements:        maps.Clone(s.settlements),
+		payments:           maps.Clone(s.payments),
+		practiceCategories: maps.Clone(s.practiceCategories),
+		practiceSeries:     maps.Clone(s.practiceSeries),
+		practiceSessions:   maps.Clone(s.practiceSessions),
+		practiceRSVPs:      maps.Clone(s.practiceRSVPs),
+		calendarExceptions: maps.Clone(s.calendarExceptions),
+		auditLog:           maps.Clone(s.auditLog),
+		reminderPolicies:   maps.Clone(s.reminderPolicies),
+		reminderJobs:       maps.Clone(s.reminderJobs),
+	}
+}
+
+// restore replaces the store's collections with those of a snapshot.
+func (s *Store) restore(snap *Store) {
+	s.users = snap.users
+	s.circles = snap.circles
+	s.memberships = snap.memberships
+	s.events = snap.events
+	s.announcements = snap.announcements
+	s.rsvps = snap.rsvps
+	s.settlements = snap.settlements
+	s.payments = snap.payments
+	s.practiceCategories = snap.practiceCategories
+	s.practiceSeries = snap.practiceSeries
+	s.practiceSessions = snap.practiceSessions
+	s.practiceRSVPs = snap.practiceRSVPs
+	s.calendarExceptions = snap.calendarExceptions
+	s.auditLog = snap.auditLog
+	s.reminderPolicies = snap.reminderPolicies
+	s.reminderJobs = snap.reminderJobs
+}
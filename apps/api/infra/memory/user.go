@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// UserRepository implements port.UserRepository.
+type UserRepository struct {
+	store *Store
+}
+
+// NewUserRepository creates a new UserRepository.
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+func cloneUser(u *domain.User) *domain.User {
+	c := *u
+	if u.Notifications != nil {
+		n := *u.Notifications
+		n.Channels = slices.Clone(n.Channels)
+		n.PushSubscriptions = slices.Clone(n.PushSubscriptions)
+		c.Notifications = &n
+	}
+	return &c
+}
+
+// Create creates a new user, overwriting any user with the same ID.
+func (r *UserRepository) Create(ctx context.Context, u *domain.User) error {
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = time.Now()
+
+	defer r.store.lock(ctx)()
+	r.store.users[u.ID] = cloneUser(u)
+	return nil
+}
+
+// GetByID returns a user by ID.
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	defer r.store.rlock(ctx)()
+	u, ok := r.store.users[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneUser(u), nil
+}
+
+// Update updates a user.
+func (r *UserRepository) Update(ctx context.Context, u *domain.User) error {
+	u.UpdatedAt = time.Now()
+
+	defer r.store.lock(ctx)()
+	r.store.users[u.ID] = cloneUser(u)
+	return nil
+}
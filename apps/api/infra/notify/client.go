@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// errInternalAddress is returned when a user-chosen URL leads to an address
+// inside the server's network.
+var errInternalAddress = errors.New("refusing to connect to an internal address")
+
+// newPublicClient returns an HTTP client for URLs chosen by users, such as
+// webhooks and push endpoints. It only connects to public addresses, checked
+// after DNS resolution, so a host name resolving to a loopback, private,
+// link-local or cloud metadata address is refused like an IP literal is.
+func newPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: refuseInternal}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on the server's behalf, past the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// refuseInternal is a net.Dialer Control function that fails connections to
+// addresses that are not public.
+func refuseInternal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddr(ip) {
+		return fmt.Errorf("%w: %s", errInternalAddress, ip)
+	}
+	return nil
+}
+
+// isPublicAddr reports whether ip is a unicast address outside loopback,
+// private and link-local ranges.
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsUnspecified() && !ip.IsMulticast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast()
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// Config selects the delivery channels. A channel is enabled when its
+// settings are present.
+type Config struct {
+	Outbox bool // record deliveries on every channel instead of sending them
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	LINEChannelAccessToken string
+
+	WebhookSecret string // signs webhook requests; webhooks are off without it
+
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+}
+
+// ConfigFromEnv reads the Config from NOTIFY_OUTBOX, SMTP_HOST, SMTP_PORT,
+// SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM, LINE_CHANNEL_ACCESS_TOKEN,
+// NOTIFY_WEBHOOK_SECRET, VAPID_PRIVATE_KEY and VAPID_SUBJECT.
+func ConfigFromEnv() Config {
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	return Config{
+		Outbox:                 os.Getenv("NOTIFY_OUTBOX") == "true",
+		SMTPHost:               os.Getenv("SMTP_HOST"),
+		SMTPPort:               port,
+		SMTPUsername:           os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:           os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:               os.Getenv("SMTP_FROM"),
+		LINEChannelAccessToken: os.Getenv("LINE_CHANNEL_ACCESS_TOKEN"),
+		WebhookSecret:          os.Getenv("NOTIFY_WEBHOOK_SECRET"),
+		VAPIDPrivateKey:        os.Getenv("VAPID_PRIVATE_KEY"),
+		VAPIDSubject:           os.Getenv("VAPID_SUBJECT"),
+	}
+}
+
+// New creates a Dispatcher for the channels enabled in cfg. With
+// cfg.Outbox, every channel records into an Outbox, available from the
+// Dispatcher's Outbox method, and nothing is sent.
+func New(cfg Config, users port.UserRepository) (*Dispatcher, error) {
+	if cfg.Outbox {
+		log.Println("Warning: NOTIFY_OUTBOX=true, notifications are recorded but not sent")
+		outbox := NewOutbox()
+		d := NewDispatcher(users,
+			outbox.Channel(domain.ChannelEmail),
+			outbox.Channel(domain.ChannelLINE),
+			outbox.Channel(domain.ChannelWebhook),
+			outbox.Channel(domain.ChannelWebPush),
+		)
+		d.outbox = outbox
+		return d, nil
+	}
+
+	var channels []Channel
+	if cfg.SMTPHost != "" {
+		if cfg.SMTPFrom == "" {
+			return nil, errors.New("SMTP_FROM is required with SMTP_HOST")
+		}
+		channels = append(channels, NewEmailChannel(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom))
+	}
+	if cfg.LINEChannelAccessToken != "" {
+		channels = append(channels, NewLINEChannel(cfg.LINEChannelAccessToken))
+	}
+	if cfg.WebhookSecret != "" {
+		channels = append(channels, NewWebhookChannel([]byte(cfg.WebhookSecret)))
+	}
+	if cfg.VAPIDPrivateKey != "" {
+		if cfg.VAPIDSubject == "" {
+			return nil, errors.New("VAPID_SUBJECT is required with VAPID_PRIVATE_KEY")
+		}
+		webPush, err := NewWebPushChannel(cfg.VAPIDPrivateKey, cfg.VAPIDSubject, users)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, webPush)
+	}
+	return NewDispatcher(users, channels...), nil
+}
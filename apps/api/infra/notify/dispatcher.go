@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// Channel delivers a notification to one user on one channel.
+type Channel interface {
+	Name() domain.NotificationChannel
+	Send(ctx context.Context, u *domain.User, msg *domain.Notification) error
+}
+
+// maxConcurrentUsers bounds how many users are notified at the same time.
+const maxConcurrentUsers = 8
+
+// Dispatcher implements port.Notifier by fanning a notification out to each
+// recipient on the channels they have enabled. Every notification is also
+// logged, so nothing is lost while no channel is configured.
+type Dispatcher struct {
+	users    port.UserRepository
+	channels []Channel
+	log      *LogNotifier
+	outbox   *Outbox // set when the channels record into it
+}
+
+// NewDispatcher creates a Dispatcher delivering on the given channels.
+func NewDispatcher(users port.UserRepository, channels ...Channel) *Dispatcher {
+	return &Dispatcher{users: users, channels: channels, log: NewLogNotifier()}
+}
+
+// Channels returns the channels users can choose from.
+func (d *Dispatcher) Channels() []domain.NotificationChannel {
+	names := make([]domain.NotificationChannel, 0, len(d.channels))
+	for _, c := range d.channels {
+		names = append(names, c.Name())
+	}
+	return names
+}
+
+// Outbox returns the Outbox the channels record into, or nil if they send
+// notifications for real.
+func (d *Dispatcher) Outbox() *Outbox {
+	return d.outbox
+}
+
+// VAPIDPublicKey returns the key browsers subscribe to Web Push with, or ""
+// if Web Push is not configured.
+func (d *Dispatcher) VAPIDPublicKey() string {
+	for _, c := range d.channels {
+		if wp, ok := c.(*WebPushChannel); ok {
+			return wp.PublicKey()
+		}
+	}
+	return ""
+}
+
+// Notify delivers msg to every recipient on their enabled channels. Failed
+// deliveries are logged; an error is returned only when deliveries were
+// attempted and none succeeded, so that callers retrying on error do not
+// notify the same users twice.
+func (d *Dispatcher) Notify(ctx context.Context, msg *domain.Notification) error {
+	d.log.Notify(ctx, msg)
+	if len(d.channels) == 0 {
+		return nil
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		delivered int
+		errs      []error
+	)
+	sem := make(chan struct{}, maxConcurrentUsers)
+	for _, userID := range msg.UserIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(userID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ok, err := d.deliver(ctx, userID, msg)
+			mu.Lock()
+			defer mu.Unlock()
+			delivered += ok
+			errs = append(errs, err...)
+		}(userID)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		log.Printf("Warning: notification %q not delivered: %v", msg.Title, err)
+	}
+	if delivered == 0 && len(errs) > 0 {
+		return fmt.Errorf("no deliveries succeeded: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// deliver sends msg to one user and returns the number of channels it was
+// delivered on.
+func (d *Dispatcher) deliver(ctx context.Context, userID string, msg *domain.Notification) (int, []error) {
+	u, err := d.users.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return 0, nil // never signed up; nothing to deliver to
+	}
+	if err != nil {
+		return 0, []error{fmt.Errorf("user %s: %w", userID, err)}
+	}
+
+	delivered := 0
+	var errs []error
+	for _, c := range d.channels {
+		if !u.Reachable(c.Name()) {
+			continue
+		}
+		if err := c.Send(ctx, u, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s to user %s: %w", c.Name(), userID, err))
+			continue
+		}
+		delivered++
+	}
+	return delivered, errs
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/infra/memory"
+)
+
+// failingChannel fails every delivery.
+type failingChannel struct {
+	name domain.NotificationChannel
+}
+
+func (c failingChannel) Name() domain.NotificationChannel { return c.name }
+
+func (c failingChannel) Send(context.Context, *domain.User, *domain.Notification) error {
+	return errors.New("unavailable")
+}
+
+// newOutboxDispatcher creates a Dispatcher in outbox mode over users.
+func newOutboxDispatcher(t *testing.T, users ...*domain.User) (*Dispatcher, *Outbox) {
+	t.Helper()
+	repo := memory.New().User
+	for _, u := range users {
+		if err := repo.Create(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := New(Config{Outbox: true}, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Outbox() == nil {
+		t.Fatal("outbox mode without an Outbox")
+	}
+	return d, d.Outbox()
+}
+
+func user(id string, channels ...domain.NotificationChannel) *domain.User {
+	return &domain.User{
+		ID:            id,
+		Email:         id + "@example.com",
+		EmailVerified: true,
+		Notifications: &domain.NotificationPreferences{
+			Channels:          channels,
+			LINEUserID:        "U" + id,
+			WebhookURL:        "https://hooks.example.com/" + id,
+			PushSubscriptions: []domain.PushSubscription{{Endpoint: "https://push.example.com/" + id}},
+		},
+	}
+}
+
+// delivered returns the channels each user received a delivery on.
+func delivered(o *Outbox) map[string][]domain.NotificationChannel {
+	got := make(map[string][]domain.NotificationChannel)
+	for _, d := range o.Deliveries() {
+		got[d.UserID] = append(got[d.UserID], d.Channel)
+	}
+	return got
+}
+
+func TestNotifyUsesEachUsersEnabledChannels(t *testing.T) {
+	d, outbox := newOutboxDispatcher(t,
+		user("u1", domain.ChannelEmail, domain.ChannelWebhook),
+		user("u2", domain.ChannelLINE),
+		user("u3"),                                      // nothing enabled
+		&domain.User{ID: "u4", Email: "u4@example.com"}, // no preferences
+	)
+	unverified := user("u5", domain.ChannelEmail)
+	unverified.EmailVerified = false
+	if err := d.users.Create(context.Background(), unverified); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &domain.Notification{UserIDs: []string{"u1", "u2", "u3", "u4", "u5", "never-signed-up"}, CircleID: "c1", Title: "お知らせ"}
+	if err := d.Notify(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	got := delivered(outbox)
+	want := map[string][]domain.NotificationChannel{
+		"u1": {domain.ChannelEmail, domain.ChannelWebhook},
+		"u2": {domain.ChannelLINE},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("delivered to %v, want %v", got, want)
+	}
+	for userID, channels := range want {
+		if len(got[userID]) != len(channels) {
+			t.Errorf("%s got %v, want %v", userID, got[userID], channels)
+			continue
+		}
+		for idx := range channels {
+			if got[userID][idx] != channels[idx] {
+				t.Errorf("%s got %v, want %v", userID, got[userID], channels)
+			}
+		}
+	}
+}
+
+func TestNotifyDeliversOnOtherChannelsWhenOneFails(t *testing.T) {
+	outbox := NewOutbox()
+	repo := memory.New().User
+	ctx := context.Background()
+	for _, u := range []*domain.User{
+		user("u1", domain.ChannelEmail, domain.ChannelLINE),
+		user("u2", domain.ChannelEmail),
+	} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := NewDispatcher(repo, failingChannel{domain.ChannelEmail}, outbox.Channel(domain.ChannelLINE))
+
+	// u1 still gets LINE; u2 has no other channel, which is only logged.
+	msg := &domain.Notification{UserIDs: []string{"u1", "u2"}, Title: "お知らせ"}
+	if err := d.Notify(ctx, msg); err != nil {
+		t.Fatalf("Notify failed although u1 was reached: %v", err)
+	}
+	got := delivered(outbox)
+	if len(got) != 1 || len(got["u1"]) != 1 || got["u1"][0] != domain.ChannelLINE {
+		t.Errorf("delivered %v, want LINE to u1", got)
+	}
+
+	// When nothing gets through, callers hear of it and may retry.
+	msg = &domain.Notification{UserIDs: []string{"u2"}, Title: "お知らせ"}
+	if err := d.Notify(ctx, msg); err == nil {
+		t.Error("Notify succeeded although every delivery failed")
+	}
+}
+
+func TestNotifyWithoutChannelsOnlyLogs(t *testing.T) {
+	d, err := New(Config{}, memory.New().User)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Outbox() != nil || len(d.Channels()) != 0 {
+		t.Fatalf("channels %v, outbox %v without configuration", d.Channels(), d.Outbox())
+	}
+	if err := d.Notify(context.Background(), &domain.Notification{UserIDs: []string{"u1"}, Title: "お知らせ"}); err != nil {
+		t.Fatal(err)
+	}
+}
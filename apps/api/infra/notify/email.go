@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// EmailChannel sends notifications as plain text email over SMTP.
+type EmailChannel struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewEmailChannel creates an EmailChannel for the SMTP server at host:port.
+// Without a username, mail is sent unauthenticated (e.g. a local relay).
+// STARTTLS is used whenever the server offers it.
+func NewEmailChannel(host, port, username, password, from string) *EmailChannel {
+	c := &EmailChannel{addr: net.JoinHostPort(host, port), from: from}
+	if username != "" {
+		c.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return c
+}
+
+// Name returns domain.ChannelEmail.
+func (c *EmailChannel) Name() domain.NotificationChannel {
+	return domain.ChannelEmail
+}
+
+// Send emails msg to the verified email address of u.
+func (c *EmailChannel) Send(ctx context.Context, u *domain.User, msg *domain.Notification) error {
+	to := u.VerifiedEmail()
+	if to == "" {
+		return errors.New("no verified email address")
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", c.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(body) > 76 {
+		b.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	b.WriteString(body + "\r\n")
+
+	return smtp.SendMail(c.addr, c.auth, c.from, []string{to}, b.Bytes())
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// linePushURL is the LINE Messaging API endpoint for push messages.
+const linePushURL = "https://api.line.me/v2/bot/message/push"
+
+// maxLINETextRunes is the LINE limit on the length of a text message.
+const maxLINETextRunes = 5000
+
+// LINEChannel sends notifications as LINE push messages from the circle's
+// official account. Users must have added the account as a friend; their
+// LINE user ID is the one the Messaging API reports in webhook events.
+type LINEChannel struct {
+	token  string
+	url    string
+	client *http.Client
+}
+
+// NewLINEChannel creates a LINEChannel using a channel access token.
+func NewLINEChannel(token string) *LINEChannel {
+	return &LINEChannel{
+		token:  token,
+		url:    linePushURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns domain.ChannelLINE.
+func (c *LINEChannel) Name() domain.NotificationChannel {
+	return domain.ChannelLINE
+}
+
+// Send pushes msg to the user's LINE account.
+func (c *LINEChannel) Send(ctx context.Context, u *domain.User, msg *domain.Notification) error {
+	text := []rune(msg.Title + "\n" + msg.Body)
+	if len(text) > maxLINETextRunes {
+		text = text[:maxLINETextRunes]
+	}
+	payload, err := json.Marshal(map[string]any{
+		"to": u.Notifications.LINEUserID,
+		"messages": []map[string]string{
+			{"type": "text", "text": string(text)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("LINE API returned %s: %s", resp.Status, body)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package notify provides port.Notifier implementations.
+package notify
+
+import (
+	"context"
+	"log"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// LogNotifier implements port.Notifier by writing notifications to the log.
+// The Dispatcher logs every notification with it before delivering.
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new LogNotifier.
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the notification.
+func (n *LogNotifier) Notify(ctx context.Context, msg *domain.Notification) error {
+	log.Printf("Notify %d user(s) in circle %s: %s: %s", len(msg.UserIDs), msg.CircleID, msg.Title, msg.Body)
+	return nil
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// Delivery is a notification recorded by an Outbox instead of being sent.
+type Delivery struct {
+	Channel  domain.NotificationChannel
+	UserID   string
+	CircleID string
+	Title    string
+	Body     string
+	SentAt   time.Time
+}
+
+// Outbox records deliveries in memory instead of sending them, so delivery
+// can be exercised locally and in tests without network access.
+type Outbox struct {
+	mu         sync.Mutex
+	deliveries []Delivery
+}
+
+// NewOutbox creates an empty Outbox.
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+// Channel returns a Channel named name that records into the outbox.
+func (o *Outbox) Channel(name domain.NotificationChannel) Channel {
+	return &outboxChannel{name: name, outbox: o}
+}
+
+// Deliveries returns the recorded deliveries, oldest first.
+func (o *Outbox) Deliveries() []Delivery {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.deliveries)
+}
+
+// Reset discards the recorded deliveries.
+func (o *Outbox) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.deliveries = nil
+}
+
+type outboxChannel struct {
+	name   domain.NotificationChannel
+	outbox *Outbox
+}
+
+func (c *outboxChannel) Name() domain.NotificationChannel {
+	return c.name
+}
+
+func (c *outboxChannel) Send(ctx context.Context, u *domain.User, msg *domain.Notification) error {
+	log.Printf("Outbox %s to user %s: %s", c.name, u.ID, msg.Title)
+	c.outbox.mu.Lock()
+	defer c.outbox.mu.Unlock()
+	c.outbox.deliveries = append(c.outbox.deliveries, Delivery{
+		Channel:  c.name,
+		UserID:   u.ID,
+		CircleID: msg.CircleID,
+		Title:    msg.Title,
+		Body:     msg.Body,
+		SentAt:   time.Now(),
+	})
+	return nil
+}
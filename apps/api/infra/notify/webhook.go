@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// WebhookSignatureHeader carries the HMAC-SHA256 of "<timestamp>.<body>"
+// as "t=<unix seconds>,v1=<hex>", so receivers can check that a request came
+// from this API and is recent.
+const WebhookSignatureHeader = "X-Circle-Signature"
+
+// WebhookChannel POSTs notifications as JSON to a URL of the user's choice,
+// such as a Slack or Discord workflow or their own automation.
+type WebhookChannel struct {
+	secret []byte
+	client *http.Client
+}
+
+// webhookPayload is the JSON body of a webhook request.
+type webhookPayload struct {
+	UserID   string    `json:"userId"`
+	CircleID string    `json:"circleId"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	SentAt   time.Time `json:"sentAt"`
+}
+
+// NewWebhookChannel creates a WebhookChannel signing requests with secret.
+func NewWebhookChannel(secret []byte) *WebhookChannel {
+	client := newPublicClient(10 * time.Second)
+	// A redirect could point the request somewhere the user was not
+	// allowed to choose, so it is treated as a failure.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &WebhookChannel{secret: secret, client: client}
+}
+
+// Name returns domain.ChannelWebhook.
+func (c *WebhookChannel) Name() domain.NotificationChannel {
+	return domain.ChannelWebhook
+}
+
+// Send posts msg to the user's webhook URL.
+func (c *WebhookChannel) Send(ctx context.Context, u *domain.User, msg *domain.Notification) error {
+	now := time.Now()
+	body, err := json.Marshal(webhookPayload{
+		UserID:   u.ID,
+		CircleID: msg.CircleID,
+		Title:    msg.Title,
+		Body:     msg.Body,
+		SentAt:   now,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.Notifications.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, "t="+ts+",v1="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// webPushTTL is how long push services keep a message for an offline browser.
+const webPushTTL = 24 * time.Hour
+
+// maxWebPushBodyRunes keeps payloads well within the 4096-byte record that
+// push services accept; the app shows the full text when opened.
+const maxWebPushBodyRunes = 1000
+
+// WebPushChannel sends notifications with the Web Push protocol (RFC 8030),
+// encrypting payloads for each browser (RFC 8291) and identifying the server
+// with VAPID (RFC 8292). Subscriptions the push service reports as gone are
+// removed from the user.
+type WebPushChannel struct {
+	key       *ecdsa.PrivateKey
+	publicKey []byte // uncompressed P-256 point
+	subject   string
+	users     port.UserRepository
+	client    *http.Client
+}
+
+// NewWebPushChannel creates a WebPushChannel from a base64url-encoded VAPID
+// private key, as generated by common Web Push libraries. subject is a
+// mailto: or https: URL push services can use to contact the operator.
+func NewWebPushChannel(privateKey, subject string, users port.UserRepository) (*WebPushChannel, error) {
+	d, err := base64.RawURLEncoding.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	pub := ecdhKey.PublicKey().Bytes()
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+	return &WebPushChannel{
+		key:       key,
+		publicKey: pub,
+		subject:   subject,
+		users:     users,
+		client:    newPublicClient(10 * time.Second),
+	}, nil
+}
+
+// PublicKey returns the base64url-encoded VAPID public key browsers need to
+// subscribe (the applicationServerKey).
+func (c *WebPushChannel) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(c.publicKey)
+}
+
+// Name returns domain.ChannelWebPush.
+func (c *WebPushChannel) Name() domain.NotificationChannel {
+	return domain.ChannelWebPush
+}
+
+// Send pushes msg to every browser the user has subscribed. It succeeds if
+// at least one browser accepted it.
+func (c *WebPushChannel) Send(ctx context.Context, u *domain.User, msg *domain.Notification) error {
+	body := []rune(msg.Body)
+	if len(body) > maxWebPushBodyRunes {
+		body = append(body[:maxWebPushBodyRunes-1], '…')
+	}
+	payload, err := json.Marshal(map[string]string{
+		"title":    msg.Title,
+		"body":     string(body),
+		"circleId": msg.CircleID,
+	})
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	var errs, gone []string
+	for _, sub := range u.Notifications.PushSubscriptions {
+		status, err := c.push(ctx, sub, payload)
+		switch {
+		case err != nil:
+			errs = append(errs, err.Error())
+		case status == http.StatusNotFound || status == http.StatusGone:
+			gone = append(gone, sub.Endpoint)
+		case status < 200 || status >= 300:
+			errs = append(errs, fmt.Sprintf("push service returned %d", status))
+		default:
+			sent++
+		}
+	}
+	if len(gone) > 0 {
+		c.removeSubscriptions(ctx, u.ID, gone)
+	}
+	if sent == 0 && len(errs) > 0 {
+		return errors.New(errs[0])
+	}
+	return nil
+}
+
+// push sends one encrypted message and returns the push service's status.
+func (c *WebPushChannel) push(ctx context.Context, sub domain.PushSubscription, payload []byte) (int, error) {
+	body, err := encryptPayload(sub, payload)
+	if err != nil {
+		return 0, err
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil {
+		return 0, err
+	}
+	token, err := c.vapidToken(endpoint.Scheme + "://" + endpoint.Host)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(int(webPushTTL.Seconds())))
+	req.Header.Set("Authorization", "vapid t="+token+", k="+c.PublicKey())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// vapidToken returns an ES256 JWT identifying this server to a push service.
+func (c *WebPushChannel) vapidToken(audience string) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": audience,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": c.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+// encryptPayload encrypts payload for a subscription with the aes128gcm
+// content coding (RFC 8188) as a single record, keyed as in RFC 8291.
+func encryptPayload(sub domain.PushSubscription, payload []byte) ([]byte, error) {
+	uaPublic, err := base64.RawURLEncoding.DecodeString(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asKey.PublicKey().Bytes()
+	ecdhSecret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := hkdf(authSecret, ecdhSecret, keyInfo, 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 marks the last (and only) record; no padding follows it.
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, 4096)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// hkdf derives length (at most 32) bytes with HKDF-SHA256 (RFC 5869).
+func hkdf(salt, secret, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{0x01})
+	return expand.Sum(nil)[:length]
+}
+
+// removeSubscriptions drops subscriptions the push service no longer knows.
+func (c *WebPushChannel) removeSubscriptions(ctx context.Context, userID string, endpoints []string) {
+	u, err := c.users.GetByID(ctx, userID)
+	if err != nil || u.Notifications == nil {
+		return
+	}
+	kept := u.Notifications.PushSubscriptions[:0]
+	for _, sub := range u.Notifications.PushSubscriptions {
+		if !slices.Contains(endpoints, sub.Endpoint) {
+			kept = append(kept, sub)
+		}
+	}
+	u.Notifications.PushSubscriptions = kept
+	if err := c.users.Update(ctx, u); err != nil {
+		log.Printf("Warning: could not remove expired push subscriptions of user %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,43 @@
+// Package scheduler runs periodic work inside the API process.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Task is a unit of periodic work.
+type Task func(ctx context.Context) error
+
+// Scheduler runs a task at a fixed interval. Runs never overlap: if a run
+// takes longer than the interval, the next one starts when it finishes.
+// Tasks keep their own state in persistent storage, so a restart or a second
+// instance running the same task does not repeat work.
+type Scheduler struct {
+	name     string
+	interval time.Duration
+	task     Task
+}
+
+// New creates a Scheduler that runs task every interval.
+func New(name string, interval time.Duration, task Task) *Scheduler {
+	return &Scheduler{name: name, interval: interval, task: task}
+}
+
+// Run runs the task immediately and then every interval until ctx is done.
+// Errors are logged and do not stop the schedule.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.task(ctx); err != nil {
+			log.Printf("Warning: scheduled task %s failed: %v", s.name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
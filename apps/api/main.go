@@ -5,27 +5,34 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/rs/cors"
 
+	"github.com/noa/circle-app/api/adapter/http/dto"
 	"github.com/noa/circle-app/api/adapter/http/handler"
+	"github.com/noa/circle-app/api/adapter/http/middleware"
 	"github.com/noa/circle-app/api/adapter/http/router"
+	"github.com/noa/circle-app/api/infra/auth"
+	"github.com/noa/circle-app/api/infra/clock"
 	firestoreRepo "github.com/noa/circle-app/api/infra/firestore"
 	"github.com/noa/circle-app/api/infra/gemini"
+	"github.com/noa/circle-app/api/infra/holiday"
+	"github.com/noa/circle-app/api/infra/memory"
+	"github.com/noa/circle-app/api/infra/notify"
+	"github.com/noa/circle-app/api/infra/scheduler"
 	"github.com/noa/circle-app/api/usecase"
+	"github.com/noa/circle-app/api/usecase/port"
 )
 
 func main() {
 	ctx := context.Background()
 
 	// Environment variables
-	projectID := os.Getenv("GCP_PROJECT_ID")
-	if projectID == "" {
-		projectID = os.Getenv("GCP_PROJECT")
-	}
-	if projectID == "" {
-		log.Fatal("GCP_PROJECT_ID or GCP_PROJECT environment variable is required")
+	storageBackend := os.Getenv("STORAGE_BACKEND")
+	if storageBackend == "" {
+		storageBackend = "firestore"
 	}
 
 	geminiAPIKey := os.Getenv("GEMINI_API_KEY")
@@ -38,39 +45,67 @@ func main() {
 		port = "8080"
 	}
 
-	// Initialize Firestore client
-	firestoreClient, err := firestore.NewClient(ctx, projectID)
-	if err != nil {
-		log.Fatalf("Failed to create Firestore client: %v", err)
+	// A bad validation tag would otherwise only fail when a request uses it
+	if err := dto.CheckRequests(); err != nil {
+		log.Fatalf("Invalid request DTO: %v", err)
 	}
-	defer firestoreClient.Close()
 
 	// Initialize repositories (infra layer)
-	circleRepo := firestoreRepo.NewCircleRepository(firestoreClient)
-	membershipRepo := firestoreRepo.NewMembershipRepository(firestoreClient)
-	eventRepo := firestoreRepo.NewEventRepository(firestoreClient)
-	announcementRepo := firestoreRepo.NewAnnouncementRepository(firestoreClient)
-	rsvpRepo := firestoreRepo.NewRSVPRepository(firestoreClient)
-	settlementRepo := firestoreRepo.NewSettlementRepository(firestoreClient)
-	paymentRepo := firestoreRepo.NewPaymentRepository(firestoreClient)
-	userRepo := firestoreRepo.NewUserRepository(firestoreClient)
-	practiceCategoryRepo := firestoreRepo.NewPracticeCategoryRepository(firestoreClient)
-	practiceSeriesRepo := firestoreRepo.NewPracticeSeriesRepository(firestoreClient)
-	practiceSessionRepo := firestoreRepo.NewPracticeSessionRepository(firestoreClient)
-	practiceRSVPRepo := firestoreRepo.NewPracticeRSVPRepository(firestoreClient)
+	var repos *repositories
+	switch storageBackend {
+	case "memory":
+		log.Println("Warning: using in-memory storage, data will be lost on restart")
+		repos = newMemoryRepositories()
+	case "firestore":
+		projectID := gcpProjectID()
+		if projectID == "" {
+			log.Fatal("GCP_PROJECT_ID or GCP_PROJECT environment variable is required")
+		}
+
+		firestoreClient, err := firestore.NewClient(ctx, projectID)
+		if err != nil {
+			log.Fatalf("Failed to create Firestore client: %v", err)
+		}
+		defer firestoreClient.Close()
+		repos = newFirestoreRepositories(firestoreClient)
+	default:
+		log.Fatalf("Unknown STORAGE_BACKEND %q: must be firestore or memory", storageBackend)
+	}
 
 	// Initialize AI service (infra layer)
 	aiService := gemini.NewAIService(geminiAPIKey)
 
+	holidays, err := holiday.NewJapanCalendar()
+	if err != nil {
+		log.Fatalf("Failed to load holiday data: %v", err)
+	}
+	notifier, err := notify.New(notify.ConfigFromEnv(), repos.user)
+	if err != nil {
+		log.Fatalf("Failed to configure notifications: %v", err)
+	}
+	if len(notifier.Channels()) == 0 {
+		log.Println("Warning: no notification channel configured, notifications are only logged")
+	}
+
+	calendarFeedSecret := os.Getenv("CALENDAR_FEED_SECRET")
+	if calendarFeedSecret == "" {
+		log.Println("Warning: CALENDAR_FEED_SECRET not set, calendar feeds are disabled")
+	}
+
 	// Initialize interactors (usecase layer)
-	circleInteractor := usecase.NewCircleInteractor(circleRepo, membershipRepo, userRepo)
-	eventInteractor := usecase.NewEventInteractor(eventRepo)
-	announcementInteractor := usecase.NewAnnouncementInteractor(announcementRepo)
-	rsvpInteractor := usecase.NewRSVPInteractor(rsvpRepo, eventRepo, settlementRepo, paymentRepo)
-	settlementInteractor := usecase.NewSettlementInteractor(settlementRepo, paymentRepo)
-	chatInteractor := usecase.NewChatInteractor(announcementRepo, eventRepo, aiService)
-	userInteractor := usecase.NewUserInteractor(userRepo)
-	practiceUseCase := usecase.NewPracticeUseCase(practiceCategoryRepo, practiceSeriesRepo, practiceSessionRepo, practiceRSVPRepo, settlementRepo)
+	authorizer := usecase.NewAuthorizer(repos.membership)
+	circleInteractor := usecase.NewCircleInteractor(repos.circle, repos.membership, repos.user, authorizer)
+	eventInteractor := usecase.NewEventInteractor(repos.event, repos.membership, repos.user, repos.rsvp, repos.settlement, repos.payment, repos.transactor, notifier, authorizer)
+	announcementInteractor := usecase.NewAnnouncementInteractor(repos.announcement, repos.event, repos.membership, notifier, authorizer)
+	rsvpInteractor := usecase.NewRSVPInteractor(repos.rsvp, repos.event, repos.membership, repos.user, repos.settlement, repos.payment, repos.auditLog, repos.transactor, notifier, authorizer)
+	settlementInteractor := usecase.NewSettlementInteractor(repos.settlement, repos.payment, repos.event, repos.user, repos.membership, repos.rsvp, repos.transactor, notifier, authorizer)
+	chatInteractor := usecase.NewChatInteractor(repos.announcement, repos.event, aiService, authorizer)
+	userInteractor := usecase.NewUserInteractor(repos.user, notifier.Channels(), notifier.VAPIDPublicKey())
+	practiceUseCase := usecase.NewPracticeUseCase(repos.practiceCategory, repos.practiceSeries, repos.practiceSession, repos.practiceRSVP, repos.settlement, repos.payment, repos.circle, repos.calendarException, repos.auditLog, repos.transactor, holidays, notifier, authorizer)
+
+	calendarInteractor := usecase.NewCalendarInteractor(repos.circle, repos.membership, repos.event, repos.rsvp, repos.practiceSeries, repos.practiceSession, repos.practiceRSVP, authorizer, []byte(calendarFeedSecret))
+	auditInteractor := usecase.NewAuditInteractor(repos.auditLog, authorizer)
+	reminderInteractor := usecase.NewReminderInteractor(repos.reminderPolicy, repos.reminderJob, repos.circle, repos.event, repos.rsvp, repos.membership, repos.settlement, repos.payment, repos.transactor, notifier, clock.System{}, authorizer)
 
 	// Initialize handlers (adapter layer)
 	circleHandler := handler.NewCircleHandler(circleInteractor)
@@ -81,6 +116,9 @@ func main() {
 	chatHandler := handler.NewChatHandler(chatInteractor)
 	userHandler := handler.NewUserHandler(userInteractor)
 	practiceHandler := handler.NewPracticeHandler(practiceUseCase)
+	calendarHandler := handler.NewCalendarHandler(calendarInteractor)
+	auditHandler := handler.NewAuditHandler(auditInteractor)
+	reminderHandler := handler.NewReminderHandler(reminderInteractor)
 
 	// Setup router
 	mux := router.Setup(
@@ -92,8 +130,25 @@ func main() {
 		chatHandler,
 		userHandler,
 		practiceHandler,
+		calendarHandler,
+		auditHandler,
+		reminderHandler,
+		newAuthenticator(storageBackend),
 	)
 
+	// Reminders are sent by an in-process scheduler
+	if interval := reminderInterval(); interval > 0 {
+		go scheduler.New("reminders", interval, func(ctx context.Context) error {
+			run, err := reminderInteractor.RunReminders(ctx)
+			if run != nil && run.Planned+run.Sent+run.Skipped+run.Failed > 0 {
+				log.Printf("Reminders: planned %d, sent %d, skipped %d, failed %d", run.Planned, run.Sent, run.Skipped, run.Failed)
+			}
+			return err
+		}).Run(ctx)
+	} else {
+		log.Println("Warning: REMINDER_INTERVAL is 0, reminders are disabled")
+	}
+
 	// Setup CORS
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
@@ -108,3 +163,150 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// gcpProjectID returns the GCP project ID from the environment.
+func gcpProjectID() string {
+	if projectID := os.Getenv("GCP_PROJECT_ID"); projectID != "" {
+		return projectID
+	}
+	return os.Getenv("GCP_PROJECT")
+}
+
+// reminderInterval returns how often reminders are checked, from
+// REMINDER_INTERVAL (e.g. "15m", the default). 0 disables reminders, e.g.
+// when cmd/send-reminders runs them from an external scheduler instead.
+func reminderInterval() time.Duration {
+	v := os.Getenv("REMINDER_INTERVAL")
+	if v == "" {
+		return 15 * time.Minute
+	}
+	interval, err := time.ParseDuration(v)
+	if err != nil || interval < 0 {
+		log.Fatalf("Invalid REMINDER_INTERVAL %q: must be a duration such as 15m", v)
+	}
+	return interval
+}
+
+// newAuthenticator builds the authentication middleware selected by AUTH_MODE.
+//
+//   - firebase: Firebase ID tokens for AUTH_FIREBASE_PROJECT_ID or the GCP project
+//   - jwt: tokens signed by keys from AUTH_JWKS_URL, AUTH_JWKS_FILE or AUTH_HMAC_SECRET,
+//     checked against AUTH_ISSUER and AUTH_AUDIENCE when set
+//   - header: trusts X-User-Id (development only)
+//
+// The default is firebase so that a deployment that forgets AUTH_MODE does not
+// let anyone act as any user. header lets anyone do that, so it must be chosen
+// explicitly and only runs with the in-memory storage backend.
+func newAuthenticator(storageBackend string) func(http.Handler) http.Handler {
+	mode := os.Getenv("AUTH_MODE")
+	if mode == "" {
+		mode = "firebase"
+	}
+
+	switch mode {
+	case "firebase":
+		projectID := os.Getenv("AUTH_FIREBASE_PROJECT_ID")
+		if projectID == "" {
+			projectID = gcpProjectID()
+		}
+		if projectID == "" {
+			log.Fatal("AUTH_FIREBASE_PROJECT_ID or GCP_PROJECT_ID is required for AUTH_MODE=firebase")
+		}
+		return middleware.Authenticate(auth.NewFirebaseVerifier(projectID))
+	case "jwt":
+		var keys auth.KeySet
+		switch {
+		case os.Getenv("AUTH_JWKS_URL") != "":
+			keys = auth.NewRemoteKeySet(os.Getenv("AUTH_JWKS_URL"))
+		case os.Getenv("AUTH_JWKS_FILE") != "":
+			fileKeys, err := auth.NewJWKSFileKeySet(os.Getenv("AUTH_JWKS_FILE"))
+			if err != nil {
+				log.Fatalf("Failed to load AUTH_JWKS_FILE: %v", err)
+			}
+			keys = fileKeys
+		case os.Getenv("AUTH_HMAC_SECRET") != "":
+			keys = auth.NewHMACKeySet([]byte(os.Getenv("AUTH_HMAC_SECRET")))
+		default:
+			log.Fatal("AUTH_JWKS_URL, AUTH_JWKS_FILE or AUTH_HMAC_SECRET is required for AUTH_MODE=jwt")
+		}
+		verifier := auth.NewVerifier(keys, os.Getenv("AUTH_ISSUER"), os.Getenv("AUTH_AUDIENCE"))
+		return middleware.Authenticate(verifier)
+	case "header":
+		if storageBackend != "memory" {
+			log.Fatal("AUTH_MODE=header trusts the X-User-Id header and is only allowed with STORAGE_BACKEND=memory")
+		}
+		log.Println("Warning: AUTH_MODE=header trusts the X-User-Id header; never use it in production")
+		return middleware.InsecureHeaderAuthenticate()
+	default:
+		log.Fatalf("Unknown AUTH_MODE %q: must be firebase, jwt or header", mode)
+		return nil
+	}
+}
+
+// repositories groups the repository implementations used by the interactors.
+type repositories struct {
+	circle            port.CircleRepository
+	membership        port.MembershipRepository
+	event             port.EventRepository
+	announcement      port.AnnouncementRepository
+	rsvp              port.RSVPRepository
+	settlement        port.SettlementRepository
+	payment           port.PaymentRepository
+	user              port.UserRepository
+	practiceCategory  port.PracticeCategoryRepository
+	practiceSeries    port.PracticeSeriesRepository
+	practiceSession   port.PracticeSessionRepository
+	practiceRSVP      port.PracticeRSVPRepository
+	calendarException port.CalendarExceptionRepository
+	auditLog          port.AuditLogRepository
+	reminderPolicy    port.ReminderPolicyRepository
+	reminderJob       port.ReminderJobRepository
+	transactor        port.Transactor
+}
+
+// newFirestoreRepositories creates repositories backed by Firestore.
+func newFirestoreRepositories(client *firestore.Client) *repositories {
+	return &repositories{
+		circle:            firestoreRepo.NewCircleRepository(client),
+		membership:        firestoreRepo.NewMembershipRepository(client),
+		event:             firestoreRepo.NewEventRepository(client),
+		announcement:      firestoreRepo.NewAnnouncementRepository(client),
+		rsvp:              firestoreRepo.NewRSVPRepository(client),
+		settlement:        firestoreRepo.NewSettlementRepository(client),
+		payment:           firestoreRepo.NewPaymentRepository(client),
+		user:              firestoreRepo.NewUserRepository(client),
+		practiceCategory:  firestoreRepo.NewPracticeCategoryRepository(client),
+		practiceSeries:    firestoreRepo.NewPracticeSeriesRepository(client),
+		practiceSession:   firestoreRepo.NewPracticeSessionRepository(client),
+		practiceRSVP:      firestoreRepo.NewPracticeRSVPRepository(client),
+		calendarException: firestoreRepo.NewCalendarExceptionRepository(client),
+		auditLog:          firestoreRepo.NewAuditLogRepository(client),
+		reminderPolicy:    firestoreRepo.NewReminderPolicyRepository(client),
+		reminderJob:       firestoreRepo.NewReminderJobRepository(client),
+		transactor:        firestoreRepo.NewTransactor(client),
+	}
+}
+
+// newMemoryRepositories creates repositories backed by a single in-memory store.
+func newMemoryRepositories() *repositories {
+	m := memory.New()
+	return &repositories{
+		circle:            m.Circle,
+		membership:        m.Membership,
+		event:             m.Event,
+		announcement:      m.Announcement,
+		rsvp:              m.RSVP,
+		settlement:        m.Settlement,
+		payment:           m.Payment,
+		user:              m.User,
+		practiceCategory:  m.PracticeCategory,
+		practiceSeries:    m.PracticeSeries,
+		practiceSession:   m.PracticeSession,
+		practiceRSVP:      m.PracticeRSVP,
+		calendarException: m.CalendarException,
+		auditLog:          m.AuditLog,
+		reminderPolicy:    m.ReminderPolicy,
+		reminderJob:       m.ReminderJob,
+		transactor:        m.Transactor,
+	}
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"log"
 	"time"
 
 	"github.com/noa/circle-app/api/domain"
@@ -11,15 +12,30 @@ import (
 // AnnouncementInteractor handles announcement-related business logic.
 type AnnouncementInteractor struct {
 	announcementRepo port.AnnouncementRepository
+	eventRepo        port.EventRepository
+	membershipRepo   port.MembershipRepository
+	notifier         port.Notifier
+	authz            *Authorizer
 }
 
 // NewAnnouncementInteractor creates a new AnnouncementInteractor.
-func NewAnnouncementInteractor(announcementRepo port.AnnouncementRepository) *AnnouncementInteractor {
-	return &AnnouncementInteractor{announcementRepo: announcementRepo}
+func NewAnnouncementInteractor(announcementRepo port.AnnouncementRepository, eventRepo port.EventRepository, membershipRepo port.MembershipRepository, notifier port.Notifier, authz *Authorizer) *AnnouncementInteractor {
+	return &AnnouncementInteractor{
+		announcementRepo: announcementRepo,
+		eventRepo:        eventRepo,
+		membershipRepo:   membershipRepo,
+		notifier:         notifier,
+		authz:            authz,
+	}
 }
 
-// CreateAnnouncement creates a new announcement.
+// CreateAnnouncement creates a new announcement and notifies the other
+// members of the circle. Only circle admins can post announcements.
 func (i *AnnouncementInteractor) CreateAnnouncement(ctx context.Context, circleID, eventID, title, body, createdBy string) (*domain.Announcement, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, createdBy); err != nil {
+		return nil, err
+	}
+
 	announcement := &domain.Announcement{
 		CircleID:  circleID,
 		EventID:   eventID,
@@ -31,33 +47,80 @@ func (i *AnnouncementInteractor) CreateAnnouncement(ctx context.Context, circleI
 	if err := i.announcementRepo.Create(ctx, announcement); err != nil {
 		return nil, err
 	}
+	i.notifyMembers(ctx, announcement)
 	return announcement, nil
 }
 
+// notifyMembers tells every member but the author about an announcement.
+func (i *AnnouncementInteractor) notifyMembers(ctx context.Context, a *domain.Announcement) {
+	memberships, err := i.membershipRepo.GetByCircle(ctx, a.CircleID)
+	if err != nil {
+		log.Printf("Warning: could not load members of circle %s: %v", a.CircleID, err)
+		return
+	}
+	var userIDs []string
+	for _, m := range memberships {
+		userIDs = append(userIDs, m.UserID)
+	}
+	userIDs = without(userIDs, a.CreatedBy)
+	if len(userIDs) == 0 {
+		return
+	}
+	n := &domain.Notification{
+		UserIDs:  userIDs,
+		CircleID: a.CircleID,
+		Title:    "お知らせ: " + a.Title,
+		Body:     truncate(a.Body, 200),
+	}
+	if err := i.notifier.Notify(ctx, n); err != nil {
+		log.Printf("Warning: could not notify members of announcement %s: %v", a.ID, err)
+	}
+}
+
 // GetByEvent returns announcements for an event.
-func (i *AnnouncementInteractor) GetByEvent(ctx context.Context, eventID string) ([]*domain.Announcement, error) {
+func (i *AnnouncementInteractor) GetByEvent(ctx context.Context, eventID, userID string) ([]*domain.Announcement, error) {
+	event, err := i.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.authz.RequireReadAccess(ctx, event.CircleID, userID); err != nil {
+		return nil, err
+	}
 	return i.announcementRepo.GetByEvent(ctx, eventID)
 }
 
 // GetByCircle returns latest announcements for a circle.
-func (i *AnnouncementInteractor) GetByCircle(ctx context.Context, circleID string, limit int) ([]*domain.Announcement, error) {
+func (i *AnnouncementInteractor) GetByCircle(ctx context.Context, circleID string, limit int, userID string) ([]*domain.Announcement, error) {
+	if err := i.authz.RequireReadAccess(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
 	if limit <= 0 {
 		limit = 10
 	}
 	return i.announcementRepo.GetByCircle(ctx, circleID, limit)
 }
 
-// GetAnnouncement returns an announcement by ID.
-func (i *AnnouncementInteractor) GetAnnouncement(ctx context.Context, id string) (*domain.Announcement, error) {
-	return i.announcementRepo.GetByID(ctx, id)
+// GetAnnouncement returns an announcement by ID to a member of its circle.
+func (i *AnnouncementInteractor) GetAnnouncement(ctx context.Context, id, userID string) (*domain.Announcement, error) {
+	announcement, err := i.announcementRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.authz.RequireReadAccess(ctx, announcement.CircleID, userID); err != nil {
+		return nil, err
+	}
+	return announcement, nil
 }
 
-// UpdateAnnouncement updates an announcement.
-func (i *AnnouncementInteractor) UpdateAnnouncement(ctx context.Context, id, title, body string) (*domain.Announcement, error) {
+// UpdateAnnouncement updates an announcement. Only circle admins can update announcements.
+func (i *AnnouncementInteractor) UpdateAnnouncement(ctx context.Context, id, title, body, actorID string) (*domain.Announcement, error) {
 	announcement, err := i.announcementRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := i.authz.RequireAdmin(ctx, announcement.CircleID, actorID); err != nil {
+		return nil, err
+	}
 	announcement.Title = title
 	announcement.Body = body
 	if err := i.announcementRepo.Update(ctx, announcement); err != nil {
@@ -66,7 +129,14 @@ func (i *AnnouncementInteractor) UpdateAnnouncement(ctx context.Context, id, tit
 	return announcement, nil
 }
 
-// DeleteAnnouncement deletes an announcement.
-func (i *AnnouncementInteractor) DeleteAnnouncement(ctx context.Context, id string) error {
+// DeleteAnnouncement deletes an announcement. Only circle admins can delete announcements.
+func (i *AnnouncementInteractor) DeleteAnnouncement(ctx context.Context, id, actorID string) error {
+	announcement, err := i.announcementRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := i.authz.RequireAdmin(ctx, announcement.CircleID, actorID); err != nil {
+		return err
+	}
 	return i.announcementRepo.Delete(ctx, id)
 }
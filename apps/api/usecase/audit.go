@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// AuditInteractor handles reading a circle's audit log.
+type AuditInteractor struct {
+	auditRepo port.AuditLogRepository
+	authz     *Authorizer
+}
+
+// NewAuditInteractor creates a new AuditInteractor.
+func NewAuditInteractor(auditRepo port.AuditLogRepository, authz *Authorizer) *AuditInteractor {
+	return &AuditInteractor{auditRepo: auditRepo, authz: authz}
+}
+
+// GetByCircle returns the audit log of a circle, newest first.
+// Only circle admins can read the audit log.
+func (i *AuditInteractor) GetByCircle(ctx context.Context, circleID, userID string) ([]*domain.AuditEntry, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
+	return i.auditRepo.GetByCircle(ctx, circleID)
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// Authorizer enforces the circle role policy:
+// admins manage events, announcements, settlements, members and practice
+// series; members RSVP and report their own payments.
+type Authorizer struct {
+	membershipRepo port.MembershipRepository
+}
+
+// NewAuthorizer creates a new Authorizer.
+func NewAuthorizer(membershipRepo port.MembershipRepository) *Authorizer {
+	return &Authorizer{membershipRepo: membershipRepo}
+}
+
+// RequireMember returns the user's membership in a circle.
+// It returns domain.ErrNotAuthorized if the user is not a member.
+func (a *Authorizer) RequireMember(ctx context.Context, circleID, userID string) (*domain.Membership, error) {
+	if circleID == "" || userID == "" {
+		return nil, domain.ErrNotAuthorized
+	}
+	membership, err := a.membershipRepo.GetByCircleAndUser(ctx, circleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil {
+		return nil, domain.ErrNotAuthorized
+	}
+	return membership, nil
+}
+
+// RequireReadAccess checks that the user can read data owned by a circle.
+// Non-members get domain.ErrNotFound so that resource IDs cannot be probed.
+func (a *Authorizer) RequireReadAccess(ctx context.Context, circleID, userID string) error {
+	if _, err := a.RequireMember(ctx, circleID, userID); err != nil {
+		if errors.Is(err, domain.ErrNotAuthorized) {
+			return domain.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RequireAdmin checks that the user is an admin of a circle.
+// It returns domain.ErrForbidden if the user is a member without the admin role.
+func (a *Authorizer) RequireAdmin(ctx context.Context, circleID, userID string) error {
+	membership, err := a.RequireMember(ctx, circleID, userID)
+	if err != nil {
+		return err
+	}
+	if membership.Role != domain.RoleAdmin {
+		return domain.ErrForbidden
+	}
+	return nil
+}
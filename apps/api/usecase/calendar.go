@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// DefaultEventDuration is the length given to calendar entries whose end is
+// unknown, since events and older practice series have no end time.
+const DefaultEventDuration = 2 * time.Hour
+
+// CalendarEntry is one event or practice session in a calendar feed.
+type CalendarEntry struct {
+	UID         string // stable across feed refreshes
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Updated     time.Time
+	Sequence    int // grows with every change, so subscribers replace their copy
+}
+
+// CalendarFeed is a circle's calendar as seen by one user.
+type CalendarFeed struct {
+	Name    string
+	Entries []CalendarEntry
+}
+
+// CalendarFeedLink is the secret feed address of a user.
+type CalendarFeedLink struct {
+	Token string `json:"token"`
+}
+
+// CalendarInteractor builds per-user iCalendar feeds of circle events and
+// practice sessions. Calendar apps cannot send bearer tokens, so feeds are
+// protected by an HMAC token bound to the circle, the user and the version
+// of the member's token instead, which members can rotate to revoke a URL.
+type CalendarInteractor struct {
+	circleRepo       port.CircleRepository
+	membershipRepo   port.MembershipRepository
+	eventRepo        port.EventRepository
+	rsvpRepo         port.RSVPRepository
+	seriesRepo       port.PracticeSeriesRepository
+	sessionRepo      port.PracticeSessionRepository
+	practiceRSVPRepo port.PracticeRSVPRepository
+	authz            *Authorizer
+	secret           []byte
+}
+
+// NewCalendarInteractor creates a new CalendarInteractor. Feeds are disabled
+// when secret is empty.
+func NewCalendarInteractor(
+	circleRepo port.CircleRepository,
+	membershipRepo port.MembershipRepository,
+	eventRepo port.EventRepository,
+	rsvpRepo port.RSVPRepository,
+	seriesRepo port.PracticeSeriesRepository,
+	sessionRepo port.PracticeSessionRepository,
+	practiceRSVPRepo port.PracticeRSVPRepository,
+	authz *Authorizer,
+	secret []byte,
+) *CalendarInteractor {
+	return &CalendarInteractor{
+		circleRepo:       circleRepo,
+		membershipRepo:   membershipRepo,
+		eventRepo:        eventRepo,
+		rsvpRepo:         rsvpRepo,
+		seriesRepo:       seriesRepo,
+		sessionRepo:      sessionRepo,
+		practiceRSVPRepo: practiceRSVPRepo,
+		authz:            authz,
+		secret:           secret,
+	}
+}
+
+// feedToken returns "<userID>.<signature>" for a member. Version 0 signs
+// only the circle and user, as tokens did before they could be rotated.
+func (uc *CalendarInteractor) feedToken(m *domain.Membership) string {
+	msg := m.CircleID + "\n" + m.UserID
+	if m.FeedTokenVersion > 0 {
+		msg += "\n" + strconv.Itoa(m.FeedTokenVersion)
+	}
+	mac := hmac.New(sha256.New, uc.secret)
+	mac.Write([]byte(msg))
+	return m.UserID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyFeedToken returns the user a feed token was issued to. The
+// membership is looked up on every fetch, so a member who left the circle or
+// rotated the token loses the feed.
+func (uc *CalendarInteractor) verifyFeedToken(ctx context.Context, circleID, token string) (string, error) {
+	i := strings.LastIndex(token, ".")
+	if len(uc.secret) == 0 || i <= 0 {
+		return "", domain.ErrNotFound
+	}
+	m, err := uc.authz.RequireMember(ctx, circleID, token[:i])
+	if errors.Is(err, domain.ErrNotAuthorized) {
+		return "", domain.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal([]byte(token), []byte(uc.feedToken(m))) {
+		return "", domain.ErrNotFound
+	}
+	return m.UserID, nil
+}
+
+// requireFeedMember returns the membership of a user asking for their feed
+// link.
+func (uc *CalendarInteractor) requireFeedMember(ctx context.Context, circleID, userID string) (*domain.Membership, error) {
+	m, err := uc.authz.RequireMember(ctx, circleID, userID)
+	if errors.Is(err, domain.ErrNotAuthorized) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(uc.secret) == 0 {
+		return nil, fmt.Errorf("%w: calendar feeds are not configured", domain.ErrPreconditionFailed)
+	}
+	return m, nil
+}
+
+// GetFeedLink returns the feed token of userID for a circle.
+func (uc *CalendarInteractor) GetFeedLink(ctx context.Context, circleID, userID string) (*CalendarFeedLink, error) {
+	m, err := uc.requireFeedMember(ctx, circleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &CalendarFeedLink{Token: uc.feedToken(m)}, nil
+}
+
+// RotateFeedLink revokes the feed token of userID for a circle and returns
+// a new one. Calendars subscribed with the old URL stop receiving updates.
+func (uc *CalendarInteractor) RotateFeedLink(ctx context.Context, circleID, userID string) (*CalendarFeedLink, error) {
+	m, err := uc.requireFeedMember(ctx, circleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	m.FeedTokenVersion++
+	if err := uc.membershipRepo.Update(ctx, m); err != nil {
+		return nil, err
+	}
+	return &CalendarFeedLink{Token: uc.feedToken(m)}, nil
+}
+
+// GetFeed returns the calendar of a circle for the holder of a feed token:
+// its events and non-cancelled practice sessions, or only those the user
+// RSVP'd GO to when goOnly is set. Invalid or rotated tokens and users who
+// left the circle get domain.ErrNotFound.
+func (uc *CalendarInteractor) GetFeed(ctx context.Context, circleID, token string, goOnly bool) (*CalendarFeed, error) {
+	userID, err := uc.verifyFeedToken(ctx, circleID, token)
+	if err != nil {
+		return nil, err
+	}
+	circle, err := uc.circleRepo.GetByID(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &CalendarFeed{Name: circle.Name}
+	if err := uc.addEvents(ctx, feed, circleID, userID, goOnly); err != nil {
+		return nil, err
+	}
+	if err := uc.addSessions(ctx, feed, circleID, userID, goOnly); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(feed.Entries, func(i, j int) bool {
+		return feed.Entries[i].Start.Before(feed.Entries[j].Start)
+	})
+	return feed, nil
+}
+
+func (uc *CalendarInteractor) addEvents(ctx context.Context, feed *CalendarFeed, circleID, userID string, goOnly bool) error {
+	events, err := uc.eventRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if goOnly {
+			rsvp, err := uc.rsvpRepo.GetByEventAndUser(ctx, e.ID, userID)
+			if err != nil {
+				return err
+			}
+			if rsvp == nil || rsvp.Status != domain.RSVPGo || rsvp.Waitlisted {
+				continue
+			}
+		}
+		feed.Entries = append(feed.Entries, CalendarEntry{
+			UID:      "event-" + e.ID,
+			Summary:  e.Title,
+			Location: e.Location,
+			Start:    e.StartAt,
+			End:      e.StartAt.Add(DefaultEventDuration),
+			Updated:  lastModified(e.CreatedAt, e.UpdatedAt),
+			Sequence: e.Sequence,
+		})
+	}
+	return nil
+}
+
+func (uc *CalendarInteractor) addSessions(ctx context.Context, feed *CalendarFeed, circleID, userID string, goOnly bool) error {
+	seriesList, err := uc.seriesRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return err
+	}
+	for _, series := range seriesList {
+		sessions, err := uc.sessionRepo.GetBySeries(ctx, series.ID)
+		if err != nil {
+			return err
+		}
+		var going map[string]bool
+		if goOnly {
+			rsvps, err := uc.practiceRSVPRepo.GetBySeriesAndUser(ctx, series.ID, userID)
+			if err != nil {
+				return err
+			}
+			going = make(map[string]bool, len(rsvps))
+			for _, r := range rsvps {
+				going[r.SessionID] = r.Status == domain.PracticeRSVPGo
+			}
+		}
+
+		for _, s := range sessions {
+			if s.Cancelled || (goOnly && !going[s.ID]) {
+				continue
+			}
+			end := s.EndAt
+			if end.IsZero() {
+				end = s.Date.Add(DefaultEventDuration)
+			}
+			description := s.Note
+			if !s.OriginalDate.IsZero() && s.RescheduleReason != "" {
+				description = strings.TrimSpace(description + "\n" + s.RescheduleReason)
+			}
+			feed.Entries = append(feed.Entries, CalendarEntry{
+				UID:         "practice-" + s.ID,
+				Summary:     series.Name,
+				Description: description,
+				Location:    series.Location,
+				Start:       s.Date,
+				End:         end,
+				Updated:     lastModified(s.CreatedAt, s.UpdatedAt, series.UpdatedAt),
+				// Entries show the series' name and location too.
+				Sequence: s.Sequence + series.Sequence,
+			})
+		}
+	}
+	return nil
+}
+
+// lastModified returns the latest of the times, which are zero for data
+// saved before they were recorded.
+func lastModified(times ...time.Time) time.Time {
+	var latest time.Time
+	for _, t := range times {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
@@ -0,0 +1,135 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// leftMembers hides the memberships of users who left a circle.
+type leftMembers struct {
+	port.MembershipRepository
+	left map[string]bool
+}
+
+func (r *leftMembers) GetByCircleAndUser(ctx context.Context, circleID, userID string) (*domain.Membership, error) {
+	if r.left[userID] {
+		return nil, nil
+	}
+	return r.MembershipRepository.GetByCircleAndUser(ctx, circleID, userID)
+}
+
+func TestCalendarFeedTokens(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1")
+	other := f.circle("other")
+	link, err := f.calendar().GetFeedLink(f.ctx, circleID, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.calendar().GetFeed(f.ctx, circleID, link.Token, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{
+		"tampered":     link.Token + "x",
+		"other user":   "admin" + link.Token[len("u1"):],
+		"no signature": "u1",
+		"empty":        "",
+	} {
+		_, err := f.calendar().GetFeed(f.ctx, circleID, token, false)
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("%s: got error %v, want not found", name, err)
+		}
+	}
+	_, err = f.calendar().GetFeed(f.ctx, other, link.Token, false)
+	wantErr(t, err, domain.ErrNotFound)
+	_, err = f.calendar().GetFeedLink(f.ctx, circleID, "stranger")
+	wantErr(t, err, domain.ErrNotFound)
+
+	// Leaving the circle revokes the feed, since membership is checked on
+	// every fetch.
+	r := f.repos
+	memberships := &leftMembers{MembershipRepository: r.Membership, left: map[string]bool{"u1": true}}
+	calendar := usecase.NewCalendarInteractor(r.Circle, memberships, r.Event, r.RSVP, r.PracticeSeries, r.PracticeSession, r.PracticeRSVP, usecase.NewAuthorizer(memberships), calendarSecret)
+	_, err = calendar.GetFeed(f.ctx, circleID, link.Token, false)
+	wantErr(t, err, domain.ErrNotFound)
+
+	// Rotating revokes the old token.
+	rotated, err := f.calendar().RotateFeedLink(f.ctx, circleID, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Token == link.Token {
+		t.Fatal("rotation kept the token")
+	}
+	_, err = f.calendar().GetFeed(f.ctx, circleID, link.Token, false)
+	wantErr(t, err, domain.ErrNotFound)
+	if _, err := f.calendar().GetFeed(f.ctx, circleID, rotated.Token, false); err != nil {
+		t.Fatal(err)
+	}
+	again, err := f.calendar().GetFeedLink(f.ctx, circleID, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Token != rotated.Token {
+		t.Errorf("feed link after rotation = %q, want %q", again.Token, rotated.Token)
+	}
+}
+
+func TestCalendarFeedReportsChanges(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin")
+	startAt := time.Now().Add(48 * time.Hour)
+	e := f.event(circleID, "admin", startAt, 0, time.Time{})
+	_, sessions := f.series(circleID, "admin", 0)
+	link, err := f.calendar().GetFeedLink(f.ctx, circleID, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := func(uid string) usecase.CalendarEntry {
+		t.Helper()
+		feed, err := f.calendar().GetFeed(f.ctx, circleID, link.Token, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range feed.Entries {
+			if entry.UID == uid {
+				return entry
+			}
+		}
+		t.Fatalf("no entry %s", uid)
+		return usecase.CalendarEntry{}
+	}
+
+	before := entry("event-" + e.ID)
+	if before.Sequence != 0 || before.Updated.IsZero() {
+		t.Fatalf("new event entry %+v", before)
+	}
+	if _, err := f.events().UpdateEvent(f.ctx, e.ID, e.Title, startAt.Add(time.Hour), "体育館", "", nil, 0, time.Time{}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	after := entry("event-" + e.ID)
+	if after.Sequence != 1 || after.Updated.Before(before.Updated) || after.Location != "体育館" {
+		t.Errorf("updated event entry %+v, want sequence 1", after)
+	}
+
+	uid := "practice-" + sessions[0].ID
+	before = entry(uid)
+	session, err := f.repos.PracticeSession.GetByID(f.ctx, sessions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Note = "コート半面"
+	if err := f.repos.PracticeSession.Update(f.ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	if after := entry(uid); after.Sequence != before.Sequence+1 || after.Description != "コート半面" {
+		t.Errorf("updated session entry %+v, want sequence %d", after, before.Sequence+1)
+	}
+}
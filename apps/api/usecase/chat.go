@@ -12,19 +12,26 @@ type ChatInteractor struct {
 	announcementRepo port.AnnouncementRepository
 	eventRepo        port.EventRepository
 	aiService        port.AIService
+	authz            *Authorizer
 }
 
 // NewChatInteractor creates a new ChatInteractor.
-func NewChatInteractor(announcementRepo port.AnnouncementRepository, eventRepo port.EventRepository, aiService port.AIService) *ChatInteractor {
+func NewChatInteractor(announcementRepo port.AnnouncementRepository, eventRepo port.EventRepository, aiService port.AIService, authz *Authorizer) *ChatInteractor {
 	return &ChatInteractor{
 		announcementRepo: announcementRepo,
 		eventRepo:        eventRepo,
 		aiService:        aiService,
+		authz:            authz,
 	}
 }
 
 // Ask processes a user question using circle's announcements as context.
-func (i *ChatInteractor) Ask(ctx context.Context, circleID, message string) (*domain.ChatResponse, error) {
+// Only members of the circle can ask about it.
+func (i *ChatInteractor) Ask(ctx context.Context, circleID, message, userID string) (*domain.ChatResponse, error) {
+	if err := i.authz.RequireReadAccess(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
+
 	// Get latest announcements for context (max 10)
 	announcements, err := i.announcementRepo.GetByCircle(ctx, circleID, 10)
 	if err != nil {
@@ -2,8 +2,12 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/noa/circle-app/api/domain"
 	"github.com/noa/circle-app/api/usecase/port"
@@ -14,19 +18,21 @@ type CircleInteractor struct {
 	circleRepo     port.CircleRepository
 	membershipRepo port.MembershipRepository
 	userRepo       port.UserRepository
+	authz          *Authorizer
 }
 
 // NewCircleInteractor creates a new CircleInteractor.
-func NewCircleInteractor(circleRepo port.CircleRepository, membershipRepo port.MembershipRepository, userRepo port.UserRepository) *CircleInteractor {
+func NewCircleInteractor(circleRepo port.CircleRepository, membershipRepo port.MembershipRepository, userRepo port.UserRepository, authz *Authorizer) *CircleInteractor {
 	return &CircleInteractor{
 		circleRepo:     circleRepo,
 		membershipRepo: membershipRepo,
 		userRepo:       userRepo,
+		authz:          authz,
 	}
 }
 
-// CreateCircle creates a new circle.
-func (i *CircleInteractor) CreateCircle(ctx context.Context, name, description, logoURL string) (*domain.Circle, error) {
+// CreateCircle creates a new circle with the creator as its first admin.
+func (i *CircleInteractor) CreateCircle(ctx context.Context, name, description, logoURL, createdBy string) (*domain.Circle, error) {
 	circle := &domain.Circle{
 		Name:        name,
 		Description: description,
@@ -36,20 +42,64 @@ func (i *CircleInteractor) CreateCircle(ctx context.Context, name, description,
 	if err := i.circleRepo.Create(ctx, circle); err != nil {
 		return nil, err
 	}
+
+	owner := &domain.Membership{
+		CircleID: circle.ID,
+		UserID:   createdBy,
+		Role:     domain.RoleAdmin,
+		JoinedAt: time.Now(),
+	}
+	if err := i.membershipRepo.Create(ctx, owner); err != nil {
+		return nil, err
+	}
 	return circle, nil
 }
 
-// GetCircle returns a circle by ID.
-func (i *CircleInteractor) GetCircle(ctx context.Context, id string) (*domain.Circle, error) {
+// GetCircle returns a circle by ID to one of its members.
+func (i *CircleInteractor) GetCircle(ctx context.Context, id, userID string) (*domain.Circle, error) {
+	if err := i.authz.RequireReadAccess(ctx, id, userID); err != nil {
+		return nil, err
+	}
 	return i.circleRepo.GetByID(ctx, id)
 }
 
-// AddMember adds a member to a circle.
-func (i *CircleInteractor) AddMember(ctx context.Context, circleID, userID string, role domain.MemberRole) (*domain.Membership, error) {
+// UpdatePaymentSettings sets the payment instructions of settlements the
+// circle bills automatically. Only admins can change circle settings.
+func (i *CircleInteractor) UpdatePaymentSettings(ctx context.Context, circleID, bankInfo, paypayInfo, actorID string) (*domain.Circle, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, actorID); err != nil {
+		return nil, err
+	}
+	circle, err := i.circleRepo.GetByID(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+	circle.BankInfo = bankInfo
+	circle.PayPayInfo = paypayInfo
+	if err := i.circleRepo.Update(ctx, circle); err != nil {
+		return nil, err
+	}
+	return circle, nil
+}
+
+// AddMember adds a member to a circle, with tags such as the member's year
+// for pricing rules. Only admins can add members.
+func (i *CircleInteractor) AddMember(ctx context.Context, circleID, userID string, role domain.MemberRole, tags []string, actorID string) (*domain.Membership, error) {
+	if !role.Valid() {
+		return nil, domain.NewValidationError("role", "must be one of ADMIN, MEMBER")
+	}
+	tags, err := memberTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.authz.RequireAdmin(ctx, circleID, actorID); err != nil {
+		return nil, err
+	}
+
 	membership := &domain.Membership{
 		CircleID: circleID,
 		UserID:   userID,
 		Role:     role,
+		Tags:     tags,
 		JoinedAt: time.Now(),
 	}
 	if err := i.membershipRepo.Create(ctx, membership); err != nil {
@@ -58,8 +108,62 @@ func (i *CircleInteractor) AddMember(ctx context.Context, circleID, userID strin
 	return membership, nil
 }
 
+// SetMemberTags replaces the tags of a member. Settlements priced by tag are
+// repriced when their rules are set again. Only admins can tag members.
+func (i *CircleInteractor) SetMemberTags(ctx context.Context, circleID, userID string, tags []string, actorID string) (*domain.Membership, error) {
+	tags, err := memberTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.authz.RequireAdmin(ctx, circleID, actorID); err != nil {
+		return nil, err
+	}
+
+	membership, err := i.membershipRepo.GetByCircleAndUser(ctx, circleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil {
+		return nil, domain.ErrNotFound
+	}
+	membership.Tags = tags
+	if err := i.membershipRepo.Update(ctx, membership); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// maxMemberTagRunes bounds the length of a member tag.
+const maxMemberTagRunes = 30
+
+// memberTags trims tags and drops empty and repeated ones.
+func memberTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.Contains(out, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxMemberTagRunes {
+			return nil, domain.NewValidationError("tags", fmt.Sprintf("must be at most %d characters each", maxMemberTagRunes))
+		}
+		out = append(out, tag)
+	}
+	if len(out) > domain.MaxMemberTags {
+		return nil, domain.NewValidationError("tags", fmt.Sprintf("must not have more than %d tags", domain.MaxMemberTags))
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
 // GetMembers returns all users who are members of a circle.
-func (i *CircleInteractor) GetMembers(ctx context.Context, circleID string) ([]*domain.User, error) {
+func (i *CircleInteractor) GetMembers(ctx context.Context, circleID, userID string) ([]*domain.User, error) {
+	if err := i.authz.RequireReadAccess(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
+
 	memberships, err := i.membershipRepo.GetByCircle(ctx, circleID)
 	if err != nil {
 		return nil, err
@@ -72,6 +176,9 @@ func (i *CircleInteractor) GetMembers(ctx context.Context, circleID string) ([]*
 			log.Printf("Warning: could not find user %s: %v", m.UserID, err)
 			continue
 		}
+		if user.ID != userID {
+			hidePrivate(user)
+		}
 		users = append(users, user)
 	}
 	return users, nil
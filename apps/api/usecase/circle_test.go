@@ -0,0 +1,67 @@
+package usecase_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+func TestCreateCircleMakesCreatorAdmin(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin")
+
+	m, err := f.authz.RequireMember(f.ctx, circleID, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Role != domain.RoleAdmin {
+		t.Errorf("creator role = %s, want ADMIN", m.Role)
+	}
+}
+
+func TestGetCircleHidesCircleFromNonMembers(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "member")
+
+	if _, err := f.circles().GetCircle(f.ctx, circleID, "member"); err != nil {
+		t.Errorf("member: %v", err)
+	}
+	_, err := f.circles().GetCircle(f.ctx, circleID, "stranger")
+	wantErr(t, err, domain.ErrNotFound)
+}
+
+func TestAddMemberRequiresAdmin(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "member")
+
+	_, err := f.circles().AddMember(f.ctx, circleID, "new", domain.RoleMember, nil, "member")
+	wantErr(t, err, domain.ErrForbidden)
+	_, err = f.circles().AddMember(f.ctx, circleID, "new", domain.RoleMember, nil, "stranger")
+	wantErr(t, err, domain.ErrNotAuthorized)
+	_, err = f.circles().AddMember(f.ctx, circleID, "new", "OWNER", nil, "admin")
+	wantErr(t, err, domain.ErrInvalidInput)
+}
+
+func TestAddMemberNormalizesTags(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin")
+
+	m, err := f.circles().AddMember(f.ctx, circleID, "new", domain.RoleMember, []string{" 1年 ", "1年", "", "幹事"}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1年", "幹事"}; !slices.Equal(m.Tags, want) {
+		t.Errorf("tags = %q, want %q", m.Tags, want)
+	}
+
+	m, err = f.circles().SetMemberTags(f.ctx, circleID, "new", nil, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Tags != nil {
+		t.Errorf("tags = %q, want none", m.Tags)
+	}
+	_, err = f.circles().SetMemberTags(f.ctx, circleID, "stranger", []string{"1年"}, "admin")
+	wantErr(t, err, domain.ErrNotFound)
+}
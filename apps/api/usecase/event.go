@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/noa/circle-app/api/domain"
@@ -10,16 +13,124 @@ import (
 
 // EventInteractor handles event-related business logic.
 type EventInteractor struct {
-	eventRepo port.EventRepository
+	eventRepo      port.EventRepository
+	membershipRepo port.MembershipRepository
+	userRepo       port.UserRepository
+	rsvpRepo       port.RSVPRepository
+	seats          *eventSeats
+	tx             port.Transactor
+	notifier       port.Notifier
+	authz          *Authorizer
 }
 
 // NewEventInteractor creates a new EventInteractor.
-func NewEventInteractor(eventRepo port.EventRepository) *EventInteractor {
-	return &EventInteractor{eventRepo: eventRepo}
+func NewEventInteractor(
+	eventRepo port.EventRepository,
+	membershipRepo port.MembershipRepository,
+	userRepo port.UserRepository,
+	rsvpRepo port.RSVPRepository,
+	settlementRepo port.SettlementRepository,
+	paymentRepo port.PaymentRepository,
+	tx port.Transactor,
+	notifier port.Notifier,
+	authz *Authorizer,
+) *EventInteractor {
+	return &EventInteractor{
+		eventRepo:      eventRepo,
+		membershipRepo: membershipRepo,
+		userRepo:       userRepo,
+		rsvpRepo:       rsvpRepo,
+		seats: &eventSeats{
+			rsvpRepo:       rsvpRepo,
+			settlementRepo: settlementRepo,
+			paymentRepo:    paymentRepo,
+			pricer:         &settlementPricer{membershipRepo: membershipRepo, rsvpRepo: rsvpRepo},
+			notifier:       notifier,
+		},
+		tx:       tx,
+		notifier: notifier,
+		authz:    authz,
+	}
+}
+
+// notifyTargets sends a notification about an event to its RSVP targets,
+// except the admin who caused it.
+func (i *EventInteractor) notifyTargets(ctx context.Context, event *domain.Event, actorID, title, body string) {
+	targets, err := eventTargets(ctx, i.membershipRepo, event)
+	if err != nil {
+		log.Printf("Warning: could not load targets of event %s: %v", event.ID, err)
+		return
+	}
+	userIDs := without(targets, actorID)
+	if len(userIDs) == 0 {
+		return
+	}
+	n := &domain.Notification{UserIDs: userIDs, CircleID: event.CircleID, Title: title, Body: body}
+	if err := i.notifier.Notify(ctx, n); err != nil {
+		log.Printf("Warning: could not notify targets of event %s: %v", event.ID, err)
+	}
+}
+
+// eventChanges describes what members need to know about an update: the
+// title, time and place. Other fields change silently.
+func eventChanges(before, after *domain.Event) []string {
+	var changes []string
+	if before.Title != after.Title {
+		changes = append(changes, fmt.Sprintf("タイトル: %s → %s", before.Title, after.Title))
+	}
+	if !before.StartAt.Equal(after.StartAt) {
+		changes = append(changes, fmt.Sprintf("日時: %s → %s", formatSessionDate(before.StartAt), formatSessionDate(after.StartAt)))
+	}
+	if before.Location != after.Location {
+		changes = append(changes, fmt.Sprintf("場所: %s → %s", before.Location, after.Location))
+	}
+	return changes
+}
+
+// validateRSVPDeadline checks that RSVPs do not stay open past the start.
+func validateRSVPDeadline(startAt, deadline time.Time) error {
+	if !deadline.IsZero() && deadline.After(startAt) {
+		return domain.NewValidationError("rsvpDeadline", "must not be after startAt")
+	}
+	return nil
 }
 
-// CreateEvent creates a new event.
-func (i *EventInteractor) CreateEvent(ctx context.Context, circleID, title string, startAt time.Time, location, coverImageURL string, rsvpTargetUserIDs []string, createdBy string) (*domain.Event, error) {
+// validateTargets checks that the RSVP targets of an event are members of its
+// circle. An empty list targets every member.
+func (i *EventInteractor) validateTargets(ctx context.Context, circleID string, targetUserIDs []string) error {
+	if len(targetUserIDs) == 0 {
+		return nil
+	}
+	memberships, err := i.membershipRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return err
+	}
+	members := make(map[string]bool, len(memberships))
+	for _, m := range memberships {
+		members[m.UserID] = true
+	}
+	for _, id := range targetUserIDs {
+		if !members[id] {
+			return domain.NewValidationError("rsvpTargetUserIds", "must only contain members of the circle: "+id)
+		}
+	}
+	return nil
+}
+
+// CreateEvent creates a new event. A capacity of 0 means unlimited and a zero
+// rsvpDeadline keeps RSVPs open until the event starts. The targets are
+// notified. Only circle admins can create events.
+func (i *EventInteractor) CreateEvent(ctx context.Context, circleID, title string, startAt time.Time, location, coverImageURL string, rsvpTargetUserIDs []string, capacity int, rsvpDeadline time.Time, createdBy string) (*domain.Event, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, createdBy); err != nil {
+		return nil, err
+	}
+	if err := validateRSVPDeadline(startAt, rsvpDeadline); err != nil {
+		return nil, err
+	}
+	if err := i.validateTargets(ctx, circleID, rsvpTargetUserIDs); err != nil {
+		return nil, err
+	}
+
 	event := &domain.Event{
 		CircleID:          circleID,
 		Title:             title,
@@ -27,45 +138,110 @@ func (i *EventInteractor) CreateEvent(ctx context.Context, circleID, title strin
 		Location:          location,
 		CoverImageURL:     coverImageURL,
 		RSVPTargetUserIDs: rsvpTargetUserIDs,
+		Capacity:          capacity,
+		RSVPDeadline:      rsvpDeadline,
 		CreatedBy:         createdBy,
 		CreatedAt:         time.Now(),
 	}
 	if err := i.eventRepo.Create(ctx, event); err != nil {
 		return nil, err
 	}
+	i.notifyTargets(ctx, event, createdBy, "新しいイベント",
+		fmt.Sprintf("「%s」（%s）が追加されました。出欠を回答してください", event.Title, formatSessionDate(event.StartAt)))
 	return event, nil
 }
 
-// GetEvent returns an event by ID.
-func (i *EventInteractor) GetEvent(ctx context.Context, id string) (*domain.Event, error) {
-	return i.eventRepo.GetByID(ctx, id)
+// GetEvent returns an event by ID to a member of its circle.
+func (i *EventInteractor) GetEvent(ctx context.Context, id, userID string) (*domain.Event, error) {
+	event, err := i.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.authz.RequireReadAccess(ctx, event.CircleID, userID); err != nil {
+		return nil, err
+	}
+	return event, nil
 }
 
 // GetEventsByCircle returns all events for a circle.
-func (i *EventInteractor) GetEventsByCircle(ctx context.Context, circleID string) ([]*domain.Event, error) {
+func (i *EventInteractor) GetEventsByCircle(ctx context.Context, circleID, userID string) ([]*domain.Event, error) {
+	if err := i.authz.RequireReadAccess(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
 	return i.eventRepo.GetByCircle(ctx, circleID)
 }
 
-// UpdateEvent updates an event.
-func (i *EventInteractor) UpdateEvent(ctx context.Context, eventID, title string, startAt time.Time, location, coverImageURL string, rsvpTargetUserIDs []string) (*domain.Event, error) {
+// UpdateEvent updates an event. Raising the capacity promotes waitlisted
+// members; lowering it keeps the seats already taken. Targets are notified
+// when the title, time or place changes. Only circle admins can update events.
+func (i *EventInteractor) UpdateEvent(ctx context.Context, eventID, title string, startAt time.Time, location, coverImageURL string, rsvpTargetUserIDs []string, capacity int, rsvpDeadline time.Time, actorID string) (*domain.Event, error) {
 	event, err := i.eventRepo.GetByID(ctx, eventID)
 	if err != nil {
 		return nil, err
 	}
+	if err := i.authz.RequireAdmin(ctx, event.CircleID, actorID); err != nil {
+		return nil, err
+	}
+	if err := validateRSVPDeadline(startAt, rsvpDeadline); err != nil {
+		return nil, err
+	}
+	if err := i.validateTargets(ctx, event.CircleID, rsvpTargetUserIDs); err != nil {
+		return nil, err
+	}
 
-	event.Title = title
-	event.StartAt = startAt
-	event.Location = location
-	event.CoverImageURL = coverImageURL
-	event.RSVPTargetUserIDs = rsvpTargetUserIDs
+	var promoted []*domain.RSVP
+	var before domain.Event
+	err = i.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		event, err = i.eventRepo.GetByID(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		before = *event
+		rsvps, err := i.rsvpRepo.GetByEvent(ctx, eventID)
+		if err != nil {
+			return err
+		}
 
-	if err := i.eventRepo.Update(ctx, event); err != nil {
+		event.Title = title
+		event.StartAt = startAt
+		event.Location = location
+		event.CoverImageURL = coverImageURL
+		event.RSVPTargetUserIDs = rsvpTargetUserIDs
+		event.Capacity = capacity
+		event.RSVPDeadline = rsvpDeadline
+
+		promoted = promote(capacity, rsvps)
+		if err := i.seats.save(ctx, eventID, promoted); err != nil {
+			return err
+		}
+		return i.eventRepo.Update(ctx, event)
+	})
+	if err != nil {
 		return nil, err
 	}
+
+	i.seats.notifyPromoted(ctx, event, promoted)
+	if changes := eventChanges(&before, event); len(changes) > 0 {
+		i.notifyTargets(ctx, event, actorID, "イベント変更のお知らせ",
+			fmt.Sprintf("「%s」の内容が変更されました\n%s", event.Title, strings.Join(changes, "\n")))
+	}
 	return event, nil
 }
 
-// DeleteEvent deletes an event.
-func (i *EventInteractor) DeleteEvent(ctx context.Context, eventID string) error {
-	return i.eventRepo.Delete(ctx, eventID)
+// DeleteEvent deletes an event and tells its targets it is cancelled.
+// Only circle admins can delete events.
+func (i *EventInteractor) DeleteEvent(ctx context.Context, eventID, actorID string) error {
+	event, err := i.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	if err := i.authz.RequireAdmin(ctx, event.CircleID, actorID); err != nil {
+		return err
+	}
+	if err := i.eventRepo.Delete(ctx, eventID); err != nil {
+		return err
+	}
+	i.notifyTargets(ctx, event, actorID, "イベント中止のお知らせ",
+		fmt.Sprintf("「%s」（%s）は中止になりました", event.Title, formatSessionDate(event.StartAt)))
+	return nil
 }
@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// MaxImportRows bounds the number of events imported at once, so that an
+// import fits in a single Firestore transaction.
+const MaxImportRows = 400
+
+// EventImportRow is one event read from an import file, before validation.
+type EventImportRow struct {
+	Row      int // 1-based file line (CSV) or VEVENT number (.ics)
+	Title    string
+	StartAt  time.Time
+	Location string
+	Targets  []string // member names, email addresses or user IDs
+	// Errors holds problems found while parsing the row, such as an
+	// unreadable date.
+	Errors []domain.FieldError
+}
+
+// EventImportItem is the outcome of validating one import row.
+type EventImportItem struct {
+	Row    int                 `json:"row"`
+	Event  *domain.Event       `json:"event"`
+	Errors []domain.FieldError `json:"errors,omitempty"`
+}
+
+// EventImportPreview lists the events an import would create.
+type EventImportPreview struct {
+	Items   []*EventImportItem `json:"items"`
+	Valid   int                `json:"valid"`
+	Invalid int                `json:"invalid"`
+}
+
+// EventImportResult is the outcome of committing an import.
+type EventImportResult struct {
+	Created []*domain.Event    `json:"created"`
+	Skipped []*EventImportItem `json:"skipped"`
+}
+
+// memberDirectory resolves member names, verified emails and IDs to user IDs.
+type memberDirectory struct {
+	ids     map[string]bool
+	byEmail map[string][]string
+	byName  map[string][]string
+}
+
+func (i *EventInteractor) loadMemberDirectory(ctx context.Context, circleID string) (*memberDirectory, error) {
+	memberships, err := i.membershipRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+	dir := &memberDirectory{
+		ids:     make(map[string]bool),
+		byEmail: make(map[string][]string),
+		byName:  make(map[string][]string),
+	}
+	for _, m := range memberships {
+		dir.ids[m.UserID] = true
+		user, err := i.userRepo.GetByID(ctx, m.UserID)
+		if err != nil {
+			log.Printf("Warning: could not find user %s: %v", m.UserID, err)
+			continue
+		}
+		// Users can enter any address in their profile, so only verified
+		// ones identify them.
+		if email := user.VerifiedEmail(); email != "" {
+			key := strings.ToLower(email)
+			dir.byEmail[key] = append(dir.byEmail[key], user.ID)
+		}
+		if key := nameKey(user.Name); key != "" {
+			dir.byName[key] = append(dir.byName[key], user.ID)
+		}
+	}
+	return dir, nil
+}
+
+// nameKey normalizes a name so that "山田 太郎", "山田　太郎" and "山田太郎" match.
+func nameKey(name string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, name))
+}
+
+// resolve returns the user ID a target refers to.
+func (d *memberDirectory) resolve(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if d.ids[target] {
+		return target, nil
+	}
+	if strings.Contains(target, "@") {
+		switch ids := d.byEmail[strings.ToLower(target)]; len(ids) {
+		case 0:
+			return "", fmt.Errorf("no member has verified email %s", target)
+		case 1:
+			return ids[0], nil
+		default:
+			return "", fmt.Errorf("%d members have email %s; use their user ID", len(ids), target)
+		}
+	}
+	switch ids := d.byName[nameKey(target)]; len(ids) {
+	case 0:
+		return "", fmt.Errorf("no member is named %s", target)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("%d members are named %s; use their email", len(ids), target)
+	}
+}
+
+// eventKey identifies events that are the same occurrence.
+func eventKey(title string, startAt time.Time) string {
+	return title + "\x00" + startAt.UTC().Format(time.RFC3339)
+}
+
+// PreviewImport validates imported rows and returns the events they would
+// create, with the problems of each row. Nothing is written. Rows that repeat
+// an existing event or an earlier row are reported as duplicates, so the same
+// file can be imported again safely. Only circle admins can import events.
+func (i *EventInteractor) PreviewImport(ctx context.Context, circleID string, rows []*EventImportRow, actorID string) (*EventImportPreview, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, actorID); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, domain.NewValidationError("file", "contains no events")
+	}
+	if len(rows) > MaxImportRows {
+		return nil, domain.NewValidationError("file", fmt.Sprintf("contains %d events; at most %d can be imported at once", len(rows), MaxImportRows))
+	}
+
+	dir, err := i.loadMemberDirectory(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := i.eventRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		seen[eventKey(e.Title, e.StartAt)] = true
+	}
+
+	preview := &EventImportPreview{Items: make([]*EventImportItem, 0, len(rows))}
+	for _, row := range rows {
+		item := &EventImportItem{
+			Row: row.Row,
+			Event: &domain.Event{
+				CircleID:          circleID,
+				Title:             strings.TrimSpace(row.Title),
+				StartAt:           row.StartAt,
+				Location:          strings.TrimSpace(row.Location),
+				RSVPTargetUserIDs: []string{},
+				CreatedBy:         actorID,
+			},
+			Errors: append([]domain.FieldError(nil), row.Errors...),
+		}
+		addErr := func(field, msg string) {
+			item.Errors = append(item.Errors, domain.FieldError{Field: field, Message: msg})
+		}
+
+		e := item.Event
+		switch n := utf8.RuneCountInString(e.Title); {
+		case n == 0:
+			addErr("title", "is required")
+		case n > 100:
+			addErr("title", "length must be at most 100")
+		}
+		if e.StartAt.IsZero() && !hasFieldError(row.Errors, "startAt") {
+			addErr("startAt", "is required")
+		}
+		if utf8.RuneCountInString(e.Location) > 200 {
+			addErr("location", "length must be at most 200")
+		}
+		for _, target := range row.Targets {
+			if strings.TrimSpace(target) == "" {
+				continue
+			}
+			id, err := dir.resolve(target)
+			if err != nil {
+				addErr("targets", err.Error())
+				continue
+			}
+			if !slices.Contains(e.RSVPTargetUserIDs, id) {
+				e.RSVPTargetUserIDs = append(e.RSVPTargetUserIDs, id)
+			}
+		}
+		if !e.StartAt.IsZero() && e.Title != "" {
+			key := eventKey(e.Title, e.StartAt)
+			if seen[key] {
+				addErr("title", "duplicates an existing event at the same time")
+			}
+			seen[key] = true
+		}
+
+		if len(item.Errors) > 0 {
+			preview.Invalid++
+		} else {
+			preview.Valid++
+		}
+		preview.Items = append(preview.Items, item)
+	}
+	return preview, nil
+}
+
+// ImportEvents creates the events of imported rows in one transaction. If
+// any row is invalid nothing is created and the problems are returned as a
+// validation error with fields like "rows[3].startAt", unless skipInvalid is
+// set, in which case invalid rows are reported as skipped. Only circle admins
+// can import events.
+func (i *EventInteractor) ImportEvents(ctx context.Context, circleID string, rows []*EventImportRow, skipInvalid bool, actorID string) (*EventImportResult, error) {
+	preview, err := i.PreviewImport(ctx, circleID, rows, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EventImportResult{Created: []*domain.Event{}, Skipped: []*EventImportItem{}}
+	verr := &domain.ValidationError{}
+	var events []*domain.Event
+	for _, item := range preview.Items {
+		if len(item.Errors) == 0 {
+			events = append(events, item.Event)
+			continue
+		}
+		result.Skipped = append(result.Skipped, item)
+		for _, fe := range item.Errors {
+			verr.Add(fmt.Sprintf("rows[%d].%s", item.Row, fe.Field), fe.Message)
+		}
+	}
+	if !skipInvalid {
+		if err := verr.ErrOrNil(); err != nil {
+			return nil, err
+		}
+	}
+
+	err = i.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		for _, e := range events {
+			e.ID = ""
+			if err := i.eventRepo.Create(ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Created = append(result.Created, events...)
+	return result, nil
+}
+
+func hasFieldError(errs []domain.FieldError, field string) bool {
+	for _, fe := range errs {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
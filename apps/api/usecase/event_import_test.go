@@ -0,0 +1,57 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+func TestPreviewImportMatchesOnlyVerifiedEmails(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2", "u3", "u4")
+	for _, u := range []*domain.User{
+		{ID: "u1", Name: "山田 太郎", Email: "taro@example.com", EmailVerified: true},
+		{ID: "u2", Name: "佐藤 花子", Email: "hanako@example.com"},
+		{ID: "u3", Name: "鈴木 一郎", Email: "shared@example.com", EmailVerified: true},
+		{ID: "u4", Name: "鈴木 次郎", Email: "Shared@example.com", EmailVerified: true},
+	} {
+		if err := f.repos.User.Create(f.ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		target  string
+		want    string // user ID, or a part of the error
+		invalid bool
+	}{
+		{"TARO@example.com", "u1", false},
+		{"hanako@example.com", "no member has verified email", true},
+		{"shared@example.com", "2 members have email", true},
+		{"佐藤花子", "u2", false},
+	}
+	startAt := time.Now().Add(48 * time.Hour)
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rows := []*usecase.EventImportRow{{Row: 1, Title: "合宿", StartAt: startAt, Targets: []string{tt.target}}}
+			preview, err := f.events().PreviewImport(f.ctx, circleID, rows, "admin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			item := preview.Items[0]
+			if tt.invalid {
+				if len(item.Errors) != 1 || !strings.Contains(item.Errors[0].Message, tt.want) {
+					t.Errorf("errors = %+v, want %q", item.Errors, tt.want)
+				}
+				return
+			}
+			if len(item.Errors) != 0 {
+				t.Fatalf("unexpected errors %+v", item.Errors)
+			}
+			wantUsers(t, item.Event.RSVPTargetUserIDs, []string{tt.want})
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+func TestCreateEventNotifiesTargetsButNotCreator(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	f.event(circleID, "admin", time.Now().Add(48*time.Hour), 0, time.Time{})
+
+	sent := f.notifier.titled("新しいイベント")
+	if len(sent) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(sent))
+	}
+	wantUsers(t, sent[0].UserIDs, []string{"u1", "u2"})
+}
+
+func TestCreateEventValidates(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "member")
+	startAt := time.Now().Add(48 * time.Hour)
+
+	_, err := f.events().CreateEvent(f.ctx, circleID, "合宿", startAt, "", "", nil, 0, time.Time{}, "member")
+	wantErr(t, err, domain.ErrForbidden)
+	_, err = f.events().CreateEvent(f.ctx, circleID, "合宿", startAt, "", "", []string{"member", "stranger"}, 0, time.Time{}, "admin")
+	wantErr(t, err, domain.ErrInvalidInput)
+	_, err = f.events().CreateEvent(f.ctx, circleID, "合宿", startAt, "", "", nil, 0, startAt.Add(time.Hour), "admin")
+	wantErr(t, err, domain.ErrInvalidInput)
+}
+
+func TestGetEventHidesEventFromNonMembers(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "member")
+	e := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 0, time.Time{})
+
+	if _, err := f.events().GetEvent(f.ctx, e.ID, "member"); err != nil {
+		t.Errorf("member: %v", err)
+	}
+	_, err := f.events().GetEvent(f.ctx, e.ID, "stranger")
+	wantErr(t, err, domain.ErrNotFound)
+}
+
+func TestUpdateEventNotifiesChangesAndPromotes(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	startAt := time.Now().Add(48 * time.Hour)
+	e := f.event(circleID, "admin", startAt, 1, time.Time{})
+	f.rsvp(e.ID, "u1", domain.RSVPGo)
+	if r := f.rsvp(e.ID, "u2", domain.RSVPGo); !r.Waitlisted {
+		t.Fatal("u2 was not waitlisted")
+	}
+
+	// Only the capacity changes: u2 gets a seat, nobody hears of a change.
+	_, err := f.events().UpdateEvent(f.ctx, e.ID, e.Title, startAt, e.Location, "", nil, 2, time.Time{}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := f.repos.RSVP.GetByEventAndUser(f.ctx, e.ID, "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Waitlisted {
+		t.Error("u2 is still waitlisted after the capacity was raised")
+	}
+	if n := len(f.notifier.titled("参加確定のお知らせ")); n != 1 {
+		t.Errorf("got %d promotion notifications, want 1", n)
+	}
+	if n := len(f.notifier.titled("イベント変更のお知らせ")); n != 0 {
+		t.Errorf("got %d change notifications, want none", n)
+	}
+
+	_, err = f.events().UpdateEvent(f.ctx, e.ID, e.Title, startAt.Add(time.Hour), "体育館", "", nil, 2, time.Time{}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.notifier.titled("イベント変更のお知らせ")); n != 1 {
+		t.Errorf("got %d change notifications, want 1", n)
+	}
+}
+
+func TestDeleteEventRequiresAdmin(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "member")
+	e := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 0, time.Time{})
+
+	wantErr(t, f.events().DeleteEvent(f.ctx, e.ID, "member"), domain.ErrForbidden)
+	if err := f.events().DeleteEvent(f.ctx, e.ID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.events().GetEvent(f.ctx, e.ID, "admin")
+	wantErr(t, err, domain.ErrNotFound)
+	if n := len(f.notifier.titled("イベント中止のお知らせ")); n != 1 {
+		t.Errorf("got %d cancellation notifications, want 1", n)
+	}
+}
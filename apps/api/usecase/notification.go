@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// NotificationSettings are a user's notification preferences together with
+// what the server offers.
+type NotificationSettings struct {
+	*domain.NotificationPreferences
+	AvailableChannels []domain.NotificationChannel `json:"availableChannels"`
+	VAPIDPublicKey    string                       `json:"vapidPublicKey,omitempty"` // for subscribing to Web Push
+}
+
+func (i *UserInteractor) settings(u *domain.User) *NotificationSettings {
+	prefs := u.Notifications
+	if prefs == nil {
+		prefs = &domain.NotificationPreferences{}
+	}
+	if prefs.Channels == nil {
+		prefs.Channels = []domain.NotificationChannel{}
+	}
+	if prefs.PushSubscriptions == nil {
+		prefs.PushSubscriptions = []domain.PushSubscription{}
+	}
+	return &NotificationSettings{
+		NotificationPreferences: prefs,
+		AvailableChannels:       i.channels,
+		VAPIDPublicKey:          i.vapidPublicKey,
+	}
+}
+
+// GetNotificationSettings returns the caller's notification preferences.
+func (i *UserInteractor) GetNotificationSettings(ctx context.Context, userID string) (*NotificationSettings, error) {
+	u, err := i.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return i.settings(u), nil
+}
+
+// UpdateNotificationPreferences sets the channels the caller is notified on
+// and their addresses. Each channel must be offered by the server and set
+// up: email needs a verified email address in the profile, LINE a LINE user ID and
+// webhooks an https URL. Push subscriptions are kept.
+func (i *UserInteractor) UpdateNotificationPreferences(ctx context.Context, userID string, channels []domain.NotificationChannel, lineUserID, webhookURL string) (*NotificationSettings, error) {
+	u, err := i.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	verr := &domain.ValidationError{}
+	enabled := []domain.NotificationChannel{}
+	for _, ch := range channels {
+		if !slices.Contains(i.channels, ch) {
+			verr.Add("channels", fmt.Sprintf("%s is not available", ch))
+			continue
+		}
+		if !slices.Contains(enabled, ch) {
+			enabled = append(enabled, ch)
+		}
+	}
+	if slices.Contains(enabled, domain.ChannelEmail) && u.VerifiedEmail() == "" {
+		verr.Add("channels", "EMAIL requires a verified email address in the profile")
+	}
+	if slices.Contains(enabled, domain.ChannelLINE) && lineUserID == "" {
+		verr.Add("lineUserId", "is required for LINE")
+	}
+	if slices.Contains(enabled, domain.ChannelWebhook) && webhookURL == "" {
+		verr.Add("webhookUrl", "is required for WEBHOOK")
+	}
+	if webhookURL != "" && !isPublicHTTPS(webhookURL) {
+		verr.Add("webhookUrl", "must be an https URL with a public host name")
+	}
+	if err := verr.ErrOrNil(); err != nil {
+		return nil, err
+	}
+
+	prefs := &domain.NotificationPreferences{}
+	if u.Notifications != nil {
+		prefs.PushSubscriptions = u.Notifications.PushSubscriptions
+	}
+	prefs.Channels = enabled
+	prefs.LINEUserID = lineUserID
+	prefs.WebhookURL = webhookURL
+	u.Notifications = prefs
+	if err := i.repo.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return i.settings(u), nil
+}
+
+// AddPushSubscription registers a browser of the caller for Web Push,
+// replacing an earlier subscription with the same endpoint. Beyond
+// domain.MaxPushSubscriptions, the oldest subscription is dropped.
+func (i *UserInteractor) AddPushSubscription(ctx context.Context, userID string, sub domain.PushSubscription) (*NotificationSettings, error) {
+	if !slices.Contains(i.channels, domain.ChannelWebPush) {
+		return nil, fmt.Errorf("%w: Web Push is not available", domain.ErrPreconditionFailed)
+	}
+	if !isPublicHTTPS(sub.Endpoint) {
+		return nil, domain.NewValidationError("endpoint", "must be an https URL with a public host name")
+	}
+	u, err := i.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Notifications == nil {
+		u.Notifications = &domain.NotificationPreferences{Channels: []domain.NotificationChannel{}}
+	}
+
+	sub.CreatedAt = time.Now()
+	subs := slices.DeleteFunc(u.Notifications.PushSubscriptions, func(s domain.PushSubscription) bool {
+		return s.Endpoint == sub.Endpoint
+	})
+	subs = append(subs, sub)
+	if len(subs) > domain.MaxPushSubscriptions {
+		subs = subs[len(subs)-domain.MaxPushSubscriptions:]
+	}
+	u.Notifications.PushSubscriptions = subs
+	if err := i.repo.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return i.settings(u), nil
+}
+
+// RemovePushSubscription unregisters a browser of the caller from Web Push.
+// Removing an unknown endpoint is not an error.
+func (i *UserInteractor) RemovePushSubscription(ctx context.Context, userID, endpoint string) (*NotificationSettings, error) {
+	u, err := i.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Notifications == nil {
+		return i.settings(u), nil
+	}
+	u.Notifications.PushSubscriptions = slices.DeleteFunc(u.Notifications.PushSubscriptions, func(s domain.PushSubscription) bool {
+		return s.Endpoint == endpoint
+	})
+	if err := i.repo.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return i.settings(u), nil
+}
+
+// isPublicHTTPS reports whether raw is an https URL whose host is a name
+// other than localhost. IP literals are refused outright; names resolving to
+// internal addresses are refused by the notification channels when sending.
+func isPublicHTTPS(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	_, err = netip.ParseAddr(host)
+	return err != nil
+}
+
+// without returns ids except id, such as everyone but the admin who caused
+// a notification.
+func without(ids []string, id string) []string {
+	out := make([]string, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// truncate shortens text for the body of a notification.
+func truncate(text string, maxRunes int) string {
+	r := []rune(text)
+	if len(r) <= maxRunes {
+		return text
+	}
+	return string(r[:maxRunes-1]) + "…"
+}
@@ -0,0 +1,61 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+func TestNotificationURLsMustBePublicHTTPS(t *testing.T) {
+	f := newFixture(t)
+	if err := f.repos.User.Create(f.ctx, &domain.User{ID: "u1", Name: "山田"}); err != nil {
+		t.Fatal(err)
+	}
+	users := usecase.NewUserInteractor(f.repos.User, []domain.NotificationChannel{domain.ChannelWebhook, domain.ChannelWebPush}, "key")
+	webhook := []domain.NotificationChannel{domain.ChannelWebhook}
+
+	for _, raw := range []string{
+		"http://hooks.example.com/x",
+		"https://localhost/x",
+		"https://api.localhost./x",
+		"https://127.0.0.1/x",
+		"https://[::1]:8443/x",
+		"https://169.254.169.254/latest/meta-data",
+		"https://10.0.0.5/x",
+		"https:///x",
+	} {
+		_, err := users.UpdateNotificationPreferences(f.ctx, "u1", webhook, "", raw)
+		wantErr(t, err, domain.ErrInvalidInput)
+		_, err = users.AddPushSubscription(f.ctx, "u1", domain.PushSubscription{Endpoint: raw, P256dh: "k", Auth: "a"})
+		wantErr(t, err, domain.ErrInvalidInput)
+	}
+	if _, err := users.UpdateNotificationPreferences(f.ctx, "u1", webhook, "", "https://hooks.example.com/x"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmailNotificationsNeedAVerifiedAddress(t *testing.T) {
+	f := newFixture(t)
+	users := usecase.NewUserInteractor(f.repos.User, []domain.NotificationChannel{domain.ChannelEmail}, "")
+	email := []domain.NotificationChannel{domain.ChannelEmail}
+
+	// Anyone can type someone else's address into their profile.
+	u, err := users.UpdateUser(f.ctx, "u1", "山田", "victim@example.com", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = users.UpdateNotificationPreferences(f.ctx, "u1", email, "", "")
+	wantErr(t, err, domain.ErrInvalidInput)
+	u.Notifications = &domain.NotificationPreferences{Channels: email}
+	if u.Reachable(domain.ChannelEmail) {
+		t.Error("an unverified address is reachable by email")
+	}
+
+	if _, err := users.UpdateUser(f.ctx, "u1", "", "me@example.com", "", "me@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.UpdateNotificationPreferences(f.ctx, "u1", email, "", ""); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,6 +4,7 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"github.com/noa/circle-app/api/domain"
 )
@@ -19,6 +20,8 @@ type UserRepository interface {
 type CircleRepository interface {
 	Create(ctx context.Context, c *domain.Circle) error
 	GetByID(ctx context.Context, id string) (*domain.Circle, error)
+	List(ctx context.Context) ([]*domain.Circle, error)
+	Update(ctx context.Context, c *domain.Circle) error
 }
 
 // MembershipRepository defines membership data access interface.
@@ -26,6 +29,7 @@ type MembershipRepository interface {
 	Create(ctx context.Context, m *domain.Membership) error
 	GetByCircle(ctx context.Context, circleID string) ([]*domain.Membership, error)
 	GetByCircleAndUser(ctx context.Context, circleID, userID string) (*domain.Membership, error)
+	Update(ctx context.Context, m *domain.Membership) error
 }
 
 // EventRepository defines event data access interface.
@@ -56,19 +60,33 @@ type RSVPRepository interface {
 
 // SettlementRepository defines settlement data access interface.
 type SettlementRepository interface {
+	// Create assigns a new ID unless s.ID is set, in which case it fails with
+	// domain.ErrConflict if a settlement with that ID already exists.
 	Create(ctx context.Context, s *domain.Settlement) error
 	GetByID(ctx context.Context, id string) (*domain.Settlement, error)
 	GetByEvent(ctx context.Context, eventID string) ([]*domain.Settlement, error)
+	GetByCircle(ctx context.Context, circleID string) ([]*domain.Settlement, error)
 	Update(ctx context.Context, s *domain.Settlement) error
+	Delete(ctx context.Context, id string) error
 }
 
 // PaymentRepository defines payment data access interface.
 type PaymentRepository interface {
+	// Create fails with domain.ErrConflict if the user already has a payment for the settlement.
 	Create(ctx context.Context, p *domain.Payment) error
 	GetBySettlementAndUser(ctx context.Context, settlementID, userID string) (*domain.Payment, error)
+	GetBySettlement(ctx context.Context, settlementID string) ([]*domain.Payment, error)
 	GetByUser(ctx context.Context, userID string) ([]*domain.Payment, error)
 	Update(ctx context.Context, p *domain.Payment) error
-	DeleteBySettlementAndUser(ctx context.Context, settlementID, userID string) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Transactor runs a unit of work atomically across repositories.
+// Repository calls made with the context passed to fn take part in the
+// transaction; if fn returns an error, none of its writes are applied.
+// fn may be retried, so it must not have side effects outside repositories.
+type Transactor interface {
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // AIService defines AI chat service interface.
@@ -76,9 +94,22 @@ type AIService interface {
 	GenerateResponse(ctx context.Context, message string, announcements []*domain.Announcement, events []*domain.Event) (*domain.ChatResponse, error)
 }
 
+// Identity is who a verified bearer token identifies.
+type Identity struct {
+	UserID string
+	// Email is the address the identity provider verified, or "" if it did not.
+	Email string
+}
+
+// TokenVerifier verifies a bearer token and returns the identity it carries.
+type TokenVerifier interface {
+	VerifyToken(ctx context.Context, token string) (*Identity, error)
+}
+
 // PracticeCategoryRepository defines practice category data access interface.
 type PracticeCategoryRepository interface {
 	Create(ctx context.Context, c *domain.PracticeCategory) error
+	GetByID(ctx context.Context, id string) (*domain.PracticeCategory, error)
 	GetByCircle(ctx context.Context, circleID string) ([]*domain.PracticeCategory, error)
 	Update(ctx context.Context, c *domain.PracticeCategory) error
 	Delete(ctx context.Context, id string) error
@@ -90,12 +121,15 @@ type PracticeSeriesRepository interface {
 	GetByID(ctx context.Context, id string) (*domain.PracticeSeries, error)
 	GetByCircle(ctx context.Context, circleID string) ([]*domain.PracticeSeries, error)
 	GetByCategory(ctx context.Context, categoryID string) ([]*domain.PracticeSeries, error)
+	List(ctx context.Context) ([]*domain.PracticeSeries, error)
 	Update(ctx context.Context, s *domain.PracticeSeries) error
 	Delete(ctx context.Context, id string) error
 }
 
 // PracticeSessionRepository defines practice session data access interface.
 type PracticeSessionRepository interface {
+	// Create assigns a new ID unless s.ID is set, in which case it fails with
+	// domain.ErrConflict if a session with that ID already exists.
 	Create(ctx context.Context, s *domain.PracticeSession) error
 	GetByID(ctx context.Context, id string) (*domain.PracticeSession, error)
 	GetBySeries(ctx context.Context, seriesID string) ([]*domain.PracticeSession, error)
@@ -109,3 +143,49 @@ type PracticeRSVPRepository interface {
 	GetBySession(ctx context.Context, sessionID string) ([]*domain.PracticeRSVP, error)
 	GetBySeriesAndUser(ctx context.Context, seriesID, userID string) ([]*domain.PracticeRSVP, error)
 }
+
+// CalendarExceptionRepository defines calendar exception data access interface.
+type CalendarExceptionRepository interface {
+	Create(ctx context.Context, e *domain.CalendarException) error
+	GetByID(ctx context.Context, id string) (*domain.CalendarException, error)
+	GetByCircle(ctx context.Context, circleID string) ([]*domain.CalendarException, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// AuditLogRepository defines audit log data access interface.
+type AuditLogRepository interface {
+	Create(ctx context.Context, e *domain.AuditEntry) error
+	GetByCircle(ctx context.Context, circleID string) ([]*domain.AuditEntry, error)
+}
+
+// ReminderPolicyRepository defines reminder policy data access interface.
+type ReminderPolicyRepository interface {
+	// Get returns nil without error when the circle has no policy.
+	Get(ctx context.Context, circleID string) (*domain.ReminderPolicy, error)
+	Upsert(ctx context.Context, p *domain.ReminderPolicy) error
+}
+
+// ReminderJobRepository defines reminder job data access interface.
+type ReminderJobRepository interface {
+	// Create fails with domain.ErrConflict if a job with the same ID exists.
+	Create(ctx context.Context, j *domain.ReminderJob) error
+	GetByID(ctx context.Context, id string) (*domain.ReminderJob, error)
+	GetByCircle(ctx context.Context, circleID string) ([]*domain.ReminderJob, error)
+	GetPending(ctx context.Context) ([]*domain.ReminderJob, error)
+	Update(ctx context.Context, j *domain.ReminderJob) error
+}
+
+// Clock tells the current time, so that scheduled work can be tested with a fake clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// HolidayCalendar provides national holidays.
+type HolidayCalendar interface {
+	Holidays(ctx context.Context, year int) ([]domain.Holiday, error)
+}
+
+// Notifier delivers notifications to users.
+type Notifier interface {
+	Notify(ctx context.Context, n *domain.Notification) error
+}
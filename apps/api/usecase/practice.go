@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/noa/circle-app/api/domain"
@@ -15,7 +17,15 @@ type PracticeUseCase struct {
 	seriesRepo     port.PracticeSeriesRepository
 	sessionRepo    port.PracticeSessionRepository
 	rsvpRepo       port.PracticeRSVPRepository
-	settlementRepo port.SettlementRepository // Added
+	settlementRepo port.SettlementRepository
+	paymentRepo    port.PaymentRepository
+	circleRepo     port.CircleRepository
+	exceptionRepo  port.CalendarExceptionRepository
+	auditRepo      port.AuditLogRepository
+	tx             port.Transactor
+	holidays       port.HolidayCalendar
+	notifier       port.Notifier
+	authz          *Authorizer
 }
 
 // NewPracticeUseCase creates a new PracticeUseCase.
@@ -24,7 +34,15 @@ func NewPracticeUseCase(
 	seriesRepo port.PracticeSeriesRepository,
 	sessionRepo port.PracticeSessionRepository,
 	rsvpRepo port.PracticeRSVPRepository,
-	settlementRepo port.SettlementRepository, // Added param
+	settlementRepo port.SettlementRepository,
+	paymentRepo port.PaymentRepository,
+	circleRepo port.CircleRepository,
+	exceptionRepo port.CalendarExceptionRepository,
+	auditRepo port.AuditLogRepository,
+	tx port.Transactor,
+	holidays port.HolidayCalendar,
+	notifier port.Notifier,
+	authz *Authorizer,
 ) *PracticeUseCase {
 	return &PracticeUseCase{
 		categoryRepo:   categoryRepo,
@@ -32,176 +50,461 @@ func NewPracticeUseCase(
 		sessionRepo:    sessionRepo,
 		rsvpRepo:       rsvpRepo,
 		settlementRepo: settlementRepo,
+		paymentRepo:    paymentRepo,
+		circleRepo:     circleRepo,
+		exceptionRepo:  exceptionRepo,
+		auditRepo:      auditRepo,
+		tx:             tx,
+		holidays:       holidays,
+		notifier:       notifier,
+		authz:          authz,
 	}
 }
 
-// ... existing methods ...
-
-// CreateSettlements generates settlements for unpaid practice sessions in a given month.
-func (uc *PracticeUseCase) CreateSettlements(ctx context.Context, seriesID string, month string) error { // month: "2024-04"
-	// 1. Get Series
+// requireSeriesAdmin loads a series and checks that actorID administers its circle.
+func (uc *PracticeUseCase) requireSeriesAdmin(ctx context.Context, seriesID, actorID string) (*domain.PracticeSeries, error) {
 	series, err := uc.seriesRepo.GetByID(ctx, seriesID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if series.Fee == 0 {
-		return nil // No fee, no settlement needed
+	if err := uc.authz.RequireAdmin(ctx, series.CircleID, actorID); err != nil {
+		return nil, err
 	}
+	return series, nil
+}
 
-	// 2. Get Sessions for the Series
-	sessions, err := uc.sessionRepo.GetBySeries(ctx, seriesID)
+// requireSeriesReader loads a series and checks that userID can read its circle.
+func (uc *PracticeUseCase) requireSeriesReader(ctx context.Context, seriesID, userID string) (*domain.PracticeSeries, error) {
+	series, err := uc.seriesRepo.GetByID(ctx, seriesID)
 	if err != nil {
-		return err
-	}
-
-	// Filter sessions by month
-	var targetSessions []*domain.PracticeSession
-	for _, s := range sessions {
-		if s.Cancelled {
-			continue
-		}
-		// Format "YYYY-MM"
-		sMonth := s.Date.Format("2006-01")
-		if sMonth == month {
-			targetSessions = append(targetSessions, s)
-		}
+		return nil, err
 	}
-	if len(targetSessions) == 0 {
-		return nil
+	if err := uc.authz.RequireReadAccess(ctx, series.CircleID, userID); err != nil {
+		return nil, err
 	}
+	return series, nil
+}
 
-	// 3. Get RSVPs for these sessions and aggregate by User
-	userCounts := make(map[string]int)
-	for _, s := range targetSessions {
-		rsvps, err := uc.rsvpRepo.GetBySession(ctx, s.ID)
-		if err != nil {
-			return err
-		}
-		for _, r := range rsvps {
-			if r.Status == domain.PracticeRSVPStatus(domain.PracticeRSVPGo) {
-				userCounts[r.UserID]++
-			}
-		}
+// requireSessionMember loads a session and checks that userID belongs to its circle.
+// Non-members get domain.ErrNotFound like on reads, since the session is addressed by ID.
+func (uc *PracticeUseCase) requireSessionMember(ctx context.Context, sessionID, userID string) (*domain.PracticeSession, error) {
+	session, err := uc.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
 	}
-
-	// 4. Create Settlement for each user
-	now := time.Now()
-	// simple due date: end of next month? or +2 weeks? Let's say +2 weeks
-	dueAt := now.AddDate(0, 0, 14)
-
-	for userID, count := range userCounts {
-		if count == 0 {
-			continue
-		}
-		amount := count * series.Fee
-		title := fmt.Sprintf("%s 参加費 (%s月度 %d回分)", series.Name, month, count)
-
-		settlement := &domain.Settlement{
-			CircleID:      series.CircleID,
-			Title:         title,
-			Amount:        amount,
-			DueAt:         dueAt,
-			TargetUserIDs: []string{userID},
-			BankInfo:      "", // TODO: Get from Circle settings? Or empty is fine
-			PayPayInfo:    "",
-			CreatedAt:     now,
-		}
-		if err := uc.settlementRepo.Create(ctx, settlement); err != nil {
-			return err
-		}
+	if _, err := uc.requireSeriesReader(ctx, session.SeriesID, userID); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return session, nil
 }
 
+// ... existing methods ...
+
 // --- Category ---
 
+// CreateCategory creates a category. Only circle admins can manage categories.
 func (uc *PracticeUseCase) CreateCategory(ctx context.Context, c *domain.PracticeCategory) error {
+	if err := uc.authz.RequireAdmin(ctx, c.CircleID, c.CreatedBy); err != nil {
+		return err
+	}
 	return uc.categoryRepo.Create(ctx, c)
 }
 
-func (uc *PracticeUseCase) GetCategories(ctx context.Context, circleID string) ([]*domain.PracticeCategory, error) {
+func (uc *PracticeUseCase) GetCategories(ctx context.Context, circleID, userID string) ([]*domain.PracticeCategory, error) {
+	if err := uc.authz.RequireReadAccess(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
 	return uc.categoryRepo.GetByCircle(ctx, circleID)
 }
 
-func (uc *PracticeUseCase) UpdateCategory(ctx context.Context, c *domain.PracticeCategory) error {
+func (uc *PracticeUseCase) UpdateCategory(ctx context.Context, c *domain.PracticeCategory, actorID string) error {
+	existing, err := uc.categoryRepo.GetByID(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	if err := uc.authz.RequireAdmin(ctx, existing.CircleID, actorID); err != nil {
+		return err
+	}
+	c.CircleID = existing.CircleID
 	return uc.categoryRepo.Update(ctx, c)
 }
 
-func (uc *PracticeUseCase) DeleteCategory(ctx context.Context, id string) error {
+func (uc *PracticeUseCase) DeleteCategory(ctx context.Context, id, actorID string) error {
+	category, err := uc.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.authz.RequireAdmin(ctx, category.CircleID, actorID); err != nil {
+		return err
+	}
 	return uc.categoryRepo.Delete(ctx, id)
 }
 
 // --- Series ---
 
+// CreateSeries creates a series. Only circle admins can manage practice series.
 func (uc *PracticeUseCase) CreateSeries(ctx context.Context, s *domain.PracticeSeries) error {
+	if err := uc.authz.RequireAdmin(ctx, s.CircleID, s.CreatedBy); err != nil {
+		return err
+	}
+	if s.StartDate == "" {
+		s.StartDate = time.Now().In(domain.Location).Format("2006-01-02")
+	}
+	if err := s.NormalizeSchedule(); err != nil {
+		return err
+	}
 	return uc.seriesRepo.Create(ctx, s)
 }
 
-func (uc *PracticeUseCase) GetSeries(ctx context.Context, id string) (*domain.PracticeSeries, error) {
-	return uc.seriesRepo.GetByID(ctx, id)
+func (uc *PracticeUseCase) GetSeries(ctx context.Context, id, userID string) (*domain.PracticeSeries, error) {
+	return uc.requireSeriesReader(ctx, id, userID)
 }
 
-func (uc *PracticeUseCase) GetSeriesByCircle(ctx context.Context, circleID string) ([]*domain.PracticeSeries, error) {
+func (uc *PracticeUseCase) GetSeriesByCircle(ctx context.Context, circleID, userID string) ([]*domain.PracticeSeries, error) {
+	if err := uc.authz.RequireReadAccess(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
 	return uc.seriesRepo.GetByCircle(ctx, circleID)
 }
 
-func (uc *PracticeUseCase) UpdateSeries(ctx context.Context, id string, req *domain.PracticeSeries) (*domain.PracticeSeries, error) {
-	series, err := uc.seriesRepo.GetByID(ctx, id)
+func (uc *PracticeUseCase) UpdateSeries(ctx context.Context, id string, req *domain.PracticeSeries, actorID string) (*domain.PracticeSeries, error) {
+	series, err := uc.requireSeriesAdmin(ctx, id, actorID)
 	if err != nil {
 		return nil, err
 	}
 	series.Name = req.Name
 	series.DayOfWeek = req.DayOfWeek
 	series.StartTime = req.StartTime
+	series.DurationMinutes = req.DurationMinutes
+	series.Recurrence = req.Recurrence
+	if req.StartDate != "" {
+		series.StartDate = req.StartDate
+	}
+	series.ExDates = req.ExDates
 	series.Location = req.Location
 	series.Fee = req.Fee
 	// Do not update CircleID, CategoryID, CreatedBy?
+	if err := series.NormalizeSchedule(); err != nil {
+		return nil, err
+	}
 	if err := uc.seriesRepo.Update(ctx, series); err != nil {
 		return nil, err
 	}
 	return series, nil
 }
 
-func (uc *PracticeUseCase) DeleteSeries(ctx context.Context, id string) error {
+func (uc *PracticeUseCase) DeleteSeries(ctx context.Context, id, actorID string) error {
+	if _, err := uc.requireSeriesAdmin(ctx, id, actorID); err != nil {
+		return err
+	}
 	return uc.seriesRepo.Delete(ctx, id)
 }
 
 // --- Session ---
 
-func (uc *PracticeUseCase) CreateSession(ctx context.Context, s *domain.PracticeSession) error {
+// CreateSession creates a session. Only circle admins can manage sessions.
+func (uc *PracticeUseCase) CreateSession(ctx context.Context, s *domain.PracticeSession, actorID string) error {
+	if _, err := uc.requireSeriesAdmin(ctx, s.SeriesID, actorID); err != nil {
+		return err
+	}
 	return uc.sessionRepo.Create(ctx, s)
 }
 
-func (uc *PracticeUseCase) GetSessionsBySeries(ctx context.Context, seriesID string) ([]*domain.PracticeSession, error) {
+func (uc *PracticeUseCase) GetSessionsBySeries(ctx context.Context, seriesID, userID string) ([]*domain.PracticeSession, error) {
+	if _, err := uc.requireSeriesReader(ctx, seriesID, userID); err != nil {
+		return nil, err
+	}
 	return uc.sessionRepo.GetBySeries(ctx, seriesID)
 }
 
-func (uc *PracticeUseCase) UpdateSession(ctx context.Context, s *domain.PracticeSession) error {
-	return uc.sessionRepo.Update(ctx, s)
+// UpdateSession updates the date, end time, RSVP deadline, note and
+// cancellation of a session without notifying anyone; see CancelSession and RescheduleSession.
+// Only circle admins can update sessions.
+func (uc *PracticeUseCase) UpdateSession(ctx context.Context, s *domain.PracticeSession, actorID string) (*domain.PracticeSession, error) {
+	existing, err := uc.sessionRepo.GetByID(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uc.requireSeriesAdmin(ctx, existing.SeriesID, actorID); err != nil {
+		return nil, err
+	}
+	if err := existing.Move(s.Date, s.EndAt); err != nil {
+		return nil, err
+	}
+	if !s.RSVPDeadline.IsZero() && s.RSVPDeadline.After(existing.Date) {
+		return nil, domain.NewValidationError("rsvpDeadline", "must not be after date")
+	}
+	existing.RSVPDeadline = s.RSVPDeadline
+	existing.Note = s.Note
+	if !s.Cancelled {
+		existing.CancelReason = ""
+		existing.ExceptionID = ""
+	}
+	existing.Cancelled = s.Cancelled
+	if err := uc.sessionRepo.Update(ctx, existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DefaultGenerationWeeks is how far ahead sessions are generated when no range is given.
+const DefaultGenerationWeeks = 8
+
+// MaxGenerationRange bounds how far ahead sessions can be generated at once.
+const MaxGenerationRange = 366 * 24 * time.Hour
+
+// GenerateSessions materializes the sessions of a series whose dates fall in
+// [from, to). Dates that already have a session, including cancelled ones,
+// are skipped, so calling it repeatedly is safe. It returns the new sessions.
+// Only circle admins can generate sessions.
+func (uc *PracticeUseCase) GenerateSessions(ctx context.Context, seriesID string, from, to time.Time, actorID string) ([]*domain.PracticeSession, error) {
+	series, err := uc.requireSeriesAdmin(ctx, seriesID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.generateSessions(ctx, series, from, to)
+}
+
+// GenerateUpcomingSessions generates the sessions of every series for the
+// given number of weeks starting today. It is meant for scheduled jobs and
+// performs no authorization. A failing series does not stop the others.
+func (uc *PracticeUseCase) GenerateUpcomingSessions(ctx context.Context, now time.Time, weeks int) (int, error) {
+	seriesList, err := uc.seriesRepo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	from := domain.StartOfDay(now)
+	to := from.AddDate(0, 0, 7*weeks)
+	created := 0
+	var errs []error
+	for _, series := range seriesList {
+		sessions, err := uc.generateSessions(ctx, series, from, to)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("series %s: %w", series.ID, err))
+			continue
+		}
+		created += len(sessions)
+	}
+	return created, errors.Join(errs...)
+}
+
+func (uc *PracticeUseCase) generateSessions(ctx context.Context, series *domain.PracticeSeries, from, to time.Time) ([]*domain.PracticeSession, error) {
+	if !to.After(from) {
+		return nil, domain.NewValidationError("to", "must be after from")
+	}
+	if to.Sub(from) > MaxGenerationRange {
+		return nil, domain.NewValidationError("to", "range must not exceed one year")
+	}
+
+	occurrences, err := series.Occurrences(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := uc.sessionRepo.GetBySeries(ctx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, s := range existing {
+		taken[domain.DateKey(s.Date)] = true
+		if !s.OriginalDate.IsZero() {
+			taken[domain.DateKey(s.OriginalDate)] = true
+		}
+	}
+	exceptions, err := uc.exceptionRepo.GetByCircle(ctx, series.CircleID)
+	if err != nil {
+		return nil, err
+	}
+
+	created := []*domain.PracticeSession{}
+	for _, start := range occurrences {
+		if taken[domain.DateKey(start)] {
+			continue
+		}
+		session := &domain.PracticeSession{
+			ID:       domain.PracticeSessionID(series.ID, start),
+			SeriesID: series.ID,
+			Date:     start,
+		}
+		if d := series.Duration(); d > 0 {
+			session.EndAt = start.Add(d)
+		}
+		if e := exceptionOn(exceptions, start); e != nil {
+			if e.Action == domain.ExceptionSkip {
+				continue
+			}
+			session.Cancelled = true
+			session.CancelReason = e.Title
+			session.ExceptionID = e.ID
+		}
+		err := uc.sessionRepo.Create(ctx, session)
+		if errors.Is(err, domain.ErrConflict) {
+			continue // generated concurrently by another run
+		}
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, session)
+	}
+	return created, nil
 }
 
 // --- RSVP ---
 
+// SubmitRSVP submits the user's own RSVP. Any member of the circle can respond
+// until the session's RSVP deadline; admins can still change their RSVP
+// afterwards, which is recorded in the audit log.
 func (uc *PracticeUseCase) SubmitRSVP(ctx context.Context, r *domain.PracticeRSVP) error {
-	return uc.rsvpRepo.Upsert(ctx, r)
+	return uc.submitRSVP(ctx, r, "", r.UserID)
+}
+
+// OverrideRSVP sets the RSVP of a member on their behalf. Changes after the
+// RSVP deadline need a reason, which is recorded in the audit log. Only circle
+// admins can override RSVPs.
+func (uc *PracticeUseCase) OverrideRSVP(ctx context.Context, r *domain.PracticeRSVP, reason, actorID string) error {
+	return uc.submitRSVP(ctx, r, reason, actorID)
 }
 
-// BulkRSVP submits multiple RSVPs at once.
-func (uc *PracticeUseCase) BulkRSVP(ctx context.Context, rsvps []*domain.PracticeRSVP) error {
-	for _, r := range rsvps {
-		if err := uc.rsvpRepo.Upsert(ctx, r); err != nil {
+func (uc *PracticeUseCase) submitRSVP(ctx context.Context, r *domain.PracticeRSVP, reason, actorID string) error {
+	if !r.Status.Valid() {
+		return domain.NewValidationError("status", "must be one of GO, NO")
+	}
+	session, err := uc.sessionRepo.GetByID(ctx, r.SessionID)
+	if err != nil {
+		return err
+	}
+	series, closed, err := uc.checkRSVPOpen(ctx, session, r.UserID, actorID)
+	if err != nil {
+		return err
+	}
+	if closed && actorID != r.UserID && strings.TrimSpace(reason) == "" {
+		return domain.NewValidationError("reason", "is required after the RSVP deadline")
+	}
+	return uc.saveRSVPs(ctx, series.CircleID, []rsvpChange{{rsvp: r, closed: closed, reason: reason, actorID: actorID}})
+}
+
+// checkRSVPOpen loads the series of a session and checks that actorID may
+// change userID's RSVP for it. After the RSVP deadline only admins may, and
+// closed is true so that the change is audited.
+func (uc *PracticeUseCase) checkRSVPOpen(ctx context.Context, session *domain.PracticeSession, userID, actorID string) (series *domain.PracticeSeries, closed bool, err error) {
+	series, err = uc.requireSeriesReader(ctx, session.SeriesID, actorID)
+	if err != nil {
+		return nil, false, err
+	}
+	actor, err := uc.authz.RequireMember(ctx, series.CircleID, actorID)
+	if err != nil {
+		return nil, false, err
+	}
+	isAdmin := actor.Role == domain.RoleAdmin
+	if userID != actorID {
+		if !isAdmin {
+			return nil, false, domain.ErrForbidden
+		}
+		if err := uc.authz.RequireReadAccess(ctx, series.CircleID, userID); err != nil {
+			return nil, false, err
+		}
+	}
+	closed = !time.Now().Before(session.RSVPClosesAt())
+	if closed && !isAdmin {
+		return nil, false, fmt.Errorf("%w: RSVPs closed at %s", domain.ErrPreconditionFailed,
+			session.RSVPClosesAt().In(domain.Location).Format("2006-01-02 15:04"))
+	}
+	return series, closed, nil
+}
+
+// rsvpChange is a practice RSVP to save. Changes made after the RSVP
+// deadline are closed and recorded in the audit log.
+type rsvpChange struct {
+	rsvp    *domain.PracticeRSVP
+	closed  bool
+	reason  string
+	actorID string
+}
+
+// saveRSVPs saves RSVPs of a circle's sessions and their audit entries in one
+// transaction, so either all of them are saved or none.
+func (uc *PracticeUseCase) saveRSVPs(ctx context.Context, circleID string, changes []rsvpChange) error {
+	return uc.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		// Read the previous statuses before the first write.
+		before := make([]string, len(changes))
+		for idx, c := range changes {
+			if !c.closed {
+				continue
+			}
+			prev, err := uc.rsvpRepo.GetBySessionAndUser(ctx, c.rsvp.SessionID, c.rsvp.UserID)
+			if err != nil {
+				return err
+			}
+			if prev != nil {
+				before[idx] = string(prev.Status)
+			}
+		}
+		for idx, c := range changes {
+			if err := uc.rsvpRepo.Upsert(ctx, c.rsvp); err != nil {
+				return err
+			}
+			if !c.closed {
+				continue
+			}
+			err := uc.auditRepo.Create(ctx, &domain.AuditEntry{
+				CircleID:   circleID,
+				Action:     domain.AuditRSVPOverride,
+				TargetType: domain.AuditTargetPracticeSession,
+				TargetID:   c.rsvp.SessionID,
+				UserID:     c.rsvp.UserID,
+				Before:     before[idx],
+				After:      string(c.rsvp.Status),
+				Reason:     c.reason,
+				ActorID:    c.actorID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BulkRSVP submits multiple RSVPs for sessions of one series at once. Nothing
+// is saved if any session's RSVP deadline has passed, unless the user is an
+// admin, in which case those changes are audited.
+func (uc *PracticeUseCase) BulkRSVP(ctx context.Context, seriesID string, rsvps []*domain.PracticeRSVP) error {
+	changes := make([]rsvpChange, len(rsvps))
+	var circleID string
+	for idx, r := range rsvps {
+		if !r.Status.Valid() {
+			return domain.NewValidationError(fmt.Sprintf("rsvps[%d].status", idx), "must be one of GO, NO")
+		}
+		session, err := uc.sessionRepo.GetByID(ctx, r.SessionID)
+		if err != nil {
+			return err
+		}
+		if session.SeriesID != seriesID {
+			return domain.ErrInvalidInput
+		}
+		series, isClosed, err := uc.checkRSVPOpen(ctx, session, r.UserID, r.UserID)
+		if err != nil {
 			return err
 		}
+		circleID = series.CircleID
+		changes[idx] = rsvpChange{rsvp: r, closed: isClosed, actorID: r.UserID}
 	}
-	return nil
+	return uc.saveRSVPs(ctx, circleID, changes)
 }
 
 func (uc *PracticeUseCase) GetMyRSVPs(ctx context.Context, seriesID, userID string) ([]*domain.PracticeRSVP, error) {
+	if _, err := uc.requireSeriesReader(ctx, seriesID, userID); err != nil {
+		return nil, err
+	}
 	return uc.rsvpRepo.GetBySeriesAndUser(ctx, seriesID, userID)
 }
 
-func (uc *PracticeUseCase) GetSessionRSVPs(ctx context.Context, sessionID string) ([]*domain.PracticeRSVP, error) {
+func (uc *PracticeUseCase) GetSessionRSVPs(ctx context.Context, sessionID, userID string) ([]*domain.PracticeRSVP, error) {
+	if _, err := uc.requireSessionMember(ctx, sessionID, userID); err != nil {
+		return nil, err
+	}
 	return uc.rsvpRepo.GetBySession(ctx, sessionID)
 }
 
@@ -213,7 +516,7 @@ type SeriesDetail struct {
 }
 
 func (uc *PracticeUseCase) GetSeriesDetail(ctx context.Context, seriesID, userID string) (*SeriesDetail, error) {
-	series, err := uc.seriesRepo.GetByID(ctx, seriesID)
+	series, err := uc.requireSeriesReader(ctx, seriesID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,334 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// PracticeFeeDueDays is how long members have to pay a practice fee.
+const PracticeFeeDueDays = 14
+
+// PracticeBilling is the outcome of billing the practice fees of a series
+// for a month.
+type PracticeBilling struct {
+	Month     string `json:"month"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"` // unpaid bills whose attended sessions changed
+	Unchanged int    `json:"unchanged"`
+	Removed   int    `json:"removed"` // unpaid bills of members who no longer attended
+	// NeedsReview lists users whose bill no longer matches their attendance
+	// but was already paid or reported.
+	NeedsReview []string             `json:"needsReview"`
+	Settlements []*domain.Settlement `json:"settlements"` // the month's bills of the series
+}
+
+// billOutcome is what billing one member did.
+type billOutcome int
+
+const (
+	billUnchanged billOutcome = iota
+	billCreated
+	billUpdated
+	billRemoved
+	billNeedsReview
+)
+
+// CreateSettlements bills the practice fee of a series for a month. Every
+// member who answered GO to sessions of the month that were held gets a
+// settlement of fee × sessions with a payment to report, using the circle's
+// payment instructions.
+//
+// Billing a month again is safe: unchanged bills are kept, unpaid bills follow
+// changed attendance and are removed when nothing is attended any more, and
+// bills already paid or reported are left for an admin to review. Bills made
+// before billing was idempotent, which carry a random ID, are recognised by
+// their title and updated instead of billed twice. Only circle admins can
+// bill practice fees.
+func (uc *PracticeUseCase) CreateSettlements(ctx context.Context, seriesID, month, actorID string) (*PracticeBilling, error) {
+	series, err := uc.requireSeriesAdmin(ctx, seriesID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Fee == 0 {
+		return nil, fmt.Errorf("%w: series has no fee", domain.ErrPreconditionFailed)
+	}
+	circle, err := uc.circleRepo.GetByID(ctx, series.CircleID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := uc.attendedSessions(ctx, seriesID, month)
+	if err != nil {
+		return nil, err
+	}
+	settlements, err := uc.settlementRepo.GetByCircle(ctx, series.CircleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sessions already on a monthly invoice are not billed again.
+	billed := billedSessions(settlements, func(s *domain.Settlement) bool {
+		return s.SeriesID == seriesID && s.Month == month
+	})
+	attended := make(map[string][]string)
+	for userID, list := range sessions {
+		for _, session := range list {
+			if !billed[userID][session.ID] {
+				attended[userID] = append(attended[userID], session.ID)
+			}
+		}
+	}
+
+	// Members billed before who no longer attended are billed again as well,
+	// which removes or reviews their bill.
+	bills := existingPracticeBills(settlements, series, month)
+	userIDs := make([]string, 0, len(attended))
+	for userID := range attended {
+		userIDs = append(userIDs, userID)
+	}
+	for userID := range bills {
+		if _, ok := attended[userID]; !ok {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	sort.Strings(userIDs)
+
+	billing := &PracticeBilling{
+		Month:       month,
+		NeedsReview: []string{},
+		Settlements: []*domain.Settlement{},
+	}
+	dueAt := time.Now().AddDate(0, 0, PracticeFeeDueDays)
+	for _, userID := range userIDs {
+		id, ok := bills[userID]
+		if !ok {
+			id = domain.PracticeFeeSettlementID(series.ID, month, userID)
+		}
+		outcome, s, err := uc.billPracticeFee(ctx, series, circle, month, id, userID, attended[userID], dueAt)
+		if err != nil {
+			return nil, err
+		}
+		switch outcome {
+		case billCreated:
+			billing.Created++
+			notifySettlement(ctx, uc.notifier, s, s.AmountFor, actorID)
+		case billUpdated:
+			billing.Updated++
+		case billRemoved:
+			billing.Removed++
+		case billNeedsReview:
+			billing.NeedsReview = append(billing.NeedsReview, userID)
+		default:
+			billing.Unchanged++
+		}
+		if s != nil {
+			billing.Settlements = append(billing.Settlements, s)
+		}
+	}
+	return billing, nil
+}
+
+// attendedSessions returns the sessions of a month that were held, in date
+// order, by the users who answered GO.
+func (uc *PracticeUseCase) attendedSessions(ctx context.Context, seriesID, month string) (map[string][]*domain.PracticeSession, error) {
+	sessions, err := uc.sessionRepo.GetBySeries(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(sessions, func(a, b int) bool {
+		return sessions[a].Date.Before(sessions[b].Date)
+	})
+
+	attended := make(map[string][]*domain.PracticeSession)
+	for _, s := range sessions {
+		if s.Cancelled || s.Date.In(domain.Location).Format("2006-01") != month {
+			continue
+		}
+		rsvps, err := uc.rsvpRepo.GetBySession(ctx, s.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rsvps {
+			if r.Status == domain.PracticeRSVPGo {
+				attended[r.UserID] = append(attended[r.UserID], s)
+			}
+		}
+	}
+	return attended, nil
+}
+
+// existingPracticeBills returns the IDs of the practice fee bills of a series
+// for a month by user. Bills from before billing was idempotent have a random
+// ID and no series or month, so they are matched by their title, which named
+// the series and month.
+func existingPracticeBills(settlements []*domain.Settlement, series *domain.PracticeSeries, month string) map[string]string {
+	legacyTitle := fmt.Sprintf("%s 参加費 (%s月度 ", series.Name, month)
+	bills := make(map[string]string)
+	for _, s := range settlements {
+		if len(s.TargetUserIDs) != 1 || s.EventID != "" {
+			continue
+		}
+		userID := s.TargetUserIDs[0]
+		switch {
+		case s.SeriesID == series.ID && s.Month == month:
+			bills[userID] = s.ID
+		case s.SeriesID == "" && s.Month == "" && strings.HasPrefix(s.Title, legacyTitle):
+			if _, ok := bills[userID]; !ok {
+				bills[userID] = s.ID
+			}
+		}
+	}
+	return bills
+}
+
+// billedSessions returns the sessions each user has been billed for on
+// single-user settlements, leaving out those for which skip reports true.
+// A session credited back on a later invoice counts as not billed.
+func billedSessions(settlements []*domain.Settlement, skip func(*domain.Settlement) bool) map[string]map[string]bool {
+	count := make(map[string]map[string]int)
+	for _, s := range settlements {
+		if len(s.TargetUserIDs) != 1 || len(s.SessionIDs) == 0 || (skip != nil && skip(s)) {
+			continue
+		}
+		userID := s.TargetUserIDs[0]
+		if count[userID] == nil {
+			count[userID] = make(map[string]int)
+		}
+		for _, id := range s.SessionIDs {
+			count[userID][id]++
+		}
+		for _, item := range s.LineItems {
+			if item.SessionID != "" && item.Amount < 0 {
+				count[userID][item.SessionID]--
+			}
+		}
+	}
+	billed := make(map[string]map[string]bool)
+	for userID, sessions := range count {
+		billed[userID] = make(map[string]bool)
+		for id, n := range sessions {
+			billed[userID][id] = n > 0
+		}
+	}
+	return billed
+}
+
+// billPracticeFee creates, updates or removes the bill id of one member for
+// a month. It returns the bill, or nil if the member has none.
+func (uc *PracticeUseCase) billPracticeFee(ctx context.Context, series *domain.PracticeSeries, circle *domain.Circle, month, id, userID string, sessionIDs []string, dueAt time.Time) (billOutcome, *domain.Settlement, error) {
+	title := fmt.Sprintf("%s 参加費 (%s月度 %d回分)", series.Name, month, len(sessionIDs))
+	amount := series.Fee * len(sessionIDs)
+
+	var outcome billOutcome
+	var bill *domain.Settlement
+	err := uc.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		outcome, bill = billUnchanged, nil
+		s, err := uc.settlementRepo.GetByID(ctx, id)
+		if errors.Is(err, domain.ErrNotFound) {
+			if len(sessionIDs) == 0 {
+				return nil
+			}
+			s = &domain.Settlement{
+				ID:            id,
+				CircleID:      series.CircleID,
+				Title:         title,
+				Amount:        amount,
+				DueAt:         dueAt,
+				TargetUserIDs: []string{userID},
+				BankInfo:      circle.BankInfo,
+				PayPayInfo:    circle.PayPayInfo,
+				SeriesID:      series.ID,
+				Month:         month,
+				SessionIDs:    sessionIDs,
+			}
+			if err := uc.settlementRepo.Create(ctx, s); err != nil {
+				return err
+			}
+			payment := &domain.Payment{SettlementID: id, UserID: userID, Status: domain.PaymentUnpaid}
+			if err := uc.paymentRepo.Create(ctx, payment); err != nil {
+				return err
+			}
+			outcome, bill = billCreated, s
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		bill = s
+		if slices.Equal(s.SessionIDs, sessionIDs) {
+			return nil
+		}
+		payment, err := uc.paymentRepo.GetBySettlementAndUser(ctx, id, userID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		settled := payment != nil && payment.Status != domain.PaymentUnpaid
+		if s.SessionIDs == nil && s.Amount == amount && len(sessionIDs) > 0 {
+			// A bill from before sessions were recorded that already
+			// covers the attendance: only record what it is for, and
+			// give it the payment the member needs to see it.
+			s.SeriesID, s.Month, s.SessionIDs = series.ID, month, sessionIDs
+			fillPaymentInfo(s, circle)
+			if err := uc.settlementRepo.Update(ctx, s); err != nil {
+				return err
+			}
+			return uc.ensurePayment(ctx, payment, id, userID)
+		}
+		if settled {
+			outcome = billNeedsReview
+			return nil
+		}
+		if len(sessionIDs) == 0 {
+			// Nothing is owed any more, as when an event RSVP changes to NO.
+			if payment != nil {
+				if err := uc.paymentRepo.Delete(ctx, payment.ID); err != nil {
+					return err
+				}
+			}
+			outcome, bill = billRemoved, nil
+			return uc.settlementRepo.Delete(ctx, id)
+		}
+
+		s.Title = title
+		s.Amount = amount
+		s.SeriesID = series.ID
+		s.Month = month
+		s.SessionIDs = sessionIDs
+		fillPaymentInfo(s, circle)
+		if err := uc.settlementRepo.Update(ctx, s); err != nil {
+			return err
+		}
+		if err := uc.ensurePayment(ctx, payment, id, userID); err != nil {
+			return err
+		}
+		outcome = billUpdated
+		return nil
+	})
+	return outcome, bill, err
+}
+
+// ensurePayment creates the unpaid payment of a bill unless it has one.
+// Bills from before billing was idempotent were saved without payments.
+func (uc *PracticeUseCase) ensurePayment(ctx context.Context, payment *domain.Payment, settlementID, userID string) error {
+	if payment != nil {
+		return nil
+	}
+	payment = &domain.Payment{SettlementID: settlementID, UserID: userID, Status: domain.PaymentUnpaid}
+	return uc.paymentRepo.Create(ctx, payment)
+}
+
+// fillPaymentInfo gives a bill saved without payment instructions those of
+// its circle.
+func fillPaymentInfo(s *domain.Settlement, circle *domain.Circle) {
+	if s.BankInfo == "" && s.PayPayInfo == "" {
+		s.BankInfo, s.PayPayInfo = circle.BankInfo, circle.PayPayInfo
+	}
+}
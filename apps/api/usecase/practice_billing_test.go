@@ -0,0 +1,126 @@
+package usecase_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+// billMonth bills the practice fees of a series for the month of session.
+func (f *fixture) billMonth(series *domain.PracticeSeries, session *domain.PracticeSession) *usecase.PracticeBilling {
+	f.t.Helper()
+	month := session.Date.In(domain.Location).Format("2006-01")
+	billing, err := f.practice().CreateSettlements(f.ctx, series.ID, month, "admin")
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return billing
+}
+
+func TestPracticeBillingRemovesUnpaidBillWhenAttendanceDropsToZero(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	series, sessions := f.series(circleID, "admin", 500)
+	f.practiceRSVP(sessions[0].ID, "u1", domain.PracticeRSVPGo)
+	f.practiceRSVP(sessions[0].ID, "u2", domain.PracticeRSVPGo)
+	if b := f.billMonth(series, sessions[0]); b.Created != 2 {
+		t.Fatalf("first run: %+v", b)
+	}
+	month := sessions[0].Date.In(domain.Location).Format("2006-01")
+	reported, err := f.repos.Payment.GetBySettlementAndUser(f.ctx, domain.PracticeFeeSettlementID(series.ID, month, "u2"), "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reported.Status = domain.PaymentPaidReported
+	if err := f.repos.Payment.Update(f.ctx, reported); err != nil {
+		t.Fatal(err)
+	}
+
+	f.practiceRSVP(sessions[0].ID, "u1", domain.PracticeRSVPNo)
+	f.practiceRSVP(sessions[0].ID, "u2", domain.PracticeRSVPNo)
+	b := f.billMonth(series, sessions[0])
+	if b.Removed != 1 || !slices.Equal(b.NeedsReview, []string{"u2"}) {
+		t.Fatalf("second run: %+v", b)
+	}
+	id := domain.PracticeFeeSettlementID(series.ID, month, "u1")
+	_, err = f.repos.Settlement.GetByID(f.ctx, id)
+	wantErr(t, err, domain.ErrNotFound)
+	if p, _ := f.repos.Payment.GetBySettlementAndUser(f.ctx, id, "u1"); p != nil {
+		t.Errorf("unpaid payment kept: %+v", p)
+	}
+}
+
+func TestPracticeBillingAdoptsBillsWithRandomIDs(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	series, sessions := f.series(circleID, "admin", 500)
+	f.practiceRSVP(sessions[0].ID, "u1", domain.PracticeRSVPGo)
+	f.practiceRSVP(sessions[0].ID, "u2", domain.PracticeRSVPGo)
+	month := sessions[0].Date.In(domain.Location).Format("2006-01")
+	if _, err := f.circles().UpdatePaymentSettings(f.ctx, circleID, "テスト銀行 普通 1234567", "", "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bills from before billing was idempotent, without payments or payment
+	// instructions: one matches the attendance, the other billed a session
+	// too many.
+	legacy := map[string]*domain.Settlement{}
+	for userID, count := range map[string]int{"u1": 1, "u2": 2} {
+		s := &domain.Settlement{
+			CircleID:      circleID,
+			Title:         fmt.Sprintf("%s 参加費 (%s月度 %d回分)", series.Name, month, count),
+			Amount:        500 * count,
+			TargetUserIDs: []string{userID},
+		}
+		if err := f.repos.Settlement.Create(f.ctx, s); err != nil {
+			t.Fatal(err)
+		}
+		legacy[userID] = s
+	}
+
+	b := f.billMonth(series, sessions[0])
+	if b.Created != 0 || b.Unchanged != 1 || b.Updated != 1 {
+		t.Fatalf("billing: %+v", b)
+	}
+	all, err := f.repos.Settlement.GetByCircle(f.ctx, circleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("got %d settlements, want the 2 legacy bills", len(all))
+	}
+	for userID, s := range legacy {
+		got, err := f.repos.Settlement.GetByID(f.ctx, s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.SeriesID != series.ID || got.Month != month || !slices.Equal(got.SessionIDs, []string{sessions[0].ID}) || got.Amount != 500 {
+			t.Errorf("%s: legacy bill not adopted: %+v", userID, got)
+		}
+		if got.BankInfo != "テスト銀行 普通 1234567" {
+			t.Errorf("%s: bank info = %q, want the circle's", userID, got.BankInfo)
+		}
+		p, err := f.repos.Payment.GetBySettlementAndUser(f.ctx, s.ID, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p == nil || p.Status != domain.PaymentUnpaid {
+			t.Fatalf("%s: payment = %+v, want unpaid", userID, p)
+		}
+		mine, err := f.settlements().GetMySettlements(f.ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mine) != 1 || mine[0].Settlement.ID != s.ID || mine[0].Amount != 500 {
+			t.Errorf("%s: own settlements %+v, want the adopted bill", userID, mine)
+		}
+	}
+
+	// Billing again finds them by series and month.
+	if b := f.billMonth(series, sessions[0]); b.Unchanged != 2 {
+		t.Errorf("third run: %+v", b)
+	}
+}
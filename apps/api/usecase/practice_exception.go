@@ -0,0 +1,303 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// MaxExceptionDays bounds the length of a single calendar exception.
+const MaxExceptionDays = 366
+
+// ExceptionResult is a calendar exception with the sessions it cancelled.
+type ExceptionResult struct {
+	Exception         *domain.CalendarException `json:"exception"`
+	CancelledSessions []*domain.PracticeSession `json:"cancelledSessions"`
+}
+
+// exceptionOn returns the first exception covering t, or nil.
+func exceptionOn(exceptions []*domain.CalendarException, t time.Time) *domain.CalendarException {
+	for _, e := range exceptions {
+		if e.Covers(t) {
+			return e
+		}
+	}
+	return nil
+}
+
+func validateException(e *domain.CalendarException) error {
+	verr := &domain.ValidationError{}
+	if !e.Kind.Valid() {
+		verr.Add("kind", "must be one of CLOSURE, EXAM, HOLIDAY, OTHER")
+	}
+	if !e.Action.Valid() {
+		verr.Add("action", "must be one of CANCEL, SKIP")
+	}
+	start, err := domain.ParseDate(e.StartDate)
+	if err != nil {
+		verr.Add("startDate", "must be a date in YYYY-MM-DD format")
+	}
+	end, err2 := domain.ParseDate(e.EndDate)
+	if err2 != nil {
+		verr.Add("endDate", "must be a date in YYYY-MM-DD format")
+	}
+	if err == nil && err2 == nil {
+		switch {
+		case end.Before(start):
+			verr.Add("endDate", "must not be before startDate")
+		case end.Sub(start) > MaxExceptionDays*24*time.Hour:
+			verr.Add("endDate", "range must not exceed one year")
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// GetExceptions returns the calendar exceptions of a circle.
+func (uc *PracticeUseCase) GetExceptions(ctx context.Context, circleID, userID string) ([]*domain.CalendarException, error) {
+	if err := uc.authz.RequireReadAccess(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
+	return uc.exceptionRepo.GetByCircle(ctx, circleID)
+}
+
+// CreateException adds an exception to a circle's calendar and cancels the
+// upcoming sessions of the circle within it, notifying members who RSVP'd GO.
+// Sessions generated later on its dates are cancelled or skipped according to
+// its action. Only circle admins can manage exceptions.
+func (uc *PracticeUseCase) CreateException(ctx context.Context, e *domain.CalendarException) (*ExceptionResult, error) {
+	if err := uc.authz.RequireAdmin(ctx, e.CircleID, e.CreatedBy); err != nil {
+		return nil, err
+	}
+	if e.EndDate == "" {
+		e.EndDate = e.StartDate
+	}
+	if err := validateException(e); err != nil {
+		return nil, err
+	}
+	cancelled, err := uc.saveException(ctx, e, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &ExceptionResult{Exception: e, CancelledSessions: cancelled}, nil
+}
+
+// ImportHolidays adds an exception for every national holiday of a year that
+// the circle does not have a HOLIDAY exception for yet, and returns the new
+// exceptions. Only circle admins can import holidays.
+func (uc *PracticeUseCase) ImportHolidays(ctx context.Context, circleID string, year int, action domain.ExceptionAction, actorID string) ([]*ExceptionResult, error) {
+	if err := uc.authz.RequireAdmin(ctx, circleID, actorID); err != nil {
+		return nil, err
+	}
+	if !action.Valid() {
+		return nil, domain.NewValidationError("action", "must be one of CANCEL, SKIP")
+	}
+	holidays, err := uc.holidays.Holidays(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := uc.exceptionRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+	imported := make(map[string]bool)
+	for _, e := range existing {
+		if e.Kind == domain.ExceptionHoliday {
+			imported[e.StartDate] = true
+		}
+	}
+
+	now := time.Now()
+	results := []*ExceptionResult{}
+	for _, h := range holidays {
+		if imported[h.Date] {
+			continue
+		}
+		e := &domain.CalendarException{
+			CircleID:  circleID,
+			Kind:      domain.ExceptionHoliday,
+			Title:     h.Name,
+			StartDate: h.Date,
+			EndDate:   h.Date,
+			Action:    action,
+			CreatedBy: actorID,
+		}
+		cancelled, err := uc.saveException(ctx, e, now)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &ExceptionResult{Exception: e, CancelledSessions: cancelled})
+	}
+	return results, nil
+}
+
+// DeleteException removes an exception and reinstates the upcoming sessions
+// it cancelled. Skipped dates are filled in by the next generation run.
+// Only circle admins can manage exceptions.
+func (uc *PracticeUseCase) DeleteException(ctx context.Context, id, actorID string) error {
+	e, err := uc.exceptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.authz.RequireAdmin(ctx, e.CircleID, actorID); err != nil {
+		return err
+	}
+	now := time.Now()
+	return uc.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		sessions, err := uc.upcomingCircleSessions(ctx, e.CircleID, now)
+		if err != nil {
+			return err
+		}
+		for _, s := range sessions {
+			if s.ExceptionID != e.ID {
+				continue
+			}
+			s.Cancelled = false
+			s.CancelReason = ""
+			s.ExceptionID = ""
+			if err := uc.sessionRepo.Update(ctx, s); err != nil {
+				return err
+			}
+		}
+		return uc.exceptionRepo.Delete(ctx, id)
+	})
+}
+
+// saveException creates e and cancels the circle's sessions after now that
+// fall within it in one transaction, then notifies the members who RSVP'd GO
+// to the cancelled sessions.
+func (uc *PracticeUseCase) saveException(ctx context.Context, e *domain.CalendarException, now time.Time) ([]*domain.PracticeSession, error) {
+	var cancelled []*domain.PracticeSession
+	err := uc.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		sessions, err := uc.upcomingCircleSessions(ctx, e.CircleID, now)
+		if err != nil {
+			return err
+		}
+		if err := uc.exceptionRepo.Create(ctx, e); err != nil {
+			return err
+		}
+		cancelled = []*domain.PracticeSession{}
+		for _, s := range sessions {
+			if s.Cancelled || !e.Covers(s.Date) {
+				continue
+			}
+			s.Cancelled = true
+			s.CancelReason = e.Title
+			s.ExceptionID = e.ID
+			if err := uc.sessionRepo.Update(ctx, s); err != nil {
+				return err
+			}
+			cancelled = append(cancelled, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range cancelled {
+		uc.notifyAttendees(ctx, e.CircleID, s, "練習中止のお知らせ",
+			fmt.Sprintf("%s の練習は中止になりました（%s）", formatSessionDate(s.Date), e.Title))
+	}
+	return cancelled, nil
+}
+
+// upcomingCircleSessions returns the sessions of every series of a circle that start after now.
+func (uc *PracticeUseCase) upcomingCircleSessions(ctx context.Context, circleID string, now time.Time) ([]*domain.PracticeSession, error) {
+	seriesList, err := uc.seriesRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+	var upcoming []*domain.PracticeSession
+	for _, series := range seriesList {
+		sessions, err := uc.sessionRepo.GetBySeries(ctx, series.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sessions {
+			if s.Date.After(now) {
+				upcoming = append(upcoming, s)
+			}
+		}
+	}
+	return upcoming, nil
+}
+
+// CancelSession cancels a session and notifies the members who RSVP'd GO.
+// Only circle admins can cancel sessions.
+func (uc *PracticeUseCase) CancelSession(ctx context.Context, sessionID, reason, actorID string) (*domain.PracticeSession, error) {
+	session, err := uc.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	series, err := uc.requireSeriesAdmin(ctx, session.SeriesID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Cancelled {
+		return nil, fmt.Errorf("%w: session is already cancelled", domain.ErrConflict)
+	}
+	session.Cancelled = true
+	session.CancelReason = reason
+	if err := uc.sessionRepo.Update(ctx, session); err != nil {
+		return nil, err
+	}
+	uc.notifyAttendees(ctx, series.CircleID, session, "練習中止のお知らせ",
+		fmt.Sprintf("%s の %s は中止になりました（%s）", formatSessionDate(session.Date), series.Name, reason))
+	return session, nil
+}
+
+// RescheduleSession moves a session to another date and notifies the members
+// who RSVP'd GO. Their RSVPs are kept. Only circle admins can reschedule sessions.
+func (uc *PracticeUseCase) RescheduleSession(ctx context.Context, sessionID string, date, endAt time.Time, reason, actorID string) (*domain.PracticeSession, error) {
+	session, err := uc.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	series, err := uc.requireSeriesAdmin(ctx, session.SeriesID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Cancelled {
+		return nil, fmt.Errorf("%w: session is cancelled", domain.ErrPreconditionFailed)
+	}
+	from := session.Date
+	if err := session.Move(date, endAt); err != nil {
+		return nil, err
+	}
+	session.RescheduleReason = reason
+	if err := uc.sessionRepo.Update(ctx, session); err != nil {
+		return nil, err
+	}
+	uc.notifyAttendees(ctx, series.CircleID, session, "練習日程変更のお知らせ",
+		fmt.Sprintf("%s の %s は %s に変更になりました（%s）", formatSessionDate(from), series.Name, formatSessionDate(session.Date), reason))
+	return session, nil
+}
+
+// notifyAttendees notifies the members who RSVP'd GO to a session. The change
+// has already been saved, so failures are logged rather than returned.
+func (uc *PracticeUseCase) notifyAttendees(ctx context.Context, circleID string, session *domain.PracticeSession, title, body string) {
+	rsvps, err := uc.rsvpRepo.GetBySession(ctx, session.ID)
+	if err != nil {
+		log.Printf("Warning: could not load RSVPs of session %s: %v", session.ID, err)
+		return
+	}
+	var userIDs []string
+	for _, r := range rsvps {
+		if r.Status == domain.PracticeRSVPGo {
+			userIDs = append(userIDs, r.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	n := &domain.Notification{UserIDs: userIDs, CircleID: circleID, Title: title, Body: body}
+	if err := uc.notifier.Notify(ctx, n); err != nil {
+		log.Printf("Warning: could not notify attendees of session %s: %v", session.ID, err)
+	}
+}
+
+func formatSessionDate(t time.Time) string {
+	return t.In(domain.Location).Format("1/2 15:04")
+}
@@ -0,0 +1,149 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// failingSessions fails every session update after the first ok ones.
+type failingSessions struct {
+	port.PracticeSessionRepository
+	ok int
+}
+
+var errUpdateFailed = errors.New("update failed")
+
+func (r *failingSessions) Update(ctx context.Context, s *domain.PracticeSession) error {
+	if r.ok == 0 {
+		return errUpdateFailed
+	}
+	r.ok--
+	return r.PracticeSessionRepository.Update(ctx, s)
+}
+
+// isoDate formats the date of t as the API does.
+func isoDate(t time.Time) string {
+	return t.In(domain.Location).Format("2006-01-02")
+}
+
+// exceptionOver returns a closure exception covering the given sessions.
+func exceptionOver(circleID, admin string, first, last *domain.PracticeSession) *domain.CalendarException {
+	return &domain.CalendarException{
+		CircleID:  circleID,
+		Kind:      domain.ExceptionClosure,
+		Title:     "体育館点検",
+		StartDate: isoDate(first.Date),
+		EndDate:   isoDate(last.Date),
+		Action:    domain.ExceptionCancel,
+		CreatedBy: admin,
+	}
+}
+
+func TestCreateExceptionCancelsSessionsAndNotifies(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1")
+	_, sessions := f.series(circleID, "admin", 0)
+	f.practiceRSVP(sessions[0].ID, "u1", domain.PracticeRSVPGo)
+
+	result, err := f.practice().CreateException(f.ctx, exceptionOver(circleID, "admin", sessions[0], sessions[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.CancelledSessions) != 2 {
+		t.Fatalf("cancelled %d sessions, want 2", len(result.CancelledSessions))
+	}
+	for i, s := range sessions {
+		got, err := f.repos.PracticeSession.GetByID(f.ctx, s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i < 2; got.Cancelled != want || (got.ExceptionID == result.Exception.ID) != want {
+			t.Errorf("session %d cancelled = %v by %q, want %v", i, got.Cancelled, got.ExceptionID, want)
+		}
+	}
+	sent := f.notifier.titled("練習中止のお知らせ")
+	if len(sent) != 1 {
+		t.Fatalf("got %d cancellation notifications, want 1", len(sent))
+	}
+	wantUsers(t, sent[0].UserIDs, []string{"u1"})
+
+	if err := f.practice().DeleteException(f.ctx, result.Exception.ID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.repos.PracticeSession.GetByID(f.ctx, sessions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cancelled || got.ExceptionID != "" {
+		t.Errorf("session still cancelled after the exception was deleted: %+v", got)
+	}
+}
+
+func TestCreateExceptionRollsBackOnFailure(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1")
+	_, sessions := f.series(circleID, "admin", 0)
+	f.practiceRSVP(sessions[0].ID, "u1", domain.PracticeRSVPGo)
+
+	// The second of three cancellations fails.
+	uc := f.practiceWith(&failingSessions{PracticeSessionRepository: f.repos.PracticeSession, ok: 1}, f.repos.PracticeRSVP)
+	_, err := uc.CreateException(f.ctx, exceptionOver(circleID, "admin", sessions[0], sessions[2]))
+	wantErr(t, err, errUpdateFailed)
+
+	exceptions, err := f.repos.CalendarException.GetByCircle(f.ctx, circleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exceptions) != 0 {
+		t.Errorf("exception kept after a failed cancellation: %+v", exceptions[0])
+	}
+	for i, s := range sessions {
+		got, err := f.repos.PracticeSession.GetByID(f.ctx, s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cancelled {
+			t.Errorf("session %d cancelled although the exception was rolled back", i)
+		}
+	}
+	if n := len(f.notifier.titled("練習中止のお知らせ")); n != 0 {
+		t.Errorf("got %d cancellation notifications, want none", n)
+	}
+}
+
+func TestImportHolidaysSavesEachHolidayWithItsCancellations(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin")
+	_, sessions := f.series(circleID, "admin", 0)
+	f.holidays = holidayList{
+		{Date: isoDate(sessions[0].Date), Name: "祝日A"},
+		{Date: isoDate(sessions[1].Date), Name: "祝日B"},
+	}
+
+	// The first holiday commits; the second fails and leaves nothing behind.
+	uc := f.practiceWith(&failingSessions{PracticeSessionRepository: f.repos.PracticeSession, ok: 1}, f.repos.PracticeRSVP)
+	_, err := uc.ImportHolidays(f.ctx, circleID, sessions[0].Date.Year(), domain.ExceptionCancel, "admin")
+	wantErr(t, err, errUpdateFailed)
+
+	exceptions, err := f.repos.CalendarException.GetByCircle(f.ctx, circleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exceptions) != 1 || exceptions[0].Title != "祝日A" {
+		t.Fatalf("got exceptions %+v, want only 祝日A", exceptions)
+	}
+	for i, want := range []bool{true, false} {
+		got, err := f.repos.PracticeSession.GetByID(f.ctx, sessions[i].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cancelled != want {
+			t.Errorf("session %d cancelled = %v, want %v", i, got.Cancelled, want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// MonthlyInvoiceRun is the outcome of billing a circle's practice fees for a
+// month on one invoice per member.
+type MonthlyInvoiceRun struct {
+	Month  string `json:"month"`
+	DryRun bool   `json:"dryRun"`
+	Total  int    `json:"total"` // sum of the invoices' amounts
+	// Invoices bill the attended sessions no earlier settlement covered, one
+	// per member, and credit back the sessions an earlier invoice billed
+	// that the member no longer attends. In a dry run they are not saved.
+	Invoices []*domain.Settlement `json:"invoices"`
+	// NeedsReview lists members whose credits outweigh their new sessions.
+	// No invoice is created for them; the admin settles the refund.
+	NeedsReview []string `json:"needsReview"`
+}
+
+// PreviewMonthlyInvoices returns the invoices CreateMonthlyInvoices would
+// create, without saving anything. Only circle admins can bill practice fees.
+func (uc *PracticeUseCase) PreviewMonthlyInvoices(ctx context.Context, circleID, month, actorID string) (*MonthlyInvoiceRun, error) {
+	return uc.runMonthlyInvoices(ctx, circleID, month, actorID, true)
+}
+
+// CreateMonthlyInvoices bills the practice fees of every series of a circle
+// for a month. Each member who answered GO to sessions that were held gets one
+// settlement itemizing the sessions, with a payment to report, using the
+// circle's payment instructions.
+//
+// Sessions already billed, on an earlier invoice or a series' own bill, are
+// left out, so running a month again bills only the sessions added since,
+// on a follow-up invoice. Sessions an earlier invoice billed that were
+// cancelled or withdrawn from since are credited on the follow-up invoice.
+// Only circle admins can bill practice fees.
+func (uc *PracticeUseCase) CreateMonthlyInvoices(ctx context.Context, circleID, month, actorID string) (*MonthlyInvoiceRun, error) {
+	return uc.runMonthlyInvoices(ctx, circleID, month, actorID, false)
+}
+
+func (uc *PracticeUseCase) runMonthlyInvoices(ctx context.Context, circleID, month, actorID string, dryRun bool) (*MonthlyInvoiceRun, error) {
+	if err := uc.authz.RequireAdmin(ctx, circleID, actorID); err != nil {
+		return nil, err
+	}
+	circle, err := uc.circleRepo.GetByID(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+	invoices, needsReview, err := uc.draftMonthlyInvoices(ctx, circle, month)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &MonthlyInvoiceRun{Month: month, DryRun: dryRun, Invoices: invoices, NeedsReview: needsReview}
+	for _, s := range invoices {
+		run.Total += s.Amount
+	}
+	if dryRun {
+		return run, nil
+	}
+	for _, s := range invoices {
+		if err := uc.createInvoice(ctx, s); err != nil {
+			return nil, err
+		}
+		notifySettlement(ctx, uc.notifier, s, s.AmountFor, actorID)
+	}
+	return run, nil
+}
+
+// draftMonthlyInvoices builds, per member, an unsaved invoice of the month's
+// attended sessions that have not been billed yet, with credits for the
+// sessions earlier invoices billed that the member no longer attends. It
+// also returns the members whose credits outweigh their new sessions.
+func (uc *PracticeUseCase) draftMonthlyInvoices(ctx context.Context, circle *domain.Circle, month string) ([]*domain.Settlement, []string, error) {
+	seriesList, err := uc.seriesRepo.GetByCircle(ctx, circle.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	settlements, err := uc.settlementRepo.GetByCircle(ctx, circle.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	billed := billedSessions(settlements, nil)
+
+	// Earlier invoices of the month decide the number of the next one, and
+	// their items are what a withdrawn session is credited at.
+	invoiced := make(map[string]int)
+	charged := make(map[string]map[string]domain.SettlementLineItem)
+	for _, s := range settlements {
+		if s.Month == month && s.SeriesID == "" && len(s.LineItems) > 0 && len(s.TargetUserIDs) == 1 {
+			userID := s.TargetUserIDs[0]
+			invoiced[userID]++
+			if charged[userID] == nil {
+				charged[userID] = make(map[string]domain.SettlementLineItem)
+			}
+			for _, item := range s.LineItems {
+				if item.SessionID != "" && item.Amount > 0 {
+					charged[userID][item.SessionID] = item
+				}
+			}
+		}
+	}
+
+	items := make(map[string][]domain.SettlementLineItem)
+	attending := make(map[string]map[string]bool)
+	for _, series := range seriesList {
+		attended, err := uc.attendedSessions(ctx, series.ID, month)
+		if err != nil {
+			return nil, nil, err
+		}
+		for userID, sessions := range attended {
+			if attending[userID] == nil {
+				attending[userID] = make(map[string]bool)
+			}
+			for _, session := range sessions {
+				attending[userID][session.ID] = true
+				if series.Fee == 0 || billed[userID][session.ID] {
+					continue
+				}
+				items[userID] = append(items[userID], domain.SettlementLineItem{
+					Label:     series.Name,
+					Amount:    series.Fee,
+					SeriesID:  series.ID,
+					SessionID: session.ID,
+					Date:      session.Date,
+				})
+			}
+		}
+	}
+	for userID, sessions := range charged {
+		for sessionID, item := range sessions {
+			if !billed[userID][sessionID] || attending[userID][sessionID] {
+				continue
+			}
+			items[userID] = append(items[userID], domain.SettlementLineItem{
+				Label:     item.Label + " (取消)",
+				Amount:    -item.Amount,
+				SeriesID:  item.SeriesID,
+				SessionID: sessionID,
+				Date:      item.Date,
+			})
+		}
+	}
+
+	userIDs := make([]string, 0, len(items))
+	for userID := range items {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	dueAt := time.Now().AddDate(0, 0, PracticeFeeDueDays)
+	invoices := make([]*domain.Settlement, 0, len(userIDs))
+	needsReview := []string{}
+	for _, userID := range userIDs {
+		lineItems := items[userID]
+		if domain.SumLineItems(lineItems) <= 0 {
+			needsReview = append(needsReview, userID)
+			continue
+		}
+		sort.SliceStable(lineItems, func(a, b int) bool {
+			if !lineItems[a].Date.Equal(lineItems[b].Date) {
+				return lineItems[a].Date.Before(lineItems[b].Date)
+			}
+			return lineItems[a].Label < lineItems[b].Label
+		})
+		s := &domain.Settlement{
+			ID:            domain.MonthlyInvoiceSettlementID(circle.ID, month, userID, invoiced[userID]+1),
+			CircleID:      circle.ID,
+			Title:         fmt.Sprintf("練習参加費 (%s月度)", month),
+			DueAt:         dueAt,
+			TargetUserIDs: []string{userID},
+			BankInfo:      circle.BankInfo,
+			PayPayInfo:    circle.PayPayInfo,
+			Month:         month,
+			Amount:        domain.SumLineItems(lineItems),
+			LineItems:     lineItems,
+		}
+		if invoiced[userID] > 0 {
+			s.Title = fmt.Sprintf("練習参加費 (%s月度 追加分)", month)
+		}
+		for _, item := range lineItems {
+			if item.Amount > 0 {
+				s.SessionIDs = append(s.SessionIDs, item.SessionID)
+			}
+		}
+		invoices = append(invoices, s)
+	}
+	return invoices, needsReview, nil
+}
+
+// createInvoice saves an invoice with the member's payment. An invoice of the
+// same number saved meanwhile means another run billed the member first.
+func (uc *PracticeUseCase) createInvoice(ctx context.Context, s *domain.Settlement) error {
+	err := uc.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.settlementRepo.Create(ctx, s); err != nil {
+			return err
+		}
+		payment := &domain.Payment{SettlementID: s.ID, UserID: s.TargetUserIDs[0], Status: domain.PaymentUnpaid}
+		return uc.paymentRepo.Create(ctx, payment)
+	})
+	if errors.Is(err, domain.ErrConflict) {
+		return fmt.Errorf("%w: invoices for %s were created by another run; preview again", domain.ErrConflict, s.Month)
+	}
+	return err
+}
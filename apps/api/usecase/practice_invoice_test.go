@@ -0,0 +1,60 @@
+package usecase_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+func TestMonthlyInvoicesCreditWithdrawnSessions(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	_, sessions := f.series(circleID, "admin", 500)
+	_, evening := f.series(circleID, "admin", 800)
+	session, other := sessions[0], evening[0]
+	month := session.Date.In(domain.Location).Format("2006-01")
+	f.practiceRSVP(session.ID, "u1", domain.PracticeRSVPGo)
+	f.practiceRSVP(session.ID, "u2", domain.PracticeRSVPGo)
+	run, err := f.practice().CreateMonthlyInvoices(f.ctx, circleID, month, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(run.Invoices) != 2 || run.Total != 1000 {
+		t.Fatalf("first run: %+v", run)
+	}
+
+	// u1 withdraws; u2 moves to the dearer session on the same day.
+	f.practiceRSVP(session.ID, "u1", domain.PracticeRSVPNo)
+	f.practiceRSVP(session.ID, "u2", domain.PracticeRSVPNo)
+	f.practiceRSVP(other.ID, "u2", domain.PracticeRSVPGo)
+	run, err = f.practice().CreateMonthlyInvoices(f.ctx, circleID, month, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(run.Invoices) != 1 || run.Total != 300 || !slices.Equal(run.NeedsReview, []string{"u1"}) {
+		t.Fatalf("second run: %+v", run)
+	}
+	s := run.Invoices[0]
+	if s.ID != domain.MonthlyInvoiceSettlementID(circleID, month, "u2", 2) || !slices.Equal(s.SessionIDs, []string{other.ID}) {
+		t.Fatalf("follow-up invoice %+v", s)
+	}
+	var credit *domain.SettlementLineItem
+	for i := range s.LineItems {
+		if s.LineItems[i].SessionID == session.ID {
+			credit = &s.LineItems[i]
+		}
+	}
+	if credit == nil || credit.Amount != -500 {
+		t.Fatalf("credit line = %+v, want -500 for the withdrawn session", credit)
+	}
+
+	// Credits are not issued twice.
+	run, err = f.practice().PreviewMonthlyInvoices(f.ctx, circleID, month, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(run.Invoices) != 0 {
+		t.Fatalf("third run: %+v", run.Invoices)
+	}
+}
@@ -0,0 +1,152 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// failingRSVPs fails every upsert after the first ok ones.
+type failingRSVPs struct {
+	port.PracticeRSVPRepository
+	ok int
+}
+
+var errUpsertFailed = errors.New("upsert failed")
+
+func (r *failingRSVPs) Upsert(ctx context.Context, rsvp *domain.PracticeRSVP) error {
+	if r.ok == 0 {
+		return errUpsertFailed
+	}
+	r.ok--
+	return r.PracticeRSVPRepository.Upsert(ctx, rsvp)
+}
+
+// closeRSVPs moves the RSVP deadline of a session into the past.
+func (f *fixture) closeRSVPs(s *domain.PracticeSession) {
+	f.t.Helper()
+	s.RSVPDeadline = time.Now().Add(-time.Hour)
+	if err := f.repos.PracticeSession.Update(f.ctx, s); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func TestPracticeOverrideAfterDeadlineNeedsReason(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1")
+	_, sessions := f.series(circleID, "admin", 0)
+	f.closeRSVPs(sessions[0])
+	r := &domain.PracticeRSVP{SessionID: sessions[0].ID, UserID: "u1", Status: domain.PracticeRSVPGo}
+
+	wantErr(t, f.practice().SubmitRSVP(f.ctx, r), domain.ErrPreconditionFailed)
+	wantErr(t, f.practice().OverrideRSVP(f.ctx, r, "", "admin"), domain.ErrInvalidInput)
+	if err := f.practice().OverrideRSVP(f.ctx, r, "当日連絡あり", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := f.repos.AuditLog.GetByCircle(f.ctx, circleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Reason != "当日連絡あり" || entries[0].ActorID != "admin" {
+		t.Errorf("unexpected audit entries %+v", entries)
+	}
+
+	// Admins changing their own RSVP late are audited without a reason.
+	own := &domain.PracticeRSVP{SessionID: sessions[0].ID, UserID: "admin", Status: domain.PracticeRSVPNo}
+	if err := f.practice().SubmitRSVP(f.ctx, own); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBulkRSVPSavesAllOrNothing(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1")
+	series, sessions := f.series(circleID, "admin", 0)
+	rsvps := make([]*domain.PracticeRSVP, len(sessions))
+	for idx, s := range sessions {
+		rsvps[idx] = &domain.PracticeRSVP{SessionID: s.ID, UserID: "u1", Status: domain.PracticeRSVPGo}
+	}
+
+	uc := f.practiceWith(f.repos.PracticeSession, &failingRSVPs{PracticeRSVPRepository: f.repos.PracticeRSVP, ok: 2})
+	wantErr(t, uc.BulkRSVP(f.ctx, series.ID, rsvps), errUpsertFailed)
+	saved, err := f.repos.PracticeRSVP.GetBySeriesAndUser(f.ctx, series.ID, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 0 {
+		t.Fatalf("%d RSVPs kept after a failed bulk RSVP", len(saved))
+	}
+
+	if err := f.practice().BulkRSVP(f.ctx, series.ID, rsvps); err != nil {
+		t.Fatal(err)
+	}
+	saved, err = f.repos.PracticeRSVP.GetBySeriesAndUser(f.ctx, series.ID, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != len(sessions) {
+		t.Errorf("saved %d RSVPs, want %d", len(saved), len(sessions))
+	}
+}
+
+// The generate-sessions job runs GenerateUpcomingSessions.
+func TestGenerateUpcomingSessionsSkipsTakenDatesAndRespectsHorizon(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin")
+	s := &domain.PracticeSeries{CircleID: circleID, Name: "土曜練習", DayOfWeek: int(time.Saturday), StartDate: "2030-06-01", StartTime: "14:00", CreatedBy: "admin"}
+	if err := f.practice().CreateSeries(f.ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	at := func(day string) time.Time {
+		d, err := domain.ParseDate(day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.Add(14 * time.Hour)
+	}
+	// The session of 6/8 was moved to the Sunday before the job ran.
+	moved := &domain.PracticeSession{ID: "moved", SeriesID: s.ID, Date: at("2030-06-09"), OriginalDate: at("2030-06-08")}
+	if err := f.repos.PracticeSession.Create(f.ctx, moved); err != nil {
+		t.Fatal(err)
+	}
+	for day, action := range map[string]domain.ExceptionAction{"2030-06-15": domain.ExceptionSkip, "2030-06-22": domain.ExceptionCancel} {
+		e := &domain.CalendarException{CircleID: circleID, Kind: domain.ExceptionClosure, Title: "体育館点検", StartDate: day, EndDate: day, Action: action, CreatedBy: "admin"}
+		if err := f.repos.CalendarException.Create(f.ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Four weeks from Monday 6/3 end before Saturday 7/6.
+	now := at("2030-06-03")
+	created, err := f.practice().GenerateUpcomingSessions(f.ctx, now, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 2 {
+		t.Fatalf("created %d sessions, want 6/22 and 6/29", created)
+	}
+	sessions, err := f.repos.PracticeSession.GetBySeries(f.ctx, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, session := range sessions {
+		got[isoDate(session.Date)] = session.Cancelled
+	}
+	want := map[string]bool{"2030-06-09": false, "2030-06-22": true, "2030-06-29": false}
+	if !maps.Equal(got, want) {
+		t.Fatalf("sessions (date: cancelled) = %v, want %v", got, want)
+	}
+
+	if created, err := f.practice().GenerateUpcomingSessions(f.ctx, now, 4); err != nil || created != 0 {
+		t.Fatalf("second run created %d sessions (%v), want none", created, err)
+	}
+	if created, err := f.practice().GenerateUpcomingSessions(f.ctx, now, 5); err != nil || created != 1 {
+		t.Fatalf("five weeks created %d sessions (%v), want only 7/6", created, err)
+	}
+}
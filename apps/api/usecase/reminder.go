@@ -0,0 +1,477 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// ReminderCatchUpWindow is how late a reminder can still be sent. Reminders
+// that were due longer ago, such as those of settlements created before
+// reminders were enabled, are never planned.
+const ReminderCatchUpWindow = 24 * time.Hour
+
+// MaxReminderAttempts is how often the delivery of a reminder is tried
+// before it is marked as failed.
+const MaxReminderAttempts = 3
+
+// ReminderRetryDelay is how long a reminder waits after its first failed
+// delivery. The delay doubles with every further failure.
+const ReminderRetryDelay = 15 * time.Minute
+
+// ReminderRun is the outcome of one scheduler run.
+type ReminderRun struct {
+	Planned int `json:"planned"`
+	Sent    int `json:"sent"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// ReminderInteractor plans and sends reminders to members who have not
+// answered an event or paid a settlement, following each circle's policy.
+// Jobs are kept in a repository so that every reminder is sent once, even
+// across restarts or with several processes running the scheduler.
+type ReminderInteractor struct {
+	policyRepo     port.ReminderPolicyRepository
+	jobRepo        port.ReminderJobRepository
+	circleRepo     port.CircleRepository
+	eventRepo      port.EventRepository
+	rsvpRepo       port.RSVPRepository
+	membershipRepo port.MembershipRepository
+	settlementRepo port.SettlementRepository
+	paymentRepo    port.PaymentRepository
+	tx             port.Transactor
+	notifier       port.Notifier
+	clock          port.Clock
+	authz          *Authorizer
+}
+
+// NewReminderInteractor creates a new ReminderInteractor.
+func NewReminderInteractor(
+	policyRepo port.ReminderPolicyRepository,
+	jobRepo port.ReminderJobRepository,
+	circleRepo port.CircleRepository,
+	eventRepo port.EventRepository,
+	rsvpRepo port.RSVPRepository,
+	membershipRepo port.MembershipRepository,
+	settlementRepo port.SettlementRepository,
+	paymentRepo port.PaymentRepository,
+	tx port.Transactor,
+	notifier port.Notifier,
+	clock port.Clock,
+	authz *Authorizer,
+) *ReminderInteractor {
+	return &ReminderInteractor{
+		policyRepo:     policyRepo,
+		jobRepo:        jobRepo,
+		circleRepo:     circleRepo,
+		eventRepo:      eventRepo,
+		rsvpRepo:       rsvpRepo,
+		membershipRepo: membershipRepo,
+		settlementRepo: settlementRepo,
+		paymentRepo:    paymentRepo,
+		tx:             tx,
+		notifier:       notifier,
+		clock:          clock,
+		authz:          authz,
+	}
+}
+
+// policyFor returns the reminder policy of a circle, or the default one.
+func (i *ReminderInteractor) policyFor(ctx context.Context, circleID string) (*domain.ReminderPolicy, error) {
+	policy, err := i.policyRepo.Get(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return domain.DefaultReminderPolicy(circleID), nil
+	}
+	return policy, nil
+}
+
+// GetPolicy returns the reminder policy of a circle.
+// Only circle admins can manage reminders.
+func (i *ReminderInteractor) GetPolicy(ctx context.Context, circleID, userID string) (*domain.ReminderPolicy, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
+	return i.policyFor(ctx, circleID)
+}
+
+// UpdatePolicy replaces the reminder policy of a circle. Pending reminders
+// that are no longer configured are skipped when they come due.
+// Only circle admins can manage reminders.
+func (i *ReminderInteractor) UpdatePolicy(ctx context.Context, p *domain.ReminderPolicy, actorID string) (*domain.ReminderPolicy, error) {
+	if err := i.authz.RequireAdmin(ctx, p.CircleID, actorID); err != nil {
+		return nil, err
+	}
+	if err := p.Normalize(); err != nil {
+		return nil, err
+	}
+	p.UpdatedBy = actorID
+	if err := i.policyRepo.Upsert(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetJobs returns the planned and sent reminders of a circle, latest first.
+// Only circle admins can manage reminders.
+func (i *ReminderInteractor) GetJobs(ctx context.Context, circleID, userID string) ([]*domain.ReminderJob, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, userID); err != nil {
+		return nil, err
+	}
+	return i.jobRepo.GetByCircle(ctx, circleID)
+}
+
+// RunReminders plans the reminders of every circle and sends those that are
+// due. It is meant for the scheduler and performs no authorization. A failing
+// circle or reminder does not stop the others.
+func (i *ReminderInteractor) RunReminders(ctx context.Context) (*ReminderRun, error) {
+	now := i.clock.Now()
+	circles, err := i.circleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &ReminderRun{}
+	policies := make(map[string]*domain.ReminderPolicy, len(circles))
+	var errs []error
+	for _, c := range circles {
+		policy, err := i.policyFor(ctx, c.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("circle %s: %w", c.ID, err))
+			continue
+		}
+		policies[c.ID] = policy
+		if !policy.Enabled {
+			continue
+		}
+		planned, err := i.plan(ctx, c.ID, policy, now)
+		run.Planned += planned
+		if err != nil {
+			errs = append(errs, fmt.Errorf("circle %s: %w", c.ID, err))
+		}
+	}
+	if err := i.sendDue(ctx, policies, now, run); err != nil {
+		errs = append(errs, err)
+	}
+	return run, errors.Join(errs...)
+}
+
+// plan creates the reminder jobs of a circle's open events and settlements.
+func (i *ReminderInteractor) plan(ctx context.Context, circleID string, policy *domain.ReminderPolicy, now time.Time) (int, error) {
+	planned := 0
+	events, err := i.eventRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range events {
+		if !now.Before(e.RSVPClosesAt()) {
+			continue
+		}
+		for _, offset := range policy.Offsets(domain.ReminderRSVP) {
+			created, err := i.ensureJob(ctx, circleID, domain.ReminderRSVP, e.ID, offset, e.StartAt.AddDate(0, 0, offset), now)
+			if err != nil {
+				return planned, err
+			}
+			if created {
+				planned++
+			}
+		}
+	}
+
+	settlements, err := i.settlementRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return planned, err
+	}
+	for _, s := range settlements {
+		if s.DueAt.IsZero() {
+			continue
+		}
+		for _, offset := range policy.Offsets(domain.ReminderPayment) {
+			created, err := i.ensureJob(ctx, circleID, domain.ReminderPayment, s.ID, offset, s.DueAt.AddDate(0, 0, offset), now)
+			if err != nil {
+				return planned, err
+			}
+			if created {
+				planned++
+			}
+		}
+	}
+	return planned, nil
+}
+
+// ensureJob creates the job of a reminder unless it exists, and reports
+// whether it did. When the event or due date has moved, a pending job is
+// moved with it, and a job already sent or skipped is re-armed if its new
+// time is still ahead.
+func (i *ReminderInteractor) ensureJob(ctx context.Context, circleID string, kind domain.ReminderKind, targetID string, offset int, dueAt, now time.Time) (bool, error) {
+	if dueAt.Before(now.Add(-ReminderCatchUpWindow)) {
+		return false, nil
+	}
+	id := domain.ReminderJobID(kind, targetID, offset)
+	job, err := i.jobRepo.GetByID(ctx, id)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		job = &domain.ReminderJob{
+			ID:         id,
+			CircleID:   circleID,
+			Kind:       kind,
+			TargetID:   targetID,
+			OffsetDays: offset,
+			DueAt:      dueAt,
+			Status:     domain.ReminderPending,
+			Recipients: []string{},
+		}
+		err := i.jobRepo.Create(ctx, job)
+		if errors.Is(err, domain.ErrConflict) {
+			return false, nil // planned concurrently by another process
+		}
+		return err == nil, err
+	case err != nil:
+		return false, err
+	case job.DueAt.Equal(dueAt):
+		return false, nil
+	case job.Status == domain.ReminderPending:
+		job.DueAt = dueAt
+		return false, i.jobRepo.Update(ctx, job)
+	case dueAt.After(now):
+		job.DueAt = dueAt
+		job.Status = domain.ReminderPending
+		job.Attempts = 0
+		job.RetryAt = time.Time{}
+		job.Note = ""
+		return false, i.jobRepo.Update(ctx, job)
+	}
+	return false, nil
+}
+
+// sendDue sends the pending reminders that are due and not waiting for a
+// retry. When several reminders of the same event or settlement are due at
+// once, for example after the scheduler was down, only the latest is sent and
+// the others are skipped.
+func (i *ReminderInteractor) sendDue(ctx context.Context, policies map[string]*domain.ReminderPolicy, now time.Time, run *ReminderRun) error {
+	jobs, err := i.jobRepo.GetPending(ctx)
+	if err != nil {
+		return err
+	}
+	var due []*domain.ReminderJob
+	for _, j := range jobs {
+		if !j.DueAt.After(now) && !j.RetryAt.After(now) {
+			due = append(due, j)
+		}
+	}
+	sort.SliceStable(due, func(a, b int) bool {
+		return due[a].DueAt.Before(due[b].DueAt)
+	})
+
+	latest := make(map[string]*domain.ReminderJob)
+	var order []string
+	for _, j := range due {
+		key := string(j.Kind) + "_" + j.TargetID
+		if prev, ok := latest[key]; ok {
+			i.finish(ctx, prev, domain.ReminderSkipped, "superseded by a later reminder")
+			run.Skipped++
+		} else {
+			order = append(order, key)
+		}
+		latest[key] = j
+	}
+
+	var errs []error
+	for _, key := range order {
+		j := latest[key]
+		policy := policies[j.CircleID]
+		if policy == nil {
+			i.finish(ctx, j, domain.ReminderSkipped, "circle not found")
+			run.Skipped++
+			continue
+		}
+		if err := i.send(ctx, j, policy, now, run); err != nil {
+			errs = append(errs, fmt.Errorf("reminder %s: %w", j.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send delivers one due reminder to the members it is still relevant to.
+func (i *ReminderInteractor) send(ctx context.Context, job *domain.ReminderJob, policy *domain.ReminderPolicy, now time.Time, run *ReminderRun) error {
+	skip := func(note string) error {
+		i.finish(ctx, job, domain.ReminderSkipped, note)
+		run.Skipped++
+		return nil
+	}
+	if !policy.Enabled {
+		return skip("reminders are disabled")
+	}
+	if !slices.Contains(policy.Offsets(job.Kind), job.OffsetDays) {
+		return skip("no longer in the reminder policy")
+	}
+
+	var n *domain.Notification
+	var note string
+	var err error
+	switch job.Kind {
+	case domain.ReminderRSVP:
+		n, note, err = i.composeRSVP(ctx, job, now)
+	case domain.ReminderPayment:
+		n, note, err = i.composePayment(ctx, job)
+	default:
+		return skip("unknown kind")
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		return skip(fmt.Sprintf("%s %s was deleted", job.Kind, job.TargetID))
+	}
+	if err != nil {
+		return err // left pending and retried on the next run
+	}
+	if n == nil {
+		return skip(note)
+	}
+
+	claimed, err := i.claim(ctx, job.ID, n.UserIDs, now)
+	if err != nil || claimed == nil {
+		return err
+	}
+	if err := i.notifier.Notify(ctx, n); err != nil {
+		claimed.Note = err.Error()
+		claimed.SentAt = time.Time{}
+		claimed.Status = domain.ReminderPending
+		claimed.RetryAt = now.Add(ReminderRetryDelay << (claimed.Attempts - 1))
+		if claimed.Attempts >= MaxReminderAttempts {
+			claimed.Status = domain.ReminderFailed
+			run.Failed++
+		}
+		if uerr := i.jobRepo.Update(ctx, claimed); uerr != nil {
+			log.Printf("Warning: could not record failure of reminder %s: %v", claimed.ID, uerr)
+		}
+		return err
+	}
+	run.Sent++
+	return nil
+}
+
+// claim marks a pending job as sent. It returns nil without error if another
+// process already handled the job.
+func (i *ReminderInteractor) claim(ctx context.Context, id string, recipients []string, now time.Time) (*domain.ReminderJob, error) {
+	var claimed *domain.ReminderJob
+	err := i.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		claimed = nil
+		job, err := i.jobRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if job.Status != domain.ReminderPending {
+			return nil
+		}
+		job.Status = domain.ReminderSent
+		job.Attempts++
+		job.Recipients = recipients
+		job.SentAt = now
+		job.RetryAt = time.Time{}
+		job.Note = ""
+		claimed = job
+		return i.jobRepo.Update(ctx, job)
+	})
+	return claimed, err
+}
+
+// finish closes a job without sending it. Failures are logged; the job is
+// simply looked at again on the next run.
+func (i *ReminderInteractor) finish(ctx context.Context, job *domain.ReminderJob, status domain.ReminderStatus, note string) {
+	job.Status = status
+	job.Note = note
+	if err := i.jobRepo.Update(ctx, job); err != nil {
+		log.Printf("Warning: could not update reminder %s: %v", job.ID, err)
+	}
+}
+
+// composeRSVP builds the reminder for targets who have not responded to an
+// event, or returns why there is nobody to remind.
+func (i *ReminderInteractor) composeRSVP(ctx context.Context, job *domain.ReminderJob, now time.Time) (*domain.Notification, string, error) {
+	event, err := i.eventRepo.GetByID(ctx, job.TargetID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !now.Before(event.RSVPClosesAt()) {
+		return nil, "RSVPs are closed", nil
+	}
+	targets, err := eventTargets(ctx, i.membershipRepo, event)
+	if err != nil {
+		return nil, "", err
+	}
+	rsvps, err := i.rsvpRepo.GetByEvent(ctx, event.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	responded := make(map[string]bool, len(rsvps))
+	for _, r := range rsvps {
+		responded[r.UserID] = true
+	}
+	var userIDs []string
+	for _, id := range targets {
+		if !responded[id] {
+			userIDs = append(userIDs, id)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil, "everyone has responded", nil
+	}
+	return &domain.Notification{
+		UserIDs:  userIDs,
+		CircleID: event.CircleID,
+		Title:    "出欠回答のお願い",
+		Body: fmt.Sprintf("「%s」（%s）の出欠が未回答です。%s までに回答してください",
+			event.Title, formatSessionDate(event.StartAt), formatSessionDate(event.RSVPClosesAt())),
+	}, "", nil
+}
+
+// composePayment builds the reminder for payers of a settlement who are
+// still unpaid, or returns why there is nobody to remind.
+func (i *ReminderInteractor) composePayment(ctx context.Context, job *domain.ReminderJob) (*domain.Notification, string, error) {
+	settlement, err := i.settlementRepo.GetByID(ctx, job.TargetID)
+	if err != nil {
+		return nil, "", err
+	}
+	payments, err := i.paymentRepo.GetBySettlement(ctx, settlement.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	var userIDs []string
+	amounts := make(map[int]bool)
+	for _, p := range payments {
+		if p.Status == domain.PaymentUnpaid && p.Owed(settlement) > 0 {
+			userIDs = append(userIDs, p.UserID)
+			amounts[p.Owed(settlement)] = true
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil, "nobody is unpaid", nil
+	}
+
+	// The amount is left out when the payers owe different amounts.
+	what := fmt.Sprintf("「%s」", settlement.Title)
+	if len(amounts) == 1 {
+		for amount := range amounts {
+			what = fmt.Sprintf("「%s」（%d円）", settlement.Title, amount)
+		}
+	}
+
+	n := &domain.Notification{UserIDs: userIDs, CircleID: settlement.CircleID}
+	if job.OffsetDays < 0 {
+		n.Title = "お支払いのお願い"
+		n.Body = fmt.Sprintf("%sの支払期限は %s です", what, formatSessionDate(settlement.DueAt))
+	} else {
+		n.Title = "支払期限を過ぎています"
+		n.Body = fmt.Sprintf("%sの支払期限（%s）を過ぎています。お支払いをお願いします", what, formatSessionDate(settlement.DueAt))
+	}
+	return n, "", nil
+}
@@ -0,0 +1,220 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/infra/clock"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+const day = 24 * time.Hour
+
+// reminderSetup is a circle with an event ten days ahead and a fake clock
+// at the current time.
+type reminderSetup struct {
+	*fixture
+	clock    *clock.Fake
+	circleID string
+	event    *domain.Event
+}
+
+func newReminderSetup(t *testing.T) *reminderSetup {
+	f := newFixture(t)
+	now := time.Now()
+	circleID := f.circle("admin", "u1", "u2")
+	e := f.event(circleID, "admin", now.Add(10*day).Truncate(time.Minute), 0, time.Time{})
+	f.notifier.sent = nil // forget the new event notification
+	return &reminderSetup{fixture: f, clock: clock.NewFake(now), circleID: circleID, event: e}
+}
+
+// run runs the scheduler once and fails the test unless it reports want.
+func (s *reminderSetup) run(want usecase.ReminderRun) {
+	s.t.Helper()
+	got, err := s.reminders(s.clock).RunReminders(s.ctx)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if *got != want {
+		s.t.Fatalf("run = %+v, want %+v", *got, want)
+	}
+}
+
+// job returns the RSVP reminder of the event at an offset in days.
+func (s *reminderSetup) job(offset int) *domain.ReminderJob {
+	s.t.Helper()
+	j, err := s.repos.ReminderJob.GetByID(s.ctx, domain.ReminderJobID(domain.ReminderRSVP, s.event.ID, offset))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return j
+}
+
+func (s *reminderSetup) reminded() []*domain.Notification {
+	return s.notifier.titled("出欠回答のお願い")
+}
+
+// moveEvent changes the start time of the event.
+func (s *reminderSetup) moveEvent(startAt time.Time) {
+	s.t.Helper()
+	e := s.event
+	if _, err := s.events().UpdateEvent(s.ctx, e.ID, e.Title, startAt, e.Location, "", nil, 0, time.Time{}, "admin"); err != nil {
+		s.t.Fatal(err)
+	}
+	e.StartAt = startAt
+}
+
+func TestRunRemindersPlansAtPolicyOffsets(t *testing.T) {
+	s := newReminderSetup(t)
+
+	s.run(usecase.ReminderRun{Planned: 2})
+	for _, offset := range []int{-3, -1} {
+		j := s.job(offset)
+		if want := s.event.StartAt.AddDate(0, 0, offset); !j.DueAt.Equal(want) || j.Status != domain.ReminderPending {
+			t.Errorf("job %d: due %v status %s, want pending at %v", offset, j.DueAt, j.Status, want)
+		}
+	}
+	s.run(usecase.ReminderRun{})
+	if n := len(s.reminded()); n != 0 {
+		t.Errorf("got %d reminders before they were due", n)
+	}
+}
+
+func TestRunRemindersSendsOnceToThoseWhoHaveNotResponded(t *testing.T) {
+	s := newReminderSetup(t)
+	s.rsvp(s.event.ID, "u1", domain.RSVPGo)
+	s.run(usecase.ReminderRun{Planned: 2})
+
+	s.clock.Set(s.event.StartAt.Add(-3 * day))
+	s.run(usecase.ReminderRun{Sent: 1})
+	s.run(usecase.ReminderRun{})
+	sent := s.reminded()
+	if len(sent) != 1 {
+		t.Fatalf("got %d reminders, want 1", len(sent))
+	}
+	wantUsers(t, sent[0].UserIDs, []string{"admin", "u2"})
+	if j := s.job(-3); j.Status != domain.ReminderSent || j.Attempts != 1 {
+		t.Errorf("job after sending: %+v", j)
+	}
+
+	// Everyone answers before the last reminder.
+	s.rsvp(s.event.ID, "u2", domain.RSVPNo)
+	s.rsvp(s.event.ID, "admin", domain.RSVPGo)
+	s.clock.Set(s.event.StartAt.Add(-1 * day))
+	s.run(usecase.ReminderRun{Skipped: 1})
+	if j := s.job(-1); j.Status != domain.ReminderSkipped || j.Note != "everyone has responded" {
+		t.Errorf("job when everyone responded: %+v", j)
+	}
+}
+
+func TestRunRemindersSendsOnlyTheLatestOfSeveralDue(t *testing.T) {
+	s := newReminderSetup(t)
+	s.run(usecase.ReminderRun{Planned: 2})
+
+	// The scheduler was down while both reminders came due.
+	s.clock.Set(s.event.StartAt.Add(-1 * day).Add(time.Hour))
+	s.run(usecase.ReminderRun{Sent: 1, Skipped: 1})
+	if j := s.job(-3); j.Status != domain.ReminderSkipped || j.Note != "superseded by a later reminder" {
+		t.Errorf("earlier job: %+v", j)
+	}
+	if j := s.job(-1); j.Status != domain.ReminderSent {
+		t.Errorf("latest job: %+v", j)
+	}
+}
+
+func TestRunRemindersFollowEventTimeChanges(t *testing.T) {
+	s := newReminderSetup(t)
+	s.run(usecase.ReminderRun{Planned: 2})
+	oldStart := s.event.StartAt
+
+	// A pending reminder moves with the event.
+	s.moveEvent(oldStart.Add(2 * day))
+	s.clock.Set(oldStart.Add(-3 * day))
+	s.run(usecase.ReminderRun{})
+	s.clock.Set(s.event.StartAt.Add(-3 * day))
+	s.run(usecase.ReminderRun{Sent: 1})
+
+	// A sent reminder is armed again when the event moves further ahead.
+	s.moveEvent(s.event.StartAt.Add(5 * day))
+	s.run(usecase.ReminderRun{})
+	if j := s.job(-3); j.Status != domain.ReminderPending || !j.DueAt.Equal(s.event.StartAt.Add(-3*day)) {
+		t.Fatalf("job after the event moved: %+v", j)
+	}
+	s.clock.Set(s.event.StartAt.Add(-3 * day))
+	s.run(usecase.ReminderRun{Sent: 1})
+	if n := len(s.reminded()); n != 2 {
+		t.Errorf("got %d reminders, want 2", n)
+	}
+}
+
+func TestRunRemindersRetriesWithBackoff(t *testing.T) {
+	s := newReminderSetup(t)
+	s.run(usecase.ReminderRun{Planned: 2})
+	errDown := errors.New("smtp down")
+	s.notifier.err = errDown
+
+	s.clock.Set(s.event.StartAt.Add(-3 * day))
+	for attempt := 1; attempt <= usecase.MaxReminderAttempts; attempt++ {
+		run, err := s.reminders(s.clock).RunReminders(s.ctx)
+		wantErr(t, err, errDown)
+		j := s.job(-3)
+		if j.Attempts != attempt || j.Note != errDown.Error() {
+			t.Fatalf("attempt %d: job %+v", attempt, j)
+		}
+		if attempt == usecase.MaxReminderAttempts {
+			if j.Status != domain.ReminderFailed || run.Failed != 1 {
+				t.Fatalf("job after the last attempt: %+v, run %+v", j, run)
+			}
+			break
+		}
+		delay := usecase.ReminderRetryDelay << (attempt - 1)
+		if j.Status != domain.ReminderPending || !j.RetryAt.Equal(s.clock.Now().Add(delay)) {
+			t.Fatalf("attempt %d: job %+v, want a retry after %v", attempt, j, delay)
+		}
+
+		// Nothing is tried again before the backoff has passed.
+		s.clock.Advance(delay - time.Minute)
+		s.run(usecase.ReminderRun{})
+		s.clock.Advance(time.Minute)
+	}
+
+	s.notifier.err = nil
+	s.clock.Advance(time.Hour)
+	s.run(usecase.ReminderRun{})
+	if n := len(s.reminded()); n != 0 {
+		t.Errorf("a failed reminder was sent %d times", n)
+	}
+}
+
+func TestRunRemindersSkipsWhatThePolicyNoLongerWants(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy domain.ReminderPolicy
+		note   string
+	}{
+		{"disabled", domain.ReminderPolicy{Enabled: false, RSVPDaysBefore: []int{3, 1}}, "reminders are disabled"},
+		{"offset removed", domain.ReminderPolicy{Enabled: true, RSVPDaysBefore: []int{1}}, "no longer in the reminder policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newReminderSetup(t)
+			s.run(usecase.ReminderRun{Planned: 2})
+
+			p := tt.policy
+			p.CircleID = s.circleID
+			if _, err := s.reminders(s.clock).UpdatePolicy(s.ctx, &p, "admin"); err != nil {
+				t.Fatal(err)
+			}
+			s.clock.Set(s.event.StartAt.Add(-3 * day))
+			s.run(usecase.ReminderRun{Skipped: 1})
+			if j := s.job(-3); j.Status != domain.ReminderSkipped || j.Note != tt.note {
+				t.Errorf("job: %+v, want skipped with %q", j, tt.note)
+			}
+			if n := len(s.reminded()); n != 0 {
+				t.Errorf("got %d reminders, want none", n)
+			}
+		})
+	}
+}
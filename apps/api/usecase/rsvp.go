@@ -2,7 +2,8 @@ package usecase
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/noa/circle-app/api/domain"
@@ -13,22 +14,51 @@ import (
 type RSVPInteractor struct {
 	rsvpRepo       port.RSVPRepository
 	eventRepo      port.EventRepository
-	settlementRepo port.SettlementRepository
-	paymentRepo    port.PaymentRepository
+	membershipRepo port.MembershipRepository
+	userRepo       port.UserRepository
+	auditRepo      port.AuditLogRepository
+	seats          *eventSeats
+	tx             port.Transactor
+	authz          *Authorizer
 }
 
 // NewRSVPInteractor creates a new RSVPInteractor.
-func NewRSVPInteractor(rsvpRepo port.RSVPRepository, eventRepo port.EventRepository, settlementRepo port.SettlementRepository, paymentRepo port.PaymentRepository) *RSVPInteractor {
+func NewRSVPInteractor(
+	rsvpRepo port.RSVPRepository,
+	eventRepo port.EventRepository,
+	membershipRepo port.MembershipRepository,
+	userRepo port.UserRepository,
+	settlementRepo port.SettlementRepository,
+	paymentRepo port.PaymentRepository,
+	auditRepo port.AuditLogRepository,
+	tx port.Transactor,
+	notifier port.Notifier,
+	authz *Authorizer,
+) *RSVPInteractor {
 	return &RSVPInteractor{
 		rsvpRepo:       rsvpRepo,
 		eventRepo:      eventRepo,
-		settlementRepo: settlementRepo,
-		paymentRepo:    paymentRepo,
+		membershipRepo: membershipRepo,
+		userRepo:       userRepo,
+		auditRepo:      auditRepo,
+		seats: &eventSeats{
+			rsvpRepo:       rsvpRepo,
+			settlementRepo: settlementRepo,
+			paymentRepo:    paymentRepo,
+			pricer:         &settlementPricer{membershipRepo: membershipRepo, rsvpRepo: rsvpRepo},
+			notifier:       notifier,
+		},
+		tx:    tx,
+		authz: authz,
 	}
 }
 
-// isTargetUser checks if user is in target list.
+// isTargetUser checks if user is in target list. An empty list targets every
+// member of the circle.
 func isTargetUser(userID string, targetUserIDs []string) bool {
+	if len(targetUserIDs) == 0 {
+		return true
+	}
 	for _, id := range targetUserIDs {
 		if id == userID {
 			return true
@@ -37,90 +67,137 @@ func isTargetUser(userID string, targetUserIDs []string) bool {
 	return false
 }
 
-// SubmitRSVP submits or updates RSVP.
+// SubmitRSVP submits or updates the user's own RSVP. Members targeted by the
+// event can respond until the RSVP deadline; admins can still change
+// their RSVP afterwards, which is recorded in the audit log. When the event is
+// full, attending responses are waitlisted; when someone with a seat
+// declines, the first waitlisted members are promoted.
 func (i *RSVPInteractor) SubmitRSVP(ctx context.Context, eventID, userID string, status domain.RSVPStatus, note string) (*domain.RSVP, error) {
-	// Verify event exists
-	_, err := i.eventRepo.GetByID(ctx, eventID)
-	if err != nil {
-		return nil, err
-	}
+	return i.submit(ctx, eventID, userID, status, note, "", userID)
+}
 
-	rsvp := &domain.RSVP{
-		EventID:   eventID,
-		UserID:    userID,
-		Status:    status,
-		Note:      note,
-		UpdatedAt: time.Now(),
-	}
+// OverrideRSVP sets the RSVP of a member on their behalf, for example when
+// they answered in person. Changes after the RSVP deadline need a reason,
+// which is recorded in the audit log. Only circle admins can override RSVPs.
+func (i *RSVPInteractor) OverrideRSVP(ctx context.Context, eventID, userID string, status domain.RSVPStatus, note, reason, actorID string) (*domain.RSVP, error) {
+	return i.submit(ctx, eventID, userID, status, note, reason, actorID)
+}
 
-	if err := i.rsvpRepo.Upsert(ctx, rsvp); err != nil {
-		return nil, err
+func (i *RSVPInteractor) submit(ctx context.Context, eventID, userID string, status domain.RSVPStatus, note, reason, actorID string) (*domain.RSVP, error) {
+	if !status.Valid() {
+		return nil, domain.NewValidationError("status", "must be one of GO, NO, LATE, EARLY")
 	}
 
-	// If user is now attending (GO/LATE/EARLY), auto-create payment records
-	// for any existing settlements on this event
-	if status == domain.RSVPGo || status == domain.RSVPLate || status == domain.RSVPEarly {
-		go i.ensurePaymentRecords(context.Background(), eventID, userID)
-	} else if status == domain.RSVPNo {
-		// Remove unpaid payment records when user declines
-		go i.removePaymentRecords(context.Background(), eventID, userID)
+	// Verify event exists
+	event, err := i.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
 	}
-
-	return rsvp, nil
-}
-
-// ensurePaymentRecords creates payment records for existing settlements if missing.
-func (i *RSVPInteractor) ensurePaymentRecords(ctx context.Context, eventID, userID string) {
-	settlements, err := i.settlementRepo.GetByEvent(ctx, eventID)
+	actor, err := i.authz.RequireMember(ctx, event.CircleID, actorID)
 	if err != nil {
-		log.Printf("Failed to get settlements for event %s: %v", eventID, err)
-		return
+		return nil, err
+	}
+	isAdmin := actor.Role == domain.RoleAdmin
+	if userID != actorID {
+		if !isAdmin {
+			return nil, domain.ErrForbidden
+		}
+		if err := i.authz.RequireReadAccess(ctx, event.CircleID, userID); err != nil {
+			return nil, err
+		}
 	}
 
-	for _, settlement := range settlements {
-		// Check if payment already exists
-		existing, err := i.paymentRepo.GetBySettlementAndUser(ctx, settlement.ID, userID)
+	var rsvp *domain.RSVP
+	var promoted []*domain.RSVP
+	// The RSVPs and payment records change together, so a failed sync never
+	// leaves them disagreeing and concurrent responses cannot overbook.
+	err = i.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		// Re-read the event so a concurrent capacity or deadline change is seen.
+		event, err = i.eventRepo.GetByID(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		if !isTargetUser(userID, event.RSVPTargetUserIDs) {
+			return fmt.Errorf("%w: user is not a target of this event", domain.ErrForbidden)
+		}
+		now := time.Now()
+		closed := !now.Before(event.RSVPClosesAt())
+		if closed && !isAdmin {
+			return fmt.Errorf("%w: RSVPs closed at %s", domain.ErrPreconditionFailed,
+				event.RSVPClosesAt().In(domain.Location).Format("2006-01-02 15:04"))
+		}
+		if closed && userID != actorID && strings.TrimSpace(reason) == "" {
+			return domain.NewValidationError("reason", "is required after the RSVP deadline")
+		}
+		rsvps, err := i.rsvpRepo.GetByEvent(ctx, eventID)
 		if err != nil {
-			log.Printf("Failed to check payment for settlement %s, user %s: %v", settlement.ID, userID, err)
-			continue
+			return err
 		}
-		if existing != nil {
-			continue // Already has a payment record
+		var before string
+		for _, r := range rsvps {
+			if r.UserID == userID {
+				before = string(r.Status)
+			}
 		}
 
-		// Create payment record
-		payment := &domain.Payment{
-			SettlementID: settlement.ID,
-			UserID:       userID,
-			Status:       domain.PaymentUnpaid,
+		rsvp = &domain.RSVP{
+			EventID:   eventID,
+			UserID:    userID,
+			Status:    status,
+			Note:      note,
+			UpdatedAt: now,
+		}
+		promoted = seat(event.Capacity, rsvps, rsvp, now)
+		if err := i.seats.save(ctx, eventID, append([]*domain.RSVP{rsvp}, promoted...)); err != nil {
+			return err
 		}
-		if err := i.paymentRepo.Create(ctx, payment); err != nil {
-			log.Printf("Failed to create payment for settlement %s, user %s: %v", settlement.ID, userID, err)
+		if !closed {
+			return nil
 		}
+		return i.auditRepo.Create(ctx, &domain.AuditEntry{
+			CircleID:   event.CircleID,
+			Action:     domain.AuditRSVPOverride,
+			TargetType: domain.AuditTargetEvent,
+			TargetID:   eventID,
+			UserID:     userID,
+			Before:     before,
+			After:      string(status),
+			Reason:     reason,
+			ActorID:    actorID,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	i.seats.notifyPromoted(ctx, event, promoted)
+	return rsvp, nil
 }
 
-// removePaymentRecords removes payment records when user declines attending.
-func (i *RSVPInteractor) removePaymentRecords(ctx context.Context, eventID, userID string) {
-	settlements, err := i.settlementRepo.GetByEvent(ctx, eventID)
+// requireEventReader loads an event and checks that userID can read its circle.
+func (i *RSVPInteractor) requireEventReader(ctx context.Context, eventID, userID string) (*domain.Event, error) {
+	event, err := i.eventRepo.GetByID(ctx, eventID)
 	if err != nil {
-		log.Printf("Failed to get settlements for event %s: %v", eventID, err)
-		return
+		return nil, err
 	}
-
-	for _, settlement := range settlements {
-		if err := i.paymentRepo.DeleteBySettlementAndUser(ctx, settlement.ID, userID); err != nil {
-			log.Printf("Failed to delete payment for settlement %s, user %s: %v", settlement.ID, userID, err)
-		}
+	if err := i.authz.RequireReadAccess(ctx, event.CircleID, userID); err != nil {
+		return nil, err
 	}
+	return event, nil
 }
 
 // GetMyRSVP returns user's RSVP for an event.
 func (i *RSVPInteractor) GetMyRSVP(ctx context.Context, eventID, userID string) (*domain.RSVP, error) {
+	if _, err := i.requireEventReader(ctx, eventID, userID); err != nil {
+		return nil, err
+	}
 	return i.rsvpRepo.GetByEventAndUser(ctx, eventID, userID)
 }
 
 // GetEventRSVPs returns all RSVPs for an event.
-func (i *RSVPInteractor) GetEventRSVPs(ctx context.Context, eventID string) ([]*domain.RSVP, error) {
+func (i *RSVPInteractor) GetEventRSVPs(ctx context.Context, eventID, userID string) ([]*domain.RSVP, error) {
+	if _, err := i.requireEventReader(ctx, eventID, userID); err != nil {
+		return nil, err
+	}
 	return i.rsvpRepo.GetByEvent(ctx, eventID)
 }
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// RSVPMember is a targeted member with their display info.
+type RSVPMember struct {
+	UserID    string `json:"userId"`
+	UserName  string `json:"userName"`
+	AvatarURL string `json:"avatarUrl,omitempty"`
+}
+
+// RSVPSummary aggregates the responses of an event's targeted members.
+type RSVPSummary struct {
+	TargetCount    int                       `json:"targetCount"`
+	RespondedCount int                       `json:"respondedCount"`
+	ByStatus       map[domain.RSVPStatus]int `json:"byStatus"`
+	WaitlistCount  int                       `json:"waitlistCount"` // attending responses without a seat
+	NonResponders  []RSVPMember              `json:"nonResponders"`
+}
+
+// eventTargets returns the user IDs expected to respond to an event: its RSVP
+// targets, or every current member of its circle when it has none.
+func eventTargets(ctx context.Context, membershipRepo port.MembershipRepository, event *domain.Event) ([]string, error) {
+	if len(event.RSVPTargetUserIDs) > 0 {
+		return event.RSVPTargetUserIDs, nil
+	}
+	memberships, err := membershipRepo.GetByCircle(ctx, event.CircleID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		ids = append(ids, m.UserID)
+	}
+	return ids, nil
+}
+
+// GetRSVPSummary counts the responses of an event's targeted members by
+// status and lists the targeted members who have not responded yet, by name.
+// Responses of users who are no longer targeted are left out.
+func (i *RSVPInteractor) GetRSVPSummary(ctx context.Context, eventID, userID string) (*RSVPSummary, error) {
+	event, err := i.requireEventReader(ctx, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := eventTargets(ctx, i.membershipRepo, event)
+	if err != nil {
+		return nil, err
+	}
+	rsvps, err := i.rsvpRepo.GetByEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[string]*domain.RSVP, len(rsvps))
+	for _, r := range rsvps {
+		byUser[r.UserID] = r
+	}
+
+	sum := &RSVPSummary{
+		TargetCount: len(targets),
+		ByStatus: map[domain.RSVPStatus]int{
+			domain.RSVPGo:    0,
+			domain.RSVPNo:    0,
+			domain.RSVPLate:  0,
+			domain.RSVPEarly: 0,
+		},
+		NonResponders: []RSVPMember{},
+	}
+	for _, id := range targets {
+		r, ok := byUser[id]
+		if !ok {
+			sum.NonResponders = append(sum.NonResponders, i.member(ctx, id))
+			continue
+		}
+		sum.RespondedCount++
+		sum.ByStatus[r.Status]++
+		if r.Waitlisted && r.Status.Attending() {
+			sum.WaitlistCount++
+		}
+	}
+	sort.SliceStable(sum.NonResponders, func(a, b int) bool {
+		return sum.NonResponders[a].UserName < sum.NonResponders[b].UserName
+	})
+	return sum, nil
+}
+
+// member returns the display info of a user, or just the ID if the user
+// cannot be loaded.
+func (i *RSVPInteractor) member(ctx context.Context, userID string) RSVPMember {
+	m := RSVPMember{UserID: userID}
+	user, err := i.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		log.Printf("Warning: could not find user %s: %v", userID, err)
+		return m
+	}
+	m.UserName = user.Name
+	m.AvatarURL = user.AvatarURL
+	return m
+}
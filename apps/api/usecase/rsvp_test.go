@@ -0,0 +1,178 @@
+package usecase_test
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+)
+
+func TestSubmitRSVPWaitlistsAndPromotesInOrder(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2", "u3")
+	e := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 1, time.Time{})
+
+	if r := f.rsvp(e.ID, "u1", domain.RSVPGo); r.Waitlisted {
+		t.Fatal("u1 was waitlisted with a free seat")
+	}
+	f.rsvp(e.ID, "u2", domain.RSVPLate)
+	f.rsvp(e.ID, "u3", domain.RSVPGo)
+
+	f.rsvp(e.ID, "u1", domain.RSVPNo)
+	for userID, want := range map[string]bool{"u2": false, "u3": true} {
+		r, err := f.repos.RSVP.GetByEventAndUser(f.ctx, e.ID, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Waitlisted != want {
+			t.Errorf("%s waitlisted = %v, want %v", userID, r.Waitlisted, want)
+		}
+	}
+	sent := f.notifier.titled("参加確定のお知らせ")
+	if len(sent) != 1 {
+		t.Fatalf("got %d promotion notifications, want 1", len(sent))
+	}
+	wantUsers(t, sent[0].UserIDs, []string{"u2"})
+}
+
+func TestSubmitRSVPChecksTargetsAndStatus(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	e, err := f.events().CreateEvent(f.ctx, circleID, "合宿", time.Now().Add(48*time.Hour), "", "", []string{"u1"}, 0, time.Time{}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.rsvps().SubmitRSVP(f.ctx, e.ID, "u2", domain.RSVPGo, "")
+	wantErr(t, err, domain.ErrForbidden)
+	_, err = f.rsvps().SubmitRSVP(f.ctx, e.ID, "stranger", domain.RSVPGo, "")
+	wantErr(t, err, domain.ErrNotAuthorized)
+	_, err = f.rsvps().SubmitRSVP(f.ctx, e.ID, "u1", "MAYBE", "")
+	wantErr(t, err, domain.ErrInvalidInput)
+	_, err = f.rsvps().OverrideRSVP(f.ctx, e.ID, "u1", domain.RSVPGo, "", "", "u2")
+	wantErr(t, err, domain.ErrForbidden)
+}
+
+func TestRSVPAfterDeadline(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1")
+	e := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 0, time.Now().Add(-time.Hour))
+
+	_, err := f.rsvps().SubmitRSVP(f.ctx, e.ID, "u1", domain.RSVPGo, "")
+	wantErr(t, err, domain.ErrPreconditionFailed)
+	_, err = f.rsvps().OverrideRSVP(f.ctx, e.ID, "u1", domain.RSVPGo, "", " ", "admin")
+	wantErr(t, err, domain.ErrInvalidInput)
+
+	if _, err := f.rsvps().OverrideRSVP(f.ctx, e.ID, "u1", domain.RSVPGo, "", "電話で連絡あり", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := f.repos.AuditLog.GetByCircle(f.ctx, circleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d audit entries, want 1", len(entries))
+	}
+	got := entries[0]
+	if got.Action != domain.AuditRSVPOverride || got.UserID != "u1" || got.ActorID != "admin" || got.After != "GO" || got.Reason != "電話で連絡あり" {
+		t.Errorf("unexpected audit entry %+v", got)
+	}
+}
+
+func TestRSVPSyncsPaymentsOfEventSettlements(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1")
+	e := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 0, time.Time{})
+	s := &domain.Settlement{CircleID: circleID, EventID: e.ID, Title: "合宿費", Amount: 3000, TargetUserIDs: []string{"admin"}}
+	if err := f.repos.Settlement.Create(f.ctx, s); err != nil {
+		t.Fatal(err)
+	}
+
+	f.rsvp(e.ID, "u1", domain.RSVPGo)
+	p, err := f.repos.Payment.GetBySettlementAndUser(f.ctx, s.ID, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.Status != domain.PaymentUnpaid || p.Owed(s) != 3000 {
+		t.Fatalf("payment after GO = %+v, want unpaid 3000", p)
+	}
+
+	f.rsvp(e.ID, "u1", domain.RSVPNo)
+	if p, _ := f.repos.Payment.GetBySettlementAndUser(f.ctx, s.ID, "u1"); p != nil {
+		t.Errorf("unpaid payment kept after NO: %+v", p)
+	}
+}
+
+func TestGetRSVPSummaryCountsResponsesOfTargets(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2", "u3", "u4", "u5")
+	for _, u := range []*domain.User{{ID: "admin", Name: "部長"}, {ID: "u5", Name: "鈴木"}} {
+		if err := f.repos.User.Create(f.ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 2, time.Time{})
+
+	f.rsvp(e.ID, "u1", domain.RSVPGo)
+	f.rsvp(e.ID, "u2", domain.RSVPLate)
+	f.rsvp(e.ID, "u3", domain.RSVPEarly) // waitlisted
+	f.rsvp(e.ID, "u4", domain.RSVPGo)    // waitlisted
+	// An admin's override replaces the member's own answer and frees a seat
+	// for u3; overriding u5, who never answered, counts as a response.
+	for userID, status := range map[string]domain.RSVPStatus{"u1": domain.RSVPNo, "u5": domain.RSVPGo} {
+		if _, err := f.rsvps().OverrideRSVP(f.ctx, e.ID, userID, status, "", "", "admin"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sum, err := f.rsvps().GetRSVPSummary(f.ctx, e.ID, "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.TargetCount != 6 || sum.RespondedCount != 5 {
+		t.Errorf("targets %d, responded %d; want 6 and 5", sum.TargetCount, sum.RespondedCount)
+	}
+	want := map[domain.RSVPStatus]int{domain.RSVPGo: 2, domain.RSVPNo: 1, domain.RSVPLate: 1, domain.RSVPEarly: 1}
+	if !maps.Equal(sum.ByStatus, want) {
+		t.Errorf("by status = %v, want %v", sum.ByStatus, want)
+	}
+	// Waitlisted responses count under their status and in WaitlistCount.
+	if sum.WaitlistCount != 2 {
+		t.Errorf("waitlisted = %d, want 2 (u4 and u5)", sum.WaitlistCount)
+	}
+	if len(sum.NonResponders) != 1 || sum.NonResponders[0] != (usecase.RSVPMember{UserID: "admin", UserName: "部長"}) {
+		t.Errorf("non-responders = %+v, want only the admin", sum.NonResponders)
+	}
+
+	_, err = f.rsvps().GetRSVPSummary(f.ctx, e.ID, "stranger")
+	wantErr(t, err, domain.ErrNotFound)
+}
+
+func TestGetRSVPSummaryLeavesOutFormerTargets(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2", "u3")
+	e, err := f.events().CreateEvent(f.ctx, circleID, "合宿", time.Now().Add(48*time.Hour), "", "", []string{"u1", "u2", "u3"}, 0, time.Time{}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.rsvp(e.ID, "u1", domain.RSVPGo)
+	f.rsvp(e.ID, "u3", domain.RSVPNo)
+	e.RSVPTargetUserIDs = []string{"u1", "u2"}
+	if err := f.repos.Event.Update(f.ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := f.rsvps().GetRSVPSummary(f.ctx, e.ID, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[domain.RSVPStatus]int{domain.RSVPGo: 1, domain.RSVPNo: 0, domain.RSVPLate: 0, domain.RSVPEarly: 0}
+	if sum.TargetCount != 2 || sum.RespondedCount != 1 || !maps.Equal(sum.ByStatus, want) {
+		t.Errorf("summary = %+v, want u3's response left out", sum)
+	}
+	if len(sum.NonResponders) != 1 || sum.NonResponders[0].UserID != "u2" {
+		t.Errorf("non-responders = %+v, want u2", sum.NonResponders)
+	}
+}
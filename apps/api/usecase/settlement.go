@@ -2,6 +2,11 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/noa/circle-app/api/domain"
@@ -12,18 +17,121 @@ import (
 type SettlementInteractor struct {
 	settlementRepo port.SettlementRepository
 	paymentRepo    port.PaymentRepository
+	eventRepo      port.EventRepository
+	userRepo       port.UserRepository
+	rsvpRepo       port.RSVPRepository
+	pricer         *settlementPricer
+	tx             port.Transactor
+	notifier       port.Notifier
+	authz          *Authorizer
 }
 
 // NewSettlementInteractor creates a new SettlementInteractor.
-func NewSettlementInteractor(settlementRepo port.SettlementRepository, paymentRepo port.PaymentRepository) *SettlementInteractor {
+func NewSettlementInteractor(settlementRepo port.SettlementRepository, paymentRepo port.PaymentRepository, eventRepo port.EventRepository, userRepo port.UserRepository, membershipRepo port.MembershipRepository, rsvpRepo port.RSVPRepository, tx port.Transactor, notifier port.Notifier, authz *Authorizer) *SettlementInteractor {
 	return &SettlementInteractor{
 		settlementRepo: settlementRepo,
 		paymentRepo:    paymentRepo,
+		eventRepo:      eventRepo,
+		userRepo:       userRepo,
+		rsvpRepo:       rsvpRepo,
+		pricer:         &settlementPricer{membershipRepo: membershipRepo, rsvpRepo: rsvpRepo},
+		tx:             tx,
+		notifier:       notifier,
+		authz:          authz,
 	}
 }
 
+// notifySettlement asks the targets of a new settlement to pay what they
+// owe, except the admin who created it and targets who owe nothing.
+func notifySettlement(ctx context.Context, notifier port.Notifier, s *domain.Settlement, owed func(userID string) int, actorID string) {
+	byAmount := make(map[int][]string)
+	var amounts []int
+	for _, userID := range without(s.TargetUserIDs, actorID) {
+		amount := owed(userID)
+		if amount == 0 {
+			continue
+		}
+		if _, ok := byAmount[amount]; !ok {
+			amounts = append(amounts, amount)
+		}
+		byAmount[amount] = append(byAmount[amount], userID)
+	}
+	for _, amount := range amounts {
+		n := &domain.Notification{
+			UserIDs:  byAmount[amount],
+			CircleID: s.CircleID,
+			Title:    "清算のお知らせ",
+			Body:     fmt.Sprintf("「%s」%d円の支払いをお願いします（期限 %s）", s.Title, amount, formatSessionDate(s.DueAt)),
+		}
+		if err := notifier.Notify(ctx, n); err != nil {
+			log.Printf("Warning: could not notify targets of settlement %s: %v", s.ID, err)
+		}
+	}
+}
+
+// lineItemsAmount checks the line items of a settlement for its targets and
+// returns the amount of the settlement: their sum, or amount without line
+// items. With line items, a requested amount must match their sum.
+func lineItemsAmount(items []domain.SettlementLineItem, targetUserIDs []string, amount int) (int, error) {
+	if len(items) == 0 {
+		if amount < 1 {
+			return 0, domain.NewValidationError("amount", "must be at least 1")
+		}
+		return amount, nil
+	}
+
+	verr := &domain.ValidationError{}
+	for i, li := range items {
+		userIDs := make([]string, 0, len(li.Overrides))
+		for userID := range li.Overrides {
+			userIDs = append(userIDs, userID)
+		}
+		sort.Strings(userIDs)
+		for _, userID := range userIDs {
+			field := fmt.Sprintf("lineItems[%d].overrides.%s", i, userID)
+			if !slices.Contains(targetUserIDs, userID) {
+				verr.Add(field, "is not a target of the settlement")
+			} else if li.Overrides[userID] < 0 {
+				verr.Add(field, "must not be negative")
+			}
+		}
+	}
+	sum := domain.SumLineItems(items)
+	if amount != 0 && amount != sum {
+		verr.Add("amount", fmt.Sprintf("must be omitted or match the sum of lineItems (%d)", sum))
+	}
+	if err := verr.ErrOrNil(); err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
 // CreateSettlement creates a new settlement and payment records for each target user.
-func (i *SettlementInteractor) CreateSettlement(ctx context.Context, circleID, eventID, title string, amount int, dueAt time.Time, targetUserIDs []string, bankInfo, paypayInfo string) (*domain.Settlement, error) {
+// With line items, the amount is their sum and targets may owe different
+// amounts through overrides; pricing rules then set each payment's amount.
+// Only circle admins can create settlements.
+func (i *SettlementInteractor) CreateSettlement(ctx context.Context, circleID, eventID, title string, amount int, dueAt time.Time, targetUserIDs []string, bankInfo, paypayInfo string, lineItems []domain.SettlementLineItem, pricingRules []domain.PricingRule, actorID string) (*domain.Settlement, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, actorID); err != nil {
+		return nil, err
+	}
+	if eventID != "" {
+		// An event of another circle is reported like a missing one.
+		event, err := i.eventRepo.GetByID(ctx, eventID)
+		if err != nil {
+			return nil, err
+		}
+		if event.CircleID != circleID {
+			return nil, domain.ErrNotFound
+		}
+	}
+	amount, err := lineItemsAmount(lineItems, targetUserIDs, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePricingRules(pricingRules, targetUserIDs, eventID); err != nil {
+		return nil, err
+	}
+
 	settlement := &domain.Settlement{
 		CircleID:      circleID,
 		EventID:       eventID,
@@ -33,41 +141,108 @@ func (i *SettlementInteractor) CreateSettlement(ctx context.Context, circleID, e
 		TargetUserIDs: targetUserIDs,
 		BankInfo:      bankInfo,
 		PayPayInfo:    paypayInfo,
+		LineItems:     lineItems,
+		PricingRules:  pricingRules,
 		CreatedAt:     time.Now(),
 	}
-
-	if err := i.settlementRepo.Create(ctx, settlement); err != nil {
+	if err := i.create(ctx, settlement, actorID); err != nil {
 		return nil, err
 	}
+	return settlement, nil
+}
 
-	// Create payment records for each target user
-	for _, userID := range targetUserIDs {
-		payment := &domain.Payment{
-			SettlementID: settlement.ID,
-			UserID:       userID,
-			Status:       domain.PaymentUnpaid,
+// create saves a new settlement with a payment of what each target owes and
+// notifies the targets. The settlement and its payments are saved together,
+// so a failed payment leaves no settlement that some targets cannot pay.
+func (i *SettlementInteractor) create(ctx context.Context, settlement *domain.Settlement, actorID string) error {
+	var owed func(userID string) int
+	err := i.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		subjects, err := i.pricer.subjects(ctx, settlement, settlement.TargetUserIDs)
+		if err != nil {
+			return err
 		}
-		if err := i.paymentRepo.Create(ctx, payment); err != nil {
-			// Log error but continue
-			continue
+		owed = func(userID string) int {
+			return settlement.PriceFor(subjects[userID])
+		}
+
+		if err := i.settlementRepo.Create(ctx, settlement); err != nil {
+			return err
 		}
+		for _, userID := range settlement.TargetUserIDs {
+			amount := owed(userID)
+			payment := &domain.Payment{
+				SettlementID: settlement.ID,
+				UserID:       userID,
+				Status:       domain.PaymentUnpaid,
+				Amount:       &amount,
+			}
+			if err := i.paymentRepo.Create(ctx, payment); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return settlement, nil
+	notifySettlement(ctx, i.notifier, settlement, owed, actorID)
+	return nil
 }
 
-// GetByEvent returns all settlements for an event.
-func (i *SettlementInteractor) GetByEvent(ctx context.Context, eventID string) ([]*domain.Settlement, error) {
-	return i.settlementRepo.GetByEvent(ctx, eventID)
+// GetByEvent returns all settlements for an event. Admins get them whole;
+// for other members, Amount is what the member owes, line items are the
+// member's breakdown and pricing rules are left out, so that what other
+// targets owe stays with the admins.
+func (i *SettlementInteractor) GetByEvent(ctx context.Context, eventID, userID string) ([]*domain.Settlement, error) {
+	event, err := i.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := i.authz.RequireMember(ctx, event.CircleID, userID)
+	if errors.Is(err, domain.ErrNotAuthorized) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	settlements, err := i.settlementRepo.GetByEvent(ctx, eventID)
+	if err != nil || membership.Role == domain.RoleAdmin {
+		return settlements, err
+	}
+
+	for _, s := range settlements {
+		payment, err := i.paymentRepo.GetBySettlementAndUser(ctx, s.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if payment != nil {
+			s.Amount = payment.Owed(s)
+		} else {
+			s.Amount = s.AmountFor(userID)
+		}
+		s.LineItems = s.BreakdownFor(userID)
+		s.PricingRules = nil
+	}
+	return settlements, nil
 }
 
 // SettlementWithPayment combines settlement with user's payment status.
 type SettlementWithPayment struct {
 	Settlement *domain.Settlement `json:"settlement"`
 	Payment    *domain.Payment    `json:"payment,omitempty"`
+	// Amount is what the user owes, and Breakdown the line items as they
+	// apply to the user. Adjustment is what pricing rules changed, such as
+	// -1000 for a discount.
+	Amount     int                         `json:"amount"`
+	Breakdown  []domain.SettlementLineItem `json:"breakdown,omitempty"`
+	Adjustment int                         `json:"adjustment,omitempty"`
 }
 
-// GetMySettlements returns settlements for a user with their payment status.
+// GetMySettlements returns settlements for a user with their payment status
+// and what they owe. Line items are returned only as the user's breakdown and
+// pricing rules not at all, so that what other targets owe stays with the
+// admins.
 func (i *SettlementInteractor) GetMySettlements(ctx context.Context, userID string) ([]SettlementWithPayment, error) {
 	payments, err := i.paymentRepo.GetByUser(ctx, userID)
 	if err != nil {
@@ -80,17 +255,37 @@ func (i *SettlementInteractor) GetMySettlements(ctx context.Context, userID stri
 		if err != nil {
 			continue
 		}
+		breakdown := settlement.BreakdownFor(userID)
+		amount := payment.Owed(settlement)
+		adjustment := amount - settlement.AmountFor(userID)
+		settlement.LineItems = nil
+		settlement.PricingRules = nil
 		results = append(results, SettlementWithPayment{
 			Settlement: settlement,
 			Payment:    payment,
+			Amount:     amount,
+			Breakdown:  breakdown,
+			Adjustment: adjustment,
 		})
 	}
 
 	return results, nil
 }
 
-// ReportPayment reports a payment.
+// ReportPayment reports the user's own payment for a settlement.
 func (i *SettlementInteractor) ReportPayment(ctx context.Context, settlementID, userID string, method domain.PaymentMethod, note string) (*domain.Payment, error) {
+	if !method.Valid() {
+		return nil, domain.NewValidationError("method", "must be one of BANK, PAYPAY")
+	}
+
+	settlement, err := i.settlementRepo.GetByID(ctx, settlementID)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.authz.RequireReadAccess(ctx, settlement.CircleID, userID); err != nil {
+		return nil, err
+	}
+
 	// Get payment record for this user
 	payment, err := i.paymentRepo.GetBySettlementAndUser(ctx, settlementID, userID)
 	if err != nil {
@@ -99,12 +294,18 @@ func (i *SettlementInteractor) ReportPayment(ctx context.Context, settlementID,
 	if payment == nil {
 		return nil, domain.ErrNotFound
 	}
+	if payment.Status == domain.PaymentConfirmed {
+		return nil, fmt.Errorf("%w: payment already confirmed", domain.ErrConflict)
+	}
 
 	// Update payment
 	payment.Status = domain.PaymentPaidReported
 	payment.Method = method
 	payment.Note = note
 	payment.ReportedAt = time.Now()
+	payment.RejectionReason = ""
+	payment.RejectedBy = ""
+	payment.RejectedAt = time.Time{}
 
 	if err := i.paymentRepo.Update(ctx, payment); err != nil {
 		return nil, err
@@ -113,20 +314,222 @@ func (i *SettlementInteractor) ReportPayment(ctx context.Context, settlementID,
 	return payment, nil
 }
 
-// UpdateSettlement updates a settlement's title, amount, and dueAt.
-func (i *SettlementInteractor) UpdateSettlement(ctx context.Context, settlementID, title string, amount int, dueAt time.Time) (*domain.Settlement, error) {
-	settlement, err := i.settlementRepo.GetByID(ctx, settlementID)
+// UpdateSettlement updates a settlement's title, amount or line items, and
+// dueAt, and reprices its unpaid payments. The amount of a settlement with
+// line items changes only with new line items. Only circle admins can update
+// settlements.
+func (i *SettlementInteractor) UpdateSettlement(ctx context.Context, settlementID, title string, amount int, lineItems []domain.SettlementLineItem, dueAt time.Time, actorID string) (*domain.Settlement, error) {
+	var settlement *domain.Settlement
+	err := i.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		settlement, err = i.requireSettlementAdmin(ctx, settlementID, actorID)
+		if err != nil {
+			return err
+		}
+		return i.update(ctx, settlement, title, amount, lineItems, dueAt)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return settlement, nil
+}
+
+// update applies the changes of UpdateSettlement to settlement and saves it.
+func (i *SettlementInteractor) update(ctx context.Context, settlement *domain.Settlement, title string, amount int, lineItems []domain.SettlementLineItem, dueAt time.Time) error {
+	var err error
+
+	switch {
+	case len(lineItems) > 0 && settlement.Split != nil:
+		return domain.NewValidationError("lineItems", "cannot change the shares of a split settlement")
+	case len(lineItems) > 0:
+		if amount, err = lineItemsAmount(lineItems, settlement.TargetUserIDs, amount); err != nil {
+			return err
+		}
+		settlement.LineItems = lineItems
+	case len(settlement.LineItems) > 0:
+		if amount != 0 && amount != settlement.Amount {
+			return domain.NewValidationError("amount", "is the sum of lineItems; send lineItems to change it")
+		}
+		amount = settlement.Amount
+	case amount < 1:
+		return domain.NewValidationError("amount", "must be at least 1")
+	}
 
 	settlement.Title = title
 	settlement.Amount = amount
 	settlement.DueAt = dueAt
 
-	if err := i.settlementRepo.Update(ctx, settlement); err != nil {
+	_, err = i.saveRepriced(ctx, settlement)
+	return err
+}
+
+// PaymentWithUser combines a payment with the paying user's display info.
+type PaymentWithUser struct {
+	Payment   *domain.Payment `json:"payment"`
+	UserName  string          `json:"userName"`
+	AvatarURL string          `json:"avatarUrl,omitempty"`
+}
+
+// requireSettlementAdmin loads a settlement and checks the actor administers its circle.
+func (i *SettlementInteractor) requireSettlementAdmin(ctx context.Context, settlementID, actorID string) (*domain.Settlement, error) {
+	settlement, err := i.settlementRepo.GetByID(ctx, settlementID)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.authz.RequireAdmin(ctx, settlement.CircleID, actorID); err != nil {
 		return nil, err
 	}
-
 	return settlement, nil
 }
+
+// getPayment returns the payment of a user for a settlement, or ErrNotFound.
+func (i *SettlementInteractor) getPayment(ctx context.Context, settlementID, userID string) (*domain.Payment, error) {
+	payment, err := i.paymentRepo.GetBySettlementAndUser(ctx, settlementID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, domain.ErrNotFound
+	}
+	return payment, nil
+}
+
+// GetPayments returns every payment of a settlement with the payers' names.
+// Only circle admins can list payments.
+func (i *SettlementInteractor) GetPayments(ctx context.Context, settlementID, actorID string) ([]PaymentWithUser, error) {
+	if _, err := i.requireSettlementAdmin(ctx, settlementID, actorID); err != nil {
+		return nil, err
+	}
+
+	payments, err := i.paymentRepo.GetBySettlement(ctx, settlementID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]PaymentWithUser, 0, len(payments))
+	for _, payment := range payments {
+		result := PaymentWithUser{Payment: payment}
+		user, err := i.userRepo.GetByID(ctx, payment.UserID)
+		if err != nil {
+			log.Printf("Warning: could not find user %s: %v", payment.UserID, err)
+		} else {
+			result.UserName = user.Name
+			result.AvatarURL = user.AvatarURL
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ConfirmPayment marks a user's payment as confirmed by the actor.
+// Unpaid payments can be confirmed directly, e.g. for cash handed to the treasurer.
+// Only circle admins can confirm payments.
+func (i *SettlementInteractor) ConfirmPayment(ctx context.Context, settlementID, userID, actorID string) (*domain.Payment, error) {
+	if _, err := i.requireSettlementAdmin(ctx, settlementID, actorID); err != nil {
+		return nil, err
+	}
+
+	payment, err := i.getPayment(ctx, settlementID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status == domain.PaymentConfirmed {
+		return nil, fmt.Errorf("%w: payment already confirmed", domain.ErrConflict)
+	}
+
+	confirm(payment, actorID, time.Now())
+	if err := i.paymentRepo.Update(ctx, payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// RejectPayment resets a reported payment to UNPAID, recording the reason.
+// Only circle admins can reject payments.
+func (i *SettlementInteractor) RejectPayment(ctx context.Context, settlementID, userID, reason, actorID string) (*domain.Payment, error) {
+	if reason == "" {
+		return nil, domain.NewValidationError("reason", "is required")
+	}
+	if _, err := i.requireSettlementAdmin(ctx, settlementID, actorID); err != nil {
+		return nil, err
+	}
+
+	payment, err := i.getPayment(ctx, settlementID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.PaymentPaidReported {
+		return nil, fmt.Errorf("%w: only reported payments can be rejected", domain.ErrPreconditionFailed)
+	}
+
+	payment.Status = domain.PaymentUnpaid
+	payment.RejectionReason = reason
+	payment.RejectedBy = actorID
+	payment.RejectedAt = time.Now()
+	if err := i.paymentRepo.Update(ctx, payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// BulkConfirmPayments confirms the payments of the given users. When userIDs is
+// empty, every reported payment of the settlement is confirmed. Payments that
+// are already confirmed are skipped. The payments are confirmed in one
+// transaction, so either all of them are or none. It returns the payments it
+// confirmed. Only circle admins can confirm payments.
+func (i *SettlementInteractor) BulkConfirmPayments(ctx context.Context, settlementID string, userIDs []string, actorID string) ([]*domain.Payment, error) {
+	if _, err := i.requireSettlementAdmin(ctx, settlementID, actorID); err != nil {
+		return nil, err
+	}
+
+	var confirmed []*domain.Payment
+	err := i.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		var targets []*domain.Payment
+		if len(userIDs) == 0 {
+			payments, err := i.paymentRepo.GetBySettlement(ctx, settlementID)
+			if err != nil {
+				return err
+			}
+			for _, p := range payments {
+				if p.Status == domain.PaymentPaidReported {
+					targets = append(targets, p)
+				}
+			}
+		} else {
+			// Resolve every user first so an unknown ID confirms nothing.
+			for _, userID := range userIDs {
+				p, err := i.getPayment(ctx, settlementID, userID)
+				if err != nil {
+					return err
+				}
+				if p.Status != domain.PaymentConfirmed {
+					targets = append(targets, p)
+				}
+			}
+		}
+
+		now := time.Now()
+		confirmed = make([]*domain.Payment, 0, len(targets))
+		for _, p := range targets {
+			confirm(p, actorID, now)
+			if err := i.paymentRepo.Update(ctx, p); err != nil {
+				return err
+			}
+			confirmed = append(confirmed, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return confirmed, nil
+}
+
+func confirm(p *domain.Payment, actorID string, at time.Time) {
+	p.Status = domain.PaymentConfirmed
+	p.ConfirmedBy = actorID
+	p.ConfirmedAt = at
+	p.RejectionReason = ""
+	p.RejectedBy = ""
+	p.RejectedAt = time.Time{}
+}
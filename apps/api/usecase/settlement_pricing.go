@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// settlementPricer works out what each target of a settlement owes under
+// its pricing rules.
+type settlementPricer struct {
+	membershipRepo port.MembershipRepository
+	rsvpRepo       port.RSVPRepository
+}
+
+// subjects returns what the pricing rules of s look at for each of userIDs.
+// Without rules, nothing is loaded.
+func (p *settlementPricer) subjects(ctx context.Context, s *domain.Settlement, userIDs []string) (map[string]domain.PricingSubject, error) {
+	subjects := make(map[string]domain.PricingSubject, len(userIDs))
+	for _, userID := range userIDs {
+		subjects[userID] = domain.PricingSubject{UserID: userID}
+	}
+	if len(s.PricingRules) == 0 {
+		return subjects, nil
+	}
+
+	memberships, err := p.membershipRepo.GetByCircle(ctx, s.CircleID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range memberships {
+		if subj, ok := subjects[m.UserID]; ok {
+			subj.Role = m.Role
+			subj.Tags = m.Tags
+			subjects[m.UserID] = subj
+		}
+	}
+
+	byRSVP := slices.ContainsFunc(s.PricingRules, func(r domain.PricingRule) bool {
+		return r.Match == domain.PricingByRSVP
+	})
+	if byRSVP && s.EventID != "" {
+		rsvps, err := p.rsvpRepo.GetByEvent(ctx, s.EventID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rsvps {
+			if subj, ok := subjects[r.UserID]; ok {
+				subj.RSVPStatus = r.Status
+				subjects[r.UserID] = subj
+			}
+		}
+	}
+	return subjects, nil
+}
+
+// reprice sets the amount of each unpaid payment of s to what its payer owes
+// now and returns the payments that changed. Reported and confirmed
+// payments keep the amount that was paid.
+func (p *settlementPricer) reprice(ctx context.Context, s *domain.Settlement, payments []*domain.Payment) ([]*domain.Payment, error) {
+	userIDs := make([]string, 0, len(payments))
+	for _, payment := range payments {
+		userIDs = append(userIDs, payment.UserID)
+	}
+	subjects, err := p.subjects(ctx, s, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []*domain.Payment
+	for _, payment := range payments {
+		if payment.Status != domain.PaymentUnpaid {
+			continue
+		}
+		amount := s.PriceFor(subjects[payment.UserID])
+		if payment.Amount != nil && *payment.Amount == amount {
+			continue
+		}
+		payment.Amount = &amount
+		changed = append(changed, payment)
+	}
+	return changed, nil
+}
+
+// validatePricingRules checks pricing rules for a settlement of an event, or
+// of no event when eventID is empty.
+func validatePricingRules(rules []domain.PricingRule, targetUserIDs []string, eventID string) error {
+	verr := &domain.ValidationError{}
+	if len(rules) > domain.MaxPricingRules {
+		verr.Add("pricingRules", fmt.Sprintf("must not have more than %d rules", domain.MaxPricingRules))
+	}
+	for i, r := range rules {
+		field := fmt.Sprintf("pricingRules[%d]", i)
+		switch r.Match {
+		case domain.PricingByRole:
+			if !domain.MemberRole(r.Value).Valid() {
+				verr.Add(field+".value", "must be one of ADMIN, MEMBER")
+			}
+		case domain.PricingByTag:
+			if r.Value == "" {
+				verr.Add(field+".value", "is required")
+			}
+		case domain.PricingByRSVP:
+			if eventID == "" {
+				verr.Add(field+".match", "RSVP needs a settlement of an event")
+			} else if !domain.RSVPStatus(r.Value).Valid() {
+				verr.Add(field+".value", "must be one of GO, NO, LATE, EARLY")
+			}
+		case domain.PricingByUser:
+			if !slices.Contains(targetUserIDs, r.Value) {
+				verr.Add(field+".value", "is not a target of the settlement")
+			}
+		default:
+			verr.Add(field+".match", "must be one of ROLE, TAG, RSVP, USER")
+		}
+
+		switch {
+		case (r.Percent == nil) == (r.Amount == nil):
+			verr.Add(field, "needs exactly one of percent and amount")
+		case r.Percent != nil && (*r.Percent < 0 || *r.Percent > 100):
+			verr.Add(field+".percent", "must be between 0 and 100")
+		case r.Amount != nil && *r.Amount < 0:
+			verr.Add(field+".amount", "must not be negative")
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// SetPricingRules replaces the pricing rules of a settlement and reprices its
+// unpaid payments. It returns the settlement's collection summary with the
+// new totals. Only circle admins can price settlements.
+func (i *SettlementInteractor) SetPricingRules(ctx context.Context, settlementID string, rules []domain.PricingRule, actorID string) (*SettlementSummary, error) {
+	var settlement *domain.Settlement
+	var payments []*domain.Payment
+	err := i.tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		settlement, err = i.requireSettlementAdmin(ctx, settlementID, actorID)
+		if err != nil {
+			return err
+		}
+		if settlement.Split != nil && len(rules) > 0 {
+			return domain.NewValidationError("pricingRules", "cannot change the shares of a split settlement")
+		}
+		if err := validatePricingRules(rules, settlement.TargetUserIDs, settlement.EventID); err != nil {
+			return err
+		}
+
+		settlement.PricingRules = rules
+		payments, err = i.saveRepriced(ctx, settlement)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summarize(settlement, payments, time.Now()), nil
+}
+
+// saveRepriced saves a changed settlement and reprices its unpaid payments,
+// reading everything it needs before it writes. It returns all of the
+// settlement's payments. Callers run it in a transaction.
+func (i *SettlementInteractor) saveRepriced(ctx context.Context, s *domain.Settlement) ([]*domain.Payment, error) {
+	payments, err := i.paymentRepo.GetBySettlement(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := i.pricer.reprice(ctx, s, payments)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.settlementRepo.Update(ctx, s); err != nil {
+		return nil, err
+	}
+	for _, p := range changed {
+		if err := i.paymentRepo.Update(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	return payments, nil
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// CreateSplitSettlement creates a settlement of an event that splits a total
+// expense evenly among the attendees: users whose GO, LATE or EARLY RSVP
+// holds a seat. rounding decides who covers the yen left over and defaults
+// to the organizer absorbing it. The attendees are fixed when the settlement
+// is created; later RSVP changes do not move the shares. Only circle admins
+// can create settlements.
+func (i *SettlementInteractor) CreateSplitSettlement(ctx context.Context, eventID, title string, total int, rounding domain.SplitRounding, dueAt time.Time, bankInfo, paypayInfo, actorID string) (*domain.Settlement, error) {
+	event, err := i.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.authz.RequireAdmin(ctx, event.CircleID, actorID); err != nil {
+		return nil, err
+	}
+	if rounding == "" {
+		rounding = domain.SplitOrganizerAbsorbs
+	}
+	if !rounding.Valid() {
+		return nil, domain.NewValidationError("rounding", "must be one of ORGANIZER_ABSORBS, ROUND_UP, SPREAD")
+	}
+
+	rsvps, err := i.rsvpRepo.GetByEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	var attendees []string
+	for _, r := range rsvps {
+		if r.Confirmed() {
+			attendees = append(attendees, r.UserID)
+		}
+	}
+	if len(attendees) == 0 {
+		return nil, fmt.Errorf("%w: event has no attendees to split among", domain.ErrPreconditionFailed)
+	}
+	if total < len(attendees) {
+		return nil, domain.NewValidationError("totalAmount", fmt.Sprintf("must be at least 1 yen per attendee (%d)", len(attendees)))
+	}
+	sort.Strings(attendees)
+
+	// With SPREAD, the attendees first in ID order pay the extra yen.
+	share, plusOne := rounding.Split(total, len(attendees))
+	item := domain.SettlementLineItem{
+		Label:  fmt.Sprintf("費用 %d円を%d人で割り勘", total, len(attendees)),
+		Amount: share,
+	}
+	if plusOne > 0 {
+		item.Overrides = make(map[string]int, plusOne)
+		for _, userID := range attendees[:plusOne] {
+			item.Overrides[userID] = share + 1
+		}
+	}
+
+	settlement := &domain.Settlement{
+		CircleID:      event.CircleID,
+		EventID:       eventID,
+		Title:         title,
+		Amount:        share,
+		DueAt:         dueAt,
+		TargetUserIDs: attendees,
+		BankInfo:      bankInfo,
+		PayPayInfo:    paypayInfo,
+		LineItems:     []domain.SettlementLineItem{item},
+		Split: &domain.SettlementSplit{
+			TotalAmount:    total,
+			Rounding:       rounding,
+			Attendees:      len(attendees),
+			OrganizerShare: total - share*len(attendees) - plusOne,
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := i.create(ctx, settlement, actorID); err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+)
+
+// AmountCount is a number of payments and the amount they add up to.
+type AmountCount struct {
+	Count  int `json:"count"`
+	Amount int `json:"amount"`
+}
+
+// CollectionSummary aggregates the payments of one or more settlements.
+type CollectionSummary struct {
+	TargetCount       int                                  `json:"targetCount"`
+	ExpectedAmount    int                                  `json:"expectedAmount"`
+	CollectedAmount   int                                  `json:"collectedAmount"`   // confirmed payments
+	ReportedAmount    int                                  `json:"reportedAmount"`    // reported, awaiting confirmation
+	OutstandingAmount int                                  `json:"outstandingAmount"` // expected minus collected
+	ByStatus          map[domain.PaymentStatus]AmountCount `json:"byStatus"`
+	ByMethod          map[domain.PaymentMethod]AmountCount `json:"byMethod"`
+	OverdueCount      int                                  `json:"overdueCount"` // unpaid targets past DueAt
+}
+
+// SettlementSummary is the collection status of a single settlement.
+type SettlementSummary struct {
+	Settlement *domain.Settlement `json:"settlement"`
+	Overdue    bool               `json:"overdue"`
+	CollectionSummary
+}
+
+// CircleSettlementsSummary lists the settlements of a circle with circle-wide totals.
+type CircleSettlementsSummary struct {
+	Settlements []*SettlementSummary `json:"settlements"`
+	Totals      CollectionSummary    `json:"totals"`
+}
+
+func newCollectionSummary() CollectionSummary {
+	return CollectionSummary{
+		ByStatus: map[domain.PaymentStatus]AmountCount{
+			domain.PaymentUnpaid:       {},
+			domain.PaymentPaidReported: {},
+			domain.PaymentConfirmed:    {},
+		},
+		ByMethod: map[domain.PaymentMethod]AmountCount{
+			domain.PaymentMethodBank:   {},
+			domain.PaymentMethodPayPay: {},
+		},
+	}
+}
+
+// summarize aggregates the payments of a settlement as of now.
+func summarize(settlement *domain.Settlement, payments []*domain.Payment, now time.Time) *SettlementSummary {
+	sum := &SettlementSummary{Settlement: settlement, CollectionSummary: newCollectionSummary()}
+	pastDue := !settlement.DueAt.IsZero() && now.After(settlement.DueAt)
+
+	for _, p := range payments {
+		amount := p.Owed(settlement)
+		sum.TargetCount++
+		sum.ExpectedAmount += amount
+		sum.ByStatus[p.Status] = sum.ByStatus[p.Status].add(amount)
+
+		switch p.Status {
+		case domain.PaymentConfirmed:
+			sum.CollectedAmount += amount
+		case domain.PaymentPaidReported:
+			sum.ReportedAmount += amount
+		case domain.PaymentUnpaid:
+			if pastDue && amount > 0 {
+				sum.OverdueCount++
+			}
+		}
+		if p.Method != "" && p.Status != domain.PaymentUnpaid {
+			sum.ByMethod[p.Method] = sum.ByMethod[p.Method].add(amount)
+		}
+	}
+	sum.OutstandingAmount = sum.ExpectedAmount - sum.CollectedAmount
+	sum.Overdue = sum.OverdueCount > 0
+	return sum
+}
+
+func (a AmountCount) add(amount int) AmountCount {
+	return AmountCount{Count: a.Count + 1, Amount: a.Amount + amount}
+}
+
+// merge adds the figures of o to s.
+func (s *CollectionSummary) merge(o CollectionSummary) {
+	s.TargetCount += o.TargetCount
+	s.ExpectedAmount += o.ExpectedAmount
+	s.CollectedAmount += o.CollectedAmount
+	s.ReportedAmount += o.ReportedAmount
+	s.OutstandingAmount += o.OutstandingAmount
+	s.OverdueCount += o.OverdueCount
+	for k, v := range o.ByStatus {
+		s.ByStatus[k] = AmountCount{Count: s.ByStatus[k].Count + v.Count, Amount: s.ByStatus[k].Amount + v.Amount}
+	}
+	for k, v := range o.ByMethod {
+		s.ByMethod[k] = AmountCount{Count: s.ByMethod[k].Count + v.Count, Amount: s.ByMethod[k].Amount + v.Amount}
+	}
+}
+
+// GetSummary returns the collection status of a settlement.
+// Only circle admins can view settlement summaries.
+func (i *SettlementInteractor) GetSummary(ctx context.Context, settlementID, actorID string) (*SettlementSummary, error) {
+	settlement, err := i.requireSettlementAdmin(ctx, settlementID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := i.paymentRepo.GetBySettlement(ctx, settlementID)
+	if err != nil {
+		return nil, err
+	}
+	return summarize(settlement, payments, time.Now()), nil
+}
+
+// GetCircleSummary returns the collection status of every settlement in a circle,
+// newest first, along with circle-wide totals.
+// Only circle admins can view settlement summaries.
+func (i *SettlementInteractor) GetCircleSummary(ctx context.Context, circleID, actorID string) (*CircleSettlementsSummary, error) {
+	if err := i.authz.RequireAdmin(ctx, circleID, actorID); err != nil {
+		return nil, err
+	}
+
+	settlements, err := i.settlementRepo.GetByCircle(ctx, circleID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &CircleSettlementsSummary{
+		Settlements: make([]*SettlementSummary, 0, len(settlements)),
+		Totals:      newCollectionSummary(),
+	}
+	for _, settlement := range settlements {
+		payments, err := i.paymentRepo.GetBySettlement(ctx, settlement.ID)
+		if err != nil {
+			return nil, err
+		}
+		sum := summarize(settlement, payments, now)
+		result.Settlements = append(result.Settlements, sum)
+		result.Totals.merge(sum.CollectionSummary)
+	}
+	return result, nil
+}
@@ -0,0 +1,451 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// failingPayments fails to create payments after the first ok ones.
+type failingPayments struct {
+	port.PaymentRepository
+	ok int
+}
+
+var errCreateFailed = errors.New("create failed")
+
+func (r *failingPayments) Create(ctx context.Context, p *domain.Payment) error {
+	if r.ok == 0 {
+		return errCreateFailed
+	}
+	r.ok--
+	return r.PaymentRepository.Create(ctx, p)
+}
+
+func TestCreateSettlementRejectsEventOfAnotherCircle(t *testing.T) {
+	f := newFixture(t)
+	circleA := f.circle("admin", "u1")
+	circleB := f.circle("other")
+	event := f.event(circleB, "other", time.Now().Add(48*time.Hour), 0, time.Time{})
+	dueAt := time.Now().Add(24 * time.Hour)
+
+	_, err := f.settlements().CreateSettlement(f.ctx, circleA, event.ID, "合宿費", 3000, dueAt, []string{"u1"}, "", "", nil, nil, "admin")
+	wantErr(t, err, domain.ErrNotFound)
+	settlements, err := f.repos.Settlement.GetByEvent(f.ctx, event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settlements) != 0 {
+		t.Errorf("settlement attached to another circle's event: %+v", settlements[0])
+	}
+
+	_, err = f.settlements().CreateSettlement(f.ctx, circleA, "missing", "合宿費", 3000, dueAt, []string{"u1"}, "", "", nil, nil, "admin")
+	wantErr(t, err, domain.ErrNotFound)
+}
+
+func TestGetByEventShowsMembersOnlyTheirOwnAmount(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	event := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 0, time.Time{})
+	lineItems := []domain.SettlementLineItem{
+		{Label: "宿泊費", Amount: 8000, Overrides: map[string]int{"u1": 5000}},
+		{Label: "交通費", Amount: 2000},
+	}
+	half := 50
+	rules := []domain.PricingRule{{Match: domain.PricingByUser, Value: "u2", Percent: &half}}
+	dueAt := time.Now().Add(24 * time.Hour)
+	if _, err := f.settlements().CreateSettlement(f.ctx, circleID, event.ID, "合宿費", 0, dueAt, []string{"u1", "u2"}, "", "", lineItems, rules, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	for userID, want := range map[string]int{"u1": 7000, "u2": 5000} {
+		settlements, err := f.settlements().GetByEvent(f.ctx, event.ID, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := settlements[0]
+		if s.Amount != want || s.PricingRules != nil || s.LineItems[0].Overrides != nil {
+			t.Errorf("%s sees %+v, want only an amount of %d", userID, s, want)
+		}
+	}
+	settlements, err := f.settlements().GetByEvent(f.ctx, event.ID, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := settlements[0]; s.Amount != 10000 || len(s.PricingRules) != 1 || s.LineItems[0].Overrides["u1"] != 5000 {
+		t.Errorf("admin sees %+v", s)
+	}
+	_, err = f.settlements().GetByEvent(f.ctx, event.ID, "stranger")
+	wantErr(t, err, domain.ErrNotFound)
+}
+
+func TestCreateSettlementSavesNothingWhenAPaymentFails(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	r := f.repos
+	settlements := usecase.NewSettlementInteractor(r.Settlement, &failingPayments{PaymentRepository: r.Payment, ok: 1}, r.Event, r.User, r.Membership, r.RSVP, r.Transactor, f.notifier, f.authz)
+
+	_, err := settlements.CreateSettlement(f.ctx, circleID, "", "部費", 3000, time.Now().Add(24*time.Hour), []string{"u1", "u2"}, "", "", nil, nil, "admin")
+	wantErr(t, err, errCreateFailed)
+	saved, err := r.Settlement.GetByCircle(f.ctx, circleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 0 {
+		t.Errorf("settlement kept without all its payments: %+v", saved[0])
+	}
+	if payments, _ := r.Payment.GetByUser(f.ctx, "u1"); len(payments) != 0 {
+		t.Errorf("payment kept: %+v", payments[0])
+	}
+	if n := len(f.notifier.titled("清算のお知らせ")); n != 0 {
+		t.Errorf("got %d notifications for a settlement that was not saved", n)
+	}
+}
+
+func TestSetPricingRulesRepricesUnpaidPayments(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	s, err := f.settlements().CreateSettlement(f.ctx, circleID, "", "部費", 3000, time.Now().Add(24*time.Hour), []string{"u1", "u2"}, "", "", nil, nil, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	discount := 1000
+	bad := []domain.PricingRule{{Match: domain.PricingByUser, Value: "stranger", Amount: &discount}}
+	_, err = f.settlements().SetPricingRules(f.ctx, s.ID, bad, "admin")
+	wantErr(t, err, domain.ErrInvalidInput)
+
+	rules := []domain.PricingRule{{Match: domain.PricingByUser, Value: "u1", Amount: &discount}}
+	if _, err := f.settlements().SetPricingRules(f.ctx, s.ID, rules, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := f.repos.Settlement.GetByID(f.ctx, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.PricingRules) != 1 {
+		t.Errorf("saved rules %+v", saved.PricingRules)
+	}
+	for userID, want := range map[string]int{"u1": 1000, "u2": 3000} {
+		p, err := f.repos.Payment.GetBySettlementAndUser(f.ctx, s.ID, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Owed(saved); got != want {
+			t.Errorf("%s owes %d, want %d", userID, got, want)
+		}
+	}
+}
+
+// reportedSettlement creates a settlement for u1, u2 and u3 in which u1 and
+// u2 have reported their payments.
+func reportedSettlement(f *fixture) *domain.Settlement {
+	f.t.Helper()
+	circleID := f.circle("admin", "u1", "u2", "u3")
+	s, err := f.settlements().CreateSettlement(f.ctx, circleID, "", "部費", 3000, time.Now().Add(24*time.Hour), []string{"u1", "u2", "u3"}, "", "", nil, nil, "admin")
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	for _, userID := range []string{"u1", "u2"} {
+		if _, err := f.settlements().ReportPayment(f.ctx, s.ID, userID, domain.PaymentMethodBank, ""); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func paymentStatus(f *fixture, settlementID, userID string) domain.PaymentStatus {
+	f.t.Helper()
+	p, err := f.repos.Payment.GetBySettlementAndUser(f.ctx, settlementID, userID)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return p.Status
+}
+
+func TestConfirmAndRejectPayment(t *testing.T) {
+	f := newFixture(t)
+	s := reportedSettlement(f)
+	uc := f.settlements()
+
+	p, err := uc.ConfirmPayment(f.ctx, s.ID, "u1", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != domain.PaymentConfirmed || p.ConfirmedBy != "admin" || p.ConfirmedAt.IsZero() {
+		t.Errorf("confirmed payment = %+v", p)
+	}
+	_, err = uc.ConfirmPayment(f.ctx, s.ID, "u1", "admin")
+	wantErr(t, err, domain.ErrConflict)
+	_, err = uc.RejectPayment(f.ctx, s.ID, "u1", "振込が見当たりません", "admin")
+	wantErr(t, err, domain.ErrPreconditionFailed)
+	if got := paymentStatus(f, s.ID, "u1"); got != domain.PaymentConfirmed {
+		t.Errorf("rejecting a confirmed payment left it %s", got)
+	}
+
+	_, err = uc.RejectPayment(f.ctx, s.ID, "u2", "", "admin")
+	wantErr(t, err, domain.ErrInvalidInput)
+	p, err = uc.RejectPayment(f.ctx, s.ID, "u2", "振込が見当たりません", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != domain.PaymentUnpaid || p.RejectionReason != "振込が見当たりません" || p.RejectedBy != "admin" {
+		t.Errorf("rejected payment = %+v", p)
+	}
+	_, err = uc.RejectPayment(f.ctx, s.ID, "u3", "未報告", "admin")
+	wantErr(t, err, domain.ErrPreconditionFailed)
+
+	// Cash handed to the treasurer: an unpaid payment is confirmed directly
+	// and the earlier rejection is cleared.
+	p, err = uc.ConfirmPayment(f.ctx, s.ID, "u2", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != domain.PaymentConfirmed || p.RejectionReason != "" || p.RejectedBy != "" {
+		t.Errorf("confirmed after rejection = %+v", p)
+	}
+
+	_, err = uc.ConfirmPayment(f.ctx, s.ID, "stranger", "admin")
+	wantErr(t, err, domain.ErrNotFound)
+}
+
+func TestPaymentReviewRequiresAdmin(t *testing.T) {
+	f := newFixture(t)
+	s := reportedSettlement(f)
+	uc := f.settlements()
+
+	_, err := uc.ConfirmPayment(f.ctx, s.ID, "u1", "u2")
+	wantErr(t, err, domain.ErrForbidden)
+	_, err = uc.RejectPayment(f.ctx, s.ID, "u1", "振込が見当たりません", "u2")
+	wantErr(t, err, domain.ErrForbidden)
+	_, err = uc.BulkConfirmPayments(f.ctx, s.ID, nil, "u2")
+	wantErr(t, err, domain.ErrForbidden)
+	for _, userID := range []string{"u1", "u2"} {
+		if got := paymentStatus(f, s.ID, userID); got != domain.PaymentPaidReported {
+			t.Errorf("%s is %s after a member's review", userID, got)
+		}
+	}
+}
+
+func TestBulkConfirmPayments(t *testing.T) {
+	f := newFixture(t)
+	s := reportedSettlement(f)
+	uc := f.settlements()
+
+	confirmed, err := uc.BulkConfirmPayments(f.ctx, s.ID, nil, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range confirmed {
+		ids = append(ids, p.UserID)
+	}
+	wantUsers(t, ids, []string{"u1", "u2"})
+	if got := paymentStatus(f, s.ID, "u3"); got != domain.PaymentUnpaid {
+		t.Errorf("unreported payment confirmed without user IDs: %s", got)
+	}
+
+	// Named users are confirmed even when unpaid; confirmed ones are skipped.
+	confirmed, err = uc.BulkConfirmPayments(f.ctx, s.ID, []string{"u1", "u3"}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 1 || confirmed[0].UserID != "u3" {
+		t.Errorf("confirmed %+v, want only u3", confirmed)
+	}
+}
+
+// failingUpdates fails to update payments after the first ok ones.
+type failingUpdates struct {
+	port.PaymentRepository
+	ok int
+}
+
+func (r *failingUpdates) Update(ctx context.Context, p *domain.Payment) error {
+	if r.ok == 0 {
+		return errUpdateFailed
+	}
+	r.ok--
+	return r.PaymentRepository.Update(ctx, p)
+}
+
+func TestBulkConfirmPaymentsConfirmsAllOrNothing(t *testing.T) {
+	f := newFixture(t)
+	s := reportedSettlement(f)
+	r := f.repos
+	settlements := usecase.NewSettlementInteractor(r.Settlement, &failingUpdates{PaymentRepository: r.Payment, ok: 1}, r.Event, r.User, r.Membership, r.RSVP, r.Transactor, f.notifier, f.authz)
+
+	_, err := settlements.BulkConfirmPayments(f.ctx, s.ID, nil, "admin")
+	wantErr(t, err, errUpdateFailed)
+	_, err = f.settlements().BulkConfirmPayments(f.ctx, s.ID, []string{"u1", "stranger"}, "admin")
+	wantErr(t, err, domain.ErrNotFound)
+	for _, userID := range []string{"u1", "u2"} {
+		if got := paymentStatus(f, s.ID, userID); got != domain.PaymentPaidReported {
+			t.Errorf("%s is %s after a failed bulk confirmation", userID, got)
+		}
+	}
+}
+
+func TestCreateSplitSettlementSharesAddUpToTheTotal(t *testing.T) {
+	tests := []struct {
+		rounding  domain.SplitRounding
+		owed      map[string]int
+		organizer int
+	}{
+		// The attendees first in ID order pay the extra yen.
+		{domain.SplitSpread, map[string]int{"u1": 251, "u2": 251, "u3": 251, "u4": 250}, 0},
+		{domain.SplitOrganizerAbsorbs, map[string]int{"u1": 250, "u2": 250, "u3": 250, "u4": 250}, 3},
+		{domain.SplitRoundUp, map[string]int{"u1": 251, "u2": 251, "u3": 251, "u4": 251}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.rounding), func(t *testing.T) {
+			f := newFixture(t)
+			circleID := f.circle("admin", "u1", "u2", "u3", "u4", "u5")
+			event := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 0, time.Time{})
+			// Map order: the shares must follow user IDs, not the order of the RSVPs.
+			for userID, status := range map[string]domain.RSVPStatus{"u4": domain.RSVPGo, "u2": domain.RSVPLate, "u5": domain.RSVPNo, "u1": domain.RSVPEarly, "u3": domain.RSVPGo} {
+				f.rsvp(event.ID, userID, status)
+			}
+
+			s, err := f.settlements().CreateSplitSettlement(f.ctx, event.ID, "懇親会", 1003, tt.rounding, time.Now().Add(24*time.Hour), "", "", "admin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantUsers(t, s.TargetUserIDs, []string{"u1", "u2", "u3", "u4"})
+			if s.Split.Attendees != 4 || s.Split.OrganizerShare != tt.organizer {
+				t.Errorf("split = %+v, want organizer share %d", s.Split, tt.organizer)
+			}
+
+			sum := 0
+			for userID, want := range tt.owed {
+				p, err := f.repos.Payment.GetBySettlementAndUser(f.ctx, s.ID, userID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := p.Owed(s); got != want {
+					t.Errorf("%s owes %d, want %d", userID, got, want)
+				}
+				sum += p.Owed(s)
+			}
+			if sum+s.Split.OrganizerShare != 1003 {
+				t.Errorf("shares %d + organizer %d do not add up to 1003", sum, s.Split.OrganizerShare)
+			}
+		})
+	}
+}
+
+func TestCreateSplitSettlementRejectsBadSplits(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2")
+	event := f.event(circleID, "admin", time.Now().Add(48*time.Hour), 0, time.Time{})
+	dueAt := time.Now().Add(24 * time.Hour)
+
+	_, err := f.settlements().CreateSplitSettlement(f.ctx, event.ID, "懇親会", 1000, "", dueAt, "", "", "admin")
+	wantErr(t, err, domain.ErrPreconditionFailed)
+
+	f.rsvp(event.ID, "u1", domain.RSVPGo)
+	f.rsvp(event.ID, "u2", domain.RSVPGo)
+	_, err = f.settlements().CreateSplitSettlement(f.ctx, event.ID, "懇親会", 1, "", dueAt, "", "", "admin")
+	wantErr(t, err, domain.ErrInvalidInput)
+	_, err = f.settlements().CreateSplitSettlement(f.ctx, event.ID, "懇親会", 1000, "ROUND_DOWN", dueAt, "", "", "admin")
+	wantErr(t, err, domain.ErrInvalidInput)
+	_, err = f.settlements().CreateSplitSettlement(f.ctx, event.ID, "懇親会", 1000, "", dueAt, "", "", "u1")
+	wantErr(t, err, domain.ErrForbidden)
+
+	s, err := f.settlements().CreateSplitSettlement(f.ctx, event.ID, "懇親会", 1001, "", dueAt, "", "", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Split.Rounding != domain.SplitOrganizerAbsorbs || s.Split.OrganizerShare != 1 {
+		t.Errorf("default split = %+v, want the organizer to absorb 1 yen", s.Split)
+	}
+}
+
+func TestGetCircleSummaryTotalsByStatus(t *testing.T) {
+	f := newFixture(t)
+	circleID := f.circle("admin", "u1", "u2", "u3", "u4")
+	uc := f.settlements()
+	free := 0
+	rules := []domain.PricingRule{{Match: domain.PricingByUser, Value: "u4", Amount: &free}}
+	camp, err := uc.CreateSettlement(f.ctx, circleID, "", "合宿費", 3000, time.Now().Add(24*time.Hour), []string{"u1", "u2", "u3", "u4"}, "", "", nil, rules, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ReportPayment(f.ctx, camp.ID, "u1", domain.PaymentMethodBank, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ConfirmPayment(f.ctx, camp.ID, "u1", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ReportPayment(f.ctx, camp.ID, "u2", domain.PaymentMethodPayPay, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Past due: u3 is overdue, u4 owes nothing and is not.
+	camp.DueAt = time.Now().Add(-time.Hour)
+	if err := f.repos.Settlement.Update(f.ctx, camp); err != nil {
+		t.Fatal(err)
+	}
+	fee, err := uc.CreateSettlement(f.ctx, circleID, "", "部費", 1000, time.Now().Add(24*time.Hour), []string{"u1"}, "", "", nil, nil, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = uc.GetCircleSummary(f.ctx, circleID, "u1")
+	wantErr(t, err, domain.ErrForbidden)
+	sum, err := uc.GetCircleSummary(f.ctx, circleID, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sum.Settlements) != 2 {
+		t.Fatalf("got %d settlements, want 2", len(sum.Settlements))
+	}
+	byID := map[string]*usecase.SettlementSummary{}
+	for _, s := range sum.Settlements {
+		byID[s.Settlement.ID] = s
+	}
+
+	campSum := byID[camp.ID]
+	if !campSum.Overdue || campSum.OverdueCount != 1 {
+		t.Errorf("camp overdue = %v with %d overdue, want 1", campSum.Overdue, campSum.OverdueCount)
+	}
+	wantCollection(t, "camp", campSum.CollectionSummary, usecase.CollectionSummary{
+		TargetCount: 4, ExpectedAmount: 9000, CollectedAmount: 3000, ReportedAmount: 3000, OutstandingAmount: 6000, OverdueCount: 1,
+		ByStatus: map[domain.PaymentStatus]usecase.AmountCount{
+			domain.PaymentUnpaid:       {Count: 2, Amount: 3000},
+			domain.PaymentPaidReported: {Count: 1, Amount: 3000},
+			domain.PaymentConfirmed:    {Count: 1, Amount: 3000},
+		},
+		ByMethod: map[domain.PaymentMethod]usecase.AmountCount{
+			domain.PaymentMethodBank:   {Count: 1, Amount: 3000},
+			domain.PaymentMethodPayPay: {Count: 1, Amount: 3000},
+		},
+	})
+	if feeSum := byID[fee.ID]; feeSum.Overdue || feeSum.ByStatus[domain.PaymentUnpaid] != (usecase.AmountCount{Count: 1, Amount: 1000}) {
+		t.Errorf("fee summary = %+v", feeSum.CollectionSummary)
+	}
+
+	wantCollection(t, "totals", sum.Totals, usecase.CollectionSummary{
+		TargetCount: 5, ExpectedAmount: 10000, CollectedAmount: 3000, ReportedAmount: 3000, OutstandingAmount: 7000, OverdueCount: 1,
+		ByStatus: map[domain.PaymentStatus]usecase.AmountCount{
+			domain.PaymentUnpaid:       {Count: 3, Amount: 4000},
+			domain.PaymentPaidReported: {Count: 1, Amount: 3000},
+			domain.PaymentConfirmed:    {Count: 1, Amount: 3000},
+		},
+		ByMethod: map[domain.PaymentMethod]usecase.AmountCount{
+			domain.PaymentMethodBank:   {Count: 1, Amount: 3000},
+			domain.PaymentMethodPayPay: {Count: 1, Amount: 3000},
+		},
+	})
+}
+
+func wantCollection(t *testing.T, name string, got, want usecase.CollectionSummary) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s summary = %+v, want %+v", name, got, want)
+	}
+}
@@ -0,0 +1,199 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/infra/memory"
+	"github.com/noa/circle-app/api/usecase"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// recordingNotifier records notifications instead of delivering them.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []*domain.Notification
+	err  error // returned by Notify when set
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, msg *domain.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+// titled returns the notifications sent with title.
+func (n *recordingNotifier) titled(title string) []*domain.Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var result []*domain.Notification
+	for _, msg := range n.sent {
+		if msg.Title == title {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// holidayList is a fixed port.HolidayCalendar.
+type holidayList []domain.Holiday
+
+func (h holidayList) Holidays(ctx context.Context, year int) ([]domain.Holiday, error) {
+	return h, nil
+}
+
+// fixture wires interactors over a fresh in-memory store.
+type fixture struct {
+	t        *testing.T
+	ctx      context.Context
+	repos    *memory.Repositories
+	notifier *recordingNotifier
+	authz    *usecase.Authorizer
+	holidays holidayList
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	repos := memory.New()
+	return &fixture{
+		t:        t,
+		ctx:      context.Background(),
+		repos:    repos,
+		notifier: &recordingNotifier{},
+		authz:    usecase.NewAuthorizer(repos.Membership),
+	}
+}
+
+func (f *fixture) circles() *usecase.CircleInteractor {
+	return usecase.NewCircleInteractor(f.repos.Circle, f.repos.Membership, f.repos.User, f.authz)
+}
+
+func (f *fixture) events() *usecase.EventInteractor {
+	r := f.repos
+	return usecase.NewEventInteractor(r.Event, r.Membership, r.User, r.RSVP, r.Settlement, r.Payment, r.Transactor, f.notifier, f.authz)
+}
+
+func (f *fixture) rsvps() *usecase.RSVPInteractor {
+	r := f.repos
+	return usecase.NewRSVPInteractor(r.RSVP, r.Event, r.Membership, r.User, r.Settlement, r.Payment, r.AuditLog, r.Transactor, f.notifier, f.authz)
+}
+
+func (f *fixture) practice() *usecase.PracticeUseCase {
+	return f.practiceWith(f.repos.PracticeSession, f.repos.PracticeRSVP)
+}
+
+// practiceWith wires the practice use case over other session and RSVP repositories.
+func (f *fixture) practiceWith(sessions port.PracticeSessionRepository, rsvps port.PracticeRSVPRepository) *usecase.PracticeUseCase {
+	r := f.repos
+	return usecase.NewPracticeUseCase(r.PracticeCategory, r.PracticeSeries, sessions, rsvps, r.Settlement, r.Payment, r.Circle, r.CalendarException, r.AuditLog, r.Transactor, f.holidays, f.notifier, f.authz)
+}
+
+func (f *fixture) reminders(clock port.Clock) *usecase.ReminderInteractor {
+	r := f.repos
+	return usecase.NewReminderInteractor(r.ReminderPolicy, r.ReminderJob, r.Circle, r.Event, r.RSVP, r.Membership, r.Settlement, r.Payment, r.Transactor, f.notifier, clock, f.authz)
+}
+
+// calendarSecret signs the calendar feed tokens of tests.
+var calendarSecret = []byte("calendar-secret")
+
+func (f *fixture) calendar() *usecase.CalendarInteractor {
+	r := f.repos
+	return usecase.NewCalendarInteractor(r.Circle, r.Membership, r.Event, r.RSVP, r.PracticeSeries, r.PracticeSession, r.PracticeRSVP, f.authz, calendarSecret)
+}
+
+func (f *fixture) settlements() *usecase.SettlementInteractor {
+	r := f.repos
+	return usecase.NewSettlementInteractor(r.Settlement, r.Payment, r.Event, r.User, r.Membership, r.RSVP, r.Transactor, f.notifier, f.authz)
+}
+
+// circle creates a circle administered by admin with the given members.
+func (f *fixture) circle(admin string, members ...string) string {
+	f.t.Helper()
+	c, err := f.circles().CreateCircle(f.ctx, "テニス部", "", "", admin)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	for _, userID := range members {
+		if _, err := f.circles().AddMember(f.ctx, c.ID, userID, domain.RoleMember, nil, admin); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+	return c.ID
+}
+
+// event creates an event of a circle starting at startAt.
+func (f *fixture) event(circleID, admin string, startAt time.Time, capacity int, deadline time.Time) *domain.Event {
+	f.t.Helper()
+	e, err := f.events().CreateEvent(f.ctx, circleID, "合宿", startAt, "", "", nil, capacity, deadline, admin)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return e
+}
+
+// rsvp submits a user's own RSVP and fails the test on error.
+func (f *fixture) rsvp(eventID, userID string, status domain.RSVPStatus) *domain.RSVP {
+	f.t.Helper()
+	r, err := f.rsvps().SubmitRSVP(f.ctx, eventID, userID, status, "")
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return r
+}
+
+// series creates a weekly practice series of a circle and generates its
+// sessions for the next four weeks.
+func (f *fixture) series(circleID, admin string, fee int) (*domain.PracticeSeries, []*domain.PracticeSession) {
+	f.t.Helper()
+	s := &domain.PracticeSeries{CircleID: circleID, Name: "土曜練習", DayOfWeek: int(time.Saturday), StartTime: "14:00", DurationMinutes: 120, Fee: fee, CreatedBy: admin}
+	if err := f.practice().CreateSeries(f.ctx, s); err != nil {
+		f.t.Fatal(err)
+	}
+	from := domain.StartOfDay(time.Now()).AddDate(0, 0, 1)
+	sessions, err := f.practice().GenerateSessions(f.ctx, s.ID, from, from.AddDate(0, 0, 28), admin)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if len(sessions) != 4 {
+		f.t.Fatalf("generated %d sessions, want 4", len(sessions))
+	}
+	return s, sessions
+}
+
+// practiceRSVP submits a user's own practice RSVP and fails the test on error.
+func (f *fixture) practiceRSVP(sessionID, userID string, status domain.PracticeRSVPStatus) {
+	f.t.Helper()
+	r := &domain.PracticeRSVP{SessionID: sessionID, UserID: userID, Status: status}
+	if err := f.practice().SubmitRSVP(f.ctx, r); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// wantErr fails the test unless err matches target.
+func wantErr(t *testing.T, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("got error %v, want %v", err, target)
+	}
+}
+
+// wantUsers fails the test unless got holds exactly the users of want.
+func wantUsers(t *testing.T, got, want []string) {
+	t.Helper()
+	got = slices.Clone(got)
+	want = slices.Clone(want)
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("got users %v, want %v", got, want)
+	}
+}
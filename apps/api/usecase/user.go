@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"github.com/noa/circle-app/api/domain"
 	"github.com/noa/circle-app/api/usecase/port"
@@ -9,43 +11,80 @@ import (
 
 // UserInteractor implements user use cases.
 type UserInteractor struct {
-	repo port.UserRepository
+	repo           port.UserRepository
+	channels       []domain.NotificationChannel
+	vapidPublicKey string
 }
 
-// NewUserInteractor creates a new UserInteractor.
-func NewUserInteractor(repo port.UserRepository) *UserInteractor {
-	return &UserInteractor{repo: repo}
+// NewUserInteractor creates a new UserInteractor. channels are the
+// notification channels users can choose from.
+func NewUserInteractor(repo port.UserRepository, channels []domain.NotificationChannel, vapidPublicKey string) *UserInteractor {
+	return &UserInteractor{repo: repo, channels: channels, vapidPublicKey: vapidPublicKey}
 }
 
-// CreateUser creates a new user.
-func (i *UserInteractor) CreateUser(ctx context.Context, id, name, avatarURL string) (*domain.User, error) {
+// hidePrivate clears what only the user themselves may see.
+func hidePrivate(u *domain.User) {
+	u.Email = ""
+	u.EmailVerified = false
+	u.Notifications = nil
+}
+
+// setEmail sets the email address of u, keeping the current one when email is
+// empty and falling back to verifiedEmail, the address the user's identity
+// provider verified. The address counts as verified only if it is verifiedEmail.
+func setEmail(u *domain.User, email, verifiedEmail string) {
+	if email != "" {
+		u.Email = email
+	} else if u.Email == "" {
+		u.Email = verifiedEmail
+	}
+	u.EmailVerified = verifiedEmail != "" && strings.EqualFold(u.Email, verifiedEmail)
+}
+
+// CreateUser creates a new user. verifiedEmail is the user's email address
+// as verified by their identity provider, or "".
+func (i *UserInteractor) CreateUser(ctx context.Context, id, name, email, avatarURL, verifiedEmail string) (*domain.User, error) {
 	u := &domain.User{
 		ID:        id,
 		Name:      name,
 		AvatarURL: avatarURL,
 	}
+	setEmail(u, email, verifiedEmail)
 	if err := i.repo.Create(ctx, u); err != nil {
 		return nil, err
 	}
 	return u, nil
 }
 
-// GetUser returns a user by ID.
-func (i *UserInteractor) GetUser(ctx context.Context, id string) (*domain.User, error) {
-	return i.repo.GetByID(ctx, id)
+// GetUser returns a user by ID. The email address and notification
+// preferences are only shown to the user themselves.
+func (i *UserInteractor) GetUser(ctx context.Context, id, viewerID string) (*domain.User, error) {
+	u, err := i.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.ID != viewerID {
+		hidePrivate(u)
+	}
+	return u, nil
 }
 
-// UpdateUser updates a user profile.
-func (i *UserInteractor) UpdateUser(ctx context.Context, id, name, avatarURL string) (*domain.User, error) {
+// UpdateUser updates a user profile. verifiedEmail is the user's email
+// address as verified by their identity provider, or "".
+func (i *UserInteractor) UpdateUser(ctx context.Context, id, name, email, avatarURL, verifiedEmail string) (*domain.User, error) {
 	u, err := i.repo.GetByID(ctx, id)
-	if err != nil {
+	if errors.Is(err, domain.ErrNotFound) {
 		// If user doesn't exist, create it (upsert-like behavior for profile edit)
-		return i.CreateUser(ctx, id, name, avatarURL)
+		return i.CreateUser(ctx, id, name, email, avatarURL, verifiedEmail)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	if name != "" {
 		u.Name = name
 	}
+	setEmail(u, email, verifiedEmail)
 	if avatarURL != "" {
 		u.AvatarURL = avatarURL
 	}
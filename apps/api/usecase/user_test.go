@@ -0,0 +1,32 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/noa/circle-app/api/usecase"
+)
+
+func TestUpdateUserVerifiesOnlyTheTokenEmail(t *testing.T) {
+	f := newFixture(t)
+	users := usecase.NewUserInteractor(f.repos.User, nil, "")
+
+	tests := []struct {
+		name, email, verifiedEmail string
+		wantEmail                  string
+		wantVerified               bool
+	}{
+		{"first sign-in takes the token email", "", "me@example.com", "me@example.com", true},
+		{"another address is not verified", "other@example.com", "me@example.com", "other@example.com", false},
+		{"switching back verifies again", "ME@example.com", "me@example.com", "ME@example.com", true},
+		{"no token email verifies nothing", "", "", "ME@example.com", false},
+	}
+	for _, tt := range tests {
+		u, err := users.UpdateUser(f.ctx, "u1", "山田", tt.email, "", tt.verifiedEmail)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Email != tt.wantEmail || u.EmailVerified != tt.wantVerified {
+			t.Errorf("%s: email %q verified %v, want %q %v", tt.name, u.Email, u.EmailVerified, tt.wantEmail, tt.wantVerified)
+		}
+	}
+}
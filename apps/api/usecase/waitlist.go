@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/noa/circle-app/api/domain"
+	"github.com/noa/circle-app/api/usecase/port"
+)
+
+// eventSeats keeps the RSVPs of an event within its capacity and the payment
+// records of its settlements in line with who holds a seat.
+type eventSeats struct {
+	rsvpRepo       port.RSVPRepository
+	settlementRepo port.SettlementRepository
+	paymentRepo    port.PaymentRepository
+	pricer         *settlementPricer
+	notifier       port.Notifier
+}
+
+// seat places rsvp among the existing RSVPs of an event and returns the other
+// RSVPs promoted from the waitlist as a result. Attendees keep the seat or
+// waitlist position they had; new attendees join the end of the waitlist and
+// get a seat only if one is free once everyone ahead of them is seated.
+func seat(capacity int, rsvps []*domain.RSVP, rsvp *domain.RSVP, now time.Time) []*domain.RSVP {
+	var prev *domain.RSVP
+	all := make([]*domain.RSVP, 0, len(rsvps)+1)
+	for _, r := range rsvps {
+		if r.UserID == rsvp.UserID {
+			prev = r
+			continue
+		}
+		all = append(all, r)
+	}
+	switch {
+	case !rsvp.Status.Attending():
+		rsvp.Waitlisted, rsvp.WaitlistedAt = false, time.Time{}
+	case prev != nil && prev.Status.Attending():
+		rsvp.Waitlisted, rsvp.WaitlistedAt = prev.Waitlisted, prev.WaitlistedAt
+	default:
+		rsvp.Waitlisted, rsvp.WaitlistedAt = true, now
+	}
+
+	var promoted []*domain.RSVP
+	for _, r := range promote(capacity, append(all, rsvp)) {
+		if r != rsvp {
+			promoted = append(promoted, r)
+		}
+	}
+	return promoted
+}
+
+// promote gives the free seats of an event to waitlisted RSVPs in the order
+// they joined the waitlist and returns the promoted RSVPs. A capacity of 0
+// seats everyone. Seats are never taken away when the capacity shrinks.
+func promote(capacity int, rsvps []*domain.RSVP) []*domain.RSVP {
+	confirmed := 0
+	var waiting []*domain.RSVP
+	for _, r := range rsvps {
+		switch {
+		case r.Confirmed():
+			confirmed++
+		case r.Waitlisted && r.Status.Attending():
+			waiting = append(waiting, r)
+		}
+	}
+	sort.SliceStable(waiting, func(i, j int) bool {
+		return waiting[i].WaitlistedAt.Before(waiting[j].WaitlistedAt)
+	})
+
+	var promoted []*domain.RSVP
+	for _, r := range waiting {
+		if capacity > 0 && confirmed >= capacity {
+			break
+		}
+		r.Waitlisted, r.WaitlistedAt = false, time.Time{}
+		confirmed++
+		promoted = append(promoted, r)
+	}
+	return promoted
+}
+
+// settlementPayment pairs a settlement of an event with the user's payment,
+// if any, and what the user owes under the settlement's pricing rules.
+type settlementPayment struct {
+	settlement *domain.Settlement
+	payment    *domain.Payment
+	amount     int
+}
+
+// save writes changed RSVPs of an event and syncs each user's payment records.
+// All reads happen before the first write, as Firestore transactions require.
+func (s *eventSeats) save(ctx context.Context, eventID string, changed []*domain.RSVP) error {
+	if len(changed) == 0 {
+		return nil
+	}
+	settlements, err := s.settlementRepo.GetByEvent(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	payments := make([][]settlementPayment, len(changed))
+	for k, r := range changed {
+		if payments[k], err = s.loadPayments(ctx, settlements, r); err != nil {
+			return err
+		}
+	}
+
+	for k, r := range changed {
+		if err := s.rsvpRepo.Upsert(ctx, r); err != nil {
+			return err
+		}
+		if err := s.syncPayments(ctx, payments[k], r.UserID, r.Confirmed()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPayments returns the user's payment record for each settlement and
+// prices it for the user's new RSVP. Split settlements are left out: their
+// shares stay with the attendees they were split among.
+func (s *eventSeats) loadPayments(ctx context.Context, settlements []*domain.Settlement, rsvp *domain.RSVP) ([]settlementPayment, error) {
+	result := make([]settlementPayment, 0, len(settlements))
+	for _, settlement := range settlements {
+		if settlement.Split != nil {
+			continue
+		}
+		payment, err := s.paymentRepo.GetBySettlementAndUser(ctx, settlement.ID, rsvp.UserID)
+		if err != nil {
+			return nil, err
+		}
+		subjects, err := s.pricer.subjects(ctx, settlement, []string{rsvp.UserID})
+		if err != nil {
+			return nil, err
+		}
+		subj := subjects[rsvp.UserID]
+		subj.RSVPStatus = rsvp.Status
+		result = append(result, settlementPayment{settlement: settlement, payment: payment, amount: settlement.PriceFor(subj)})
+	}
+	return result, nil
+}
+
+// syncPayments creates missing payment records when the user holds a seat
+// and removes unpaid ones when the user declines or is waitlisted. Unpaid
+// payments follow the price of the user's RSVP, such as a pro-rated LATE.
+// Payments that were already reported or confirmed are never changed.
+func (s *eventSeats) syncPayments(ctx context.Context, payments []settlementPayment, userID string, confirmed bool) error {
+	for _, sp := range payments {
+		unpaid := sp.payment != nil && sp.payment.Status == domain.PaymentUnpaid
+		switch {
+		case confirmed && sp.payment == nil:
+			payment := &domain.Payment{
+				SettlementID: sp.settlement.ID,
+				UserID:       userID,
+				Status:       domain.PaymentUnpaid,
+				Amount:       &sp.amount,
+			}
+			if err := s.paymentRepo.Create(ctx, payment); err != nil {
+				return err
+			}
+		case confirmed && unpaid && (sp.payment.Amount == nil || *sp.payment.Amount != sp.amount):
+			sp.payment.Amount = &sp.amount
+			if err := s.paymentRepo.Update(ctx, sp.payment); err != nil {
+				return err
+			}
+		case !confirmed && unpaid:
+			if err := s.paymentRepo.Delete(ctx, sp.payment.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notifyPromoted tells users promoted from the waitlist that they have a
+// seat. The promotion has already been saved, so failures are logged.
+func (s *eventSeats) notifyPromoted(ctx context.Context, event *domain.Event, promoted []*domain.RSVP) {
+	if len(promoted) == 0 {
+		return
+	}
+	userIDs := make([]string, 0, len(promoted))
+	for _, r := range promoted {
+		userIDs = append(userIDs, r.UserID)
+	}
+	n := &domain.Notification{
+		UserIDs:  userIDs,
+		CircleID: event.CircleID,
+		Title:    "参加確定のお知らせ",
+		Body:     fmt.Sprintf("キャンセル待ちだった「%s」（%s）の参加が確定しました", event.Title, formatSessionDate(event.StartAt)),
+	}
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		log.Printf("Warning: could not notify promoted attendees of event %s: %v", event.ID, err)
+	}
+}
@@ -17,17 +17,35 @@ interface RequestOptions {
     userId?: string;
 }
 
+// Returns the signed-in user's ID token (e.g. Firebase `user.getIdToken()`),
+// or null when nobody is signed in.
+export type IdTokenProvider = () => Promise<string | null>;
+
+let idTokenProvider: IdTokenProvider | null = null;
+
+// setIdTokenProvider is called by the sign-in flow. While a provider is set,
+// requests carry `Authorization: Bearer <ID token>`, which the API requires
+// unless it runs with AUTH_MODE=header.
+export function setIdTokenProvider(provider: IdTokenProvider | null) {
+    idTokenProvider = provider;
+}
+
 export async function apiRequest<T>(endpoint: string, options: RequestOptions = {}): Promise<T> {
     const { method = 'GET', body, userId } = options;
 
-    // Get current user ID from localStorage if not provided
-    const currentUserId = userId || (typeof window !== 'undefined' ? localStorage.getItem('current_user_id') : null) || DEFAULT_USER_ID;
-
     const headers: Record<string, string> = {
         'Content-Type': 'application/json',
-        'X-User-Id': currentUserId,
     };
 
+    const idToken = idTokenProvider ? await idTokenProvider() : null;
+    if (idToken) {
+        headers['Authorization'] = `Bearer ${idToken}`;
+    } else {
+        // Development only: the API trusts X-User-Id with AUTH_MODE=header.
+        // Get current user ID from localStorage if not provided
+        headers['X-User-Id'] = userId || (typeof window !== 'undefined' ? localStorage.getItem('current_user_id') : null) || DEFAULT_USER_ID;
+    }
+
     const url = `${API_BASE_URL}${endpoint}`;
 
     try {